@@ -1,5 +1,5 @@
 import type {Request, Response} from 'express';
-import {verify} from 'jsonwebtoken';
+import {sign, verify} from 'jsonwebtoken';
 
 export const authenticateUser = async(req: Request, res: Response) => {
     try {
@@ -27,6 +27,23 @@ export const authenticateUser = async(req: Request, res: Response) => {
         res.setHeader('X-User-Email', (payload as any).email);
         res.setHeader('X-User-Name', (payload as any).user_metadata?.full_name || '');
 
+        // short lived assertion of the verified user for services behind the gateway
+        const assertionSecret = process.env.GATEWAY_ASSERTION_SECRET;
+
+        if (assertionSecret) {
+            const assertion = sign(
+                {
+                    sub: (payload as any).sub,
+                    email: (payload as any).email,
+                    name: (payload as any).user_metadata?.full_name || '',
+                },
+                assertionSecret,
+                {algorithm: 'HS256', expiresIn: '60s', issuer: 'fuse-gateway', audience: 'fuse-services'},
+            );
+
+            res.setHeader('X-User-Assertion', assertion);
+        }
+
         return res.status(200).json({message: 'User authenticated'});
     } catch (error) {
         return res.status(401).json({message: 'Invalid token'});
@@ -7,6 +7,7 @@ import 'dotenv/config';
 @Injectable()
 export class AccessService {
   private subscriptionServiceUrl = process.env.SUBSCRIPTION_SERVICE_URL;
+  private subscriptionServiceToken = process.env.SUBSCRIPTION_SERVICE_TOKEN;
 
   constructor() {}
 
@@ -18,6 +19,7 @@ export class AccessService {
           method: 'GET',
           headers: {
             'Content-Type': 'application/json',
+            Authorization: `Bearer ${this.subscriptionServiceToken}`,
           },
         },
       );
@@ -3,6 +3,7 @@ import { Injectable } from '@nestjs/common';
 @Injectable()
 export class SubscriptionsService {
     private subscriptionServiceUrl = process.env.SUBSCRIPTION_SERVICE_URL;
+    private subscriptionServiceToken = process.env.SUBSCRIPTION_SERVICE_TOKEN;
 
     constructor() { }
 
@@ -14,6 +15,7 @@ export class SubscriptionsService {
                     method: 'DELETE',
                     headers: {
                         'Content-Type': 'application/json',
+                        Authorization: `Bearer ${this.subscriptionServiceToken}`,
                     },
                 },
             );
@@ -37,16 +37,16 @@ function RouteComponent() {
       }
 
       const data = await plansResponse.json();
-      return data.plans;
+      return data;
     } catch (error) {
       toast.error('Failed to fetch subscription plans');
-      return {};
+      return { plans: {}, currency: 'INR' };
     }
   }
 
   async function handlePlanUpgrade(plan_type: string) {
     try {
-      const orderResponse = await fetch(`${SUBSCRIPTION_URL}/payment/initialize?plan_type=${plan_type}`, {
+      const orderResponse = await fetch(`${SUBSCRIPTION_URL}/payment/initialize?plan_type=${plan_type}&currency=${plans.data?.currency ?? ''}`, {
         method: 'GET',
         headers: {
           'Authorization': `Bearer ${getToken()}`,
@@ -61,8 +61,8 @@ function RouteComponent() {
 
       const options = {
         "key": RAZORPAY_KEY_ID, // Enter the Key ID generated from the Dashboard
-        "amount": orderData.plan.Price, // Amount is in currency subunits.
-        "currency": "INR",
+        "amount": orderData.order.amount, // Amount is in currency subunits.
+        "currency": orderData.order.currency,
         "name": "Fuse", //your business name
         "description": `${plan_type} Subscription Plan`,
         "image": "https://example.com/your_logo",
@@ -116,15 +116,16 @@ function RouteComponent() {
 
   return <div className='flex-1 p-10 flex justify-center items-center'>
     <div className='relative bg-white p-5 rounded-xl grid lg:grid-cols-3 md:grid-cols-2 grid-cols-1 gap-12'>
-      {Object.keys(plans.data || {}).map((planKey) => {
-        const plan = plans.data[planKey];
+      {Object.keys(plans.data?.plans || {}).map((planKey) => {
+        const plan = plans.data.plans[planKey];
+        const currency = plans.data.currency;
         const isCurrentPlan = currentPlan.plan_type?.toLowerCase() === planKey;
 
         return <div key={planKey} className='p-3 rounded-lg flex flex-col justify-between space-y-5 w-64'>
           <div className='space-y-5'>
             <div className='space-x-1'>
               <span className='text-4xl font-semibold'>
-                &#8377; {plan.Price}
+                {new Intl.NumberFormat(undefined, { style: 'currency', currency }).format(plan.Prices[currency])}
               </span>
               <span className='text-gray-600'> /month</span>
             </div>
@@ -44,7 +44,7 @@ function RouteComponent() {
             <div className='space-y-5'>
               <div className='space-x-1'>
                 <span className='text-4xl font-semibold'>
-                  &#8377; {plan.Price}
+                  &#8377; {plan.Prices.INR}
                 </span>
                 <span className='text-secondary'> /month</span>
               </div>
@@ -0,0 +1,94 @@
+// Command reconcile compares razorpay payments and refunds with the local subscriptions
+// and refunds tables and prints a discrepancy report as JSON.
+//
+//	go run ./cmd/reconcile -from 2026-10-01 -to 2026-10-08 [-repair]
+//
+// It exits with status 1 when discrepancies remain unresolved.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/joho/godotenv"
+	"github.com/parbhat-cpp/fuse/subscriptions/internal/config"
+	"github.com/parbhat-cpp/fuse/subscriptions/internal/db/sqlc"
+	"github.com/parbhat-cpp/fuse/subscriptions/internal/logging"
+	"github.com/parbhat-cpp/fuse/subscriptions/internal/services"
+)
+
+func main() {
+	now := time.Now()
+
+	from_flag := flag.String("from", now.AddDate(0, 0, -1).Format(time.DateOnly), "start of the range, a date or RFC 3339 timestamp")
+	to_flag := flag.String("to", now.Format(time.RFC3339), "end of the range (exclusive), a date or RFC 3339 timestamp")
+	repair := flag.Bool("repair", false, "record refunds that razorpay made but are missing locally")
+	flag.Parse()
+
+	from, err := parseTime(*from_flag)
+
+	if err != nil {
+		logging.Fatal("Invalid -from", "error", err)
+	}
+
+	to, err := parseTime(*to_flag)
+
+	if err != nil {
+		logging.Fatal("Invalid -to", "error", err)
+	}
+
+	err = godotenv.Load()
+
+	if err != nil {
+		logging.Fatal("Cannot load .env", "error", err)
+	}
+
+	// the report goes to stdout, logs to stderr
+	logging.Setup(os.Stderr, config.LoadEnv().LOG_LEVEL)
+
+	dbPool := config.ConnectDB()
+	defer dbPool.Close()
+
+	query := sqlc.New(dbPool)
+
+	reconciliationService := services.NewReconciliationService(query, services.NewRefundService(query, dbPool))
+
+	report, err := reconciliationService.Reconcile(context.Background(), from, to, *repair)
+
+	if err != nil {
+		logging.Fatal("Reconciliation failed", "error", err)
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+
+	err = encoder.Encode(report)
+
+	if err != nil {
+		logging.Fatal("Unable to write report", "error", err)
+	}
+
+	if report.Unresolved() > 0 {
+		dbPool.Close()
+		os.Exit(1)
+	}
+}
+
+// parseTime accepts a date, taken as midnight UTC, or an RFC 3339 timestamp.
+func parseTime(value string) (time.Time, error) {
+	if t, err := time.Parse(time.DateOnly, value); err == nil {
+		return t, nil
+	}
+
+	t, err := time.Parse(time.RFC3339, value)
+
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%q is neither a date nor an RFC 3339 timestamp", value)
+	}
+
+	return t, nil
+}
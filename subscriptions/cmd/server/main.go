@@ -1,8 +1,14 @@
 package main
 
 import (
-	"log"
+	"context"
+	"errors"
+	"log/slog"
 	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"github.com/joho/godotenv"
 	"github.com/labstack/echo/v4"
@@ -10,30 +16,89 @@ import (
 	"github.com/parbhat-cpp/fuse/subscriptions/internal/config"
 	"github.com/parbhat-cpp/fuse/subscriptions/internal/db/sqlc"
 	"github.com/parbhat-cpp/fuse/subscriptions/internal/handlers"
+	"github.com/parbhat-cpp/fuse/subscriptions/internal/identity"
+	"github.com/parbhat-cpp/fuse/subscriptions/internal/jobs"
+	"github.com/parbhat-cpp/fuse/subscriptions/internal/logging"
+	"github.com/parbhat-cpp/fuse/subscriptions/internal/metrics"
 	"github.com/parbhat-cpp/fuse/subscriptions/internal/middlewares"
+	"github.com/parbhat-cpp/fuse/subscriptions/internal/openapi"
 	"github.com/parbhat-cpp/fuse/subscriptions/internal/services"
+	"github.com/parbhat-cpp/fuse/subscriptions/internal/tracing"
+	"github.com/parbhat-cpp/fuse/subscriptions/lib"
 )
 
 func main() {
 	err := godotenv.Load()
 
 	if err != nil {
-		log.Fatalf("Cannot load .env: %s", err)
+		logging.Fatal("Cannot load .env", "error", err)
+	}
+
+	cfg := config.LoadEnv()
+
+	logging.Setup(os.Stdout, cfg.LOG_LEVEL)
+
+	// readiness fails while the config is invalid, startup only warns
+	if err := cfg.Validate(); err != nil {
+		slog.Warn("Invalid config", "error", err)
+	}
+
+	// SIGTERM and interrupts start a graceful shutdown
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	shutdownTracing, err := tracing.Init(context.Background(), tracing.Options{
+		Endpoint:    cfg.OTEL_EXPORTER_OTLP_ENDPOINT,
+		ServiceName: cfg.OTEL_SERVICE_NAME,
+		SampleRatio: cfg.OTEL_TRACES_SAMPLE_RATIO,
+	})
+
+	if err != nil {
+		logging.Fatal("Cannot set up tracing", "error", err)
+	}
+
+	// client ips, recorded on payment attempts, are read from X-Forwarded-For only
+	// when nginx sent the request
+	ip_extractor, err := middlewares.ClientIPExtractor(cfg.TRUSTED_PROXY_CIDRS)
+
+	if err != nil {
+		logging.Fatal("Cannot start", "error", err)
 	}
 
 	e := echo.New()
+	e.HideBanner = true
+	e.HidePort = true
+	e.IPExtractor = ip_extractor
 
 	dbPool := config.ConnectDB()
-	defer dbPool.Close()
+
+	metrics.RegisterPool(dbPool)
 
 	query := sqlc.New(dbPool)
 
-	e.Use(middleware.Logger())
+	// errors are rendered as one JSON envelope carrying the request id
+	e.HTTPErrorHandler = handlers.HTTPErrorHandler
+	e.Use(middlewares.RequestIDMiddleware())
+	e.Use(tracing.HTTPMiddleware(cfg.OTEL_SERVICE_NAME))
+	e.Use(metrics.HTTPMiddleware)
 	e.Use(middleware.Recover())
 	e.Use(middlewares.PanicRecoveryMiddleware)
 
-	// subscription v1 api
-	apiV1 := e.Group("/v1")
+	// internal routes get their own listener when INTERNAL_PORT is set
+	internal := e
+
+	if cfg.INTERNAL_PORT != "" {
+		internal = echo.New()
+		internal.HideBanner = true
+		internal.HidePort = true
+		internal.IPExtractor = ip_extractor
+		internal.HTTPErrorHandler = handlers.HTTPErrorHandler
+		internal.Use(middlewares.RequestIDMiddleware())
+		internal.Use(tracing.HTTPMiddleware(cfg.OTEL_SERVICE_NAME))
+		internal.Use(metrics.HTTPMiddleware)
+		internal.Use(middleware.Recover())
+		internal.Use(middlewares.PanicRecoveryMiddleware)
+	}
 
 	// access control: specifies if a user can access a resource based on their current subscription
 	accessService := services.NewAccessService(query)
@@ -47,23 +112,194 @@ func main() {
 	usageService := services.NewUsageService(query)
 	usageHandler := handlers.NewUsageHandler(usageService)
 
+	// invoices issued for each payment and credit notes for refunds
+	invoiceService := services.NewInvoiceService(query)
+	invoiceHandler := handlers.NewInvoiceHandler(invoiceService)
+
+	// background jobs, stopped together on shutdown
+	workers := jobs.NewGroup()
+
+	// plan changes, cancellations and free trials
+	subscriptionService := services.NewSubscriptionService(query, dbPool)
+	subscriptionHandler := handlers.NewSubscriptionHandler(subscriptionService)
+
+	workers.Every("apply plan changes", time.Minute, subscriptionService.ApplyDuePlanChanges)
+	workers.Every("sync subscription statuses", time.Minute, subscriptionService.SyncSubscriptionStatuses)
+	workers.Every("process trials", time.Minute, subscriptionService.ProcessTrials)
+	workers.Every("send renewal reminders", time.Hour, subscriptionService.SendRenewalReminders)
+
+	// notifications and domain events written to the outbox are delivered in the background
+	outboxService := services.NewOutboxService(query, lib.NewNotificationClient())
+	outboxHandler := handlers.NewOutboxHandler(outboxService)
+
+	workers.Every("dispatch outbox", 10*time.Second, outboxService.DispatchOutbox)
+
+	// refunds are queued and submitted to razorpay in the background
+	refundService := services.NewRefundService(query, dbPool)
+	refundHandler := handlers.NewRefundHandler(refundService)
+
+	workers.Every("process refunds", time.Minute, refundService.ProcessRefunds)
+
+	// razorpay payments and refunds checked against local records
+	reconciliationService := services.NewReconciliationService(query, refundService)
+
+	if hours := cfg.RECONCILE_INTERVAL_HOURS; hours > 0 {
+		workers.Every("reconcile payments", time.Duration(hours)*time.Hour, reconciliationService.ReconcileRecent)
+	}
+
+	// log of every payment verification attempt
+	paymentAttemptService := services.NewPaymentAttemptService(query)
+	paymentAttemptHandler := handlers.NewPaymentAttemptHandler(paymentAttemptService)
+
+	// promo codes
+	couponService := services.NewCouponService(query)
+	couponHandler := handlers.NewCouponHandler(couponService)
+
 	// user data deletion
 	deletionService := services.NewDeletionService(query, dbPool)
 	deletionHandler := handlers.NewDeletionHandler(deletionService)
 
-	routes := []handlers.Route{}
+	// liveness and readiness probes
+	healthService := services.NewHealthService(dbPool)
+	healthHandler := handlers.NewHealthHandler(healthService)
+
+	api := handlers.API{
+		Access:         accessHandler,
+		Payment:        paymentHandler,
+		Usage:          usageHandler,
+		Invoice:        invoiceHandler,
+		Subscription:   subscriptionHandler,
+		Deletion:       deletionHandler,
+		Refund:         refundHandler,
+		Coupon:         couponHandler,
+		PaymentAttempt: paymentAttemptHandler,
+		Outbox:         outboxHandler,
+	}
 
-	// add routes for subscription v1
-	routes = append(routes, handlers.AccessRoutes(accessHandler)...)
-	routes = append(routes, handlers.PaymentRoutes(paymentHandler)...)
-	routes = append(routes, handlers.UsageRoutes(usageHandler)...)
-	routes = append(routes, handlers.DeletionRoutes(deletionHandler)...)
+	// requests are validated against the OpenAPI document after they are authenticated
+	spec, err := openapi.Load()
 
-	handlers.RegisterRoutes(apiV1, routes)
+	if err != nil {
+		logging.Fatal("Cannot load OpenAPI document", "error", err)
+	}
 
-	// to check microservice health
-	e.GET("/health", func(c echo.Context) error {
-		return c.String(http.StatusOK, "Hello, World!")
+	validator := openapi.ValidationMiddleware(spec)
+
+	// each audience is authenticated, rate limited and logged on its own
+	publicMiddleware := []echo.MiddlewareFunc{
+		middlewares.RequestLoggerMiddleware(string(handlers.AudiencePublic)),
+		middlewares.IdentityMiddleware(identity.NewVerifier()),
+		middlewares.RateLimitMiddleware(cfg.RATE_LIMIT_PUBLIC_RPS),
+		validator,
+	}
+	anonymousMiddleware := []echo.MiddlewareFunc{
+		middlewares.RequestLoggerMiddleware(string(handlers.AudienceAnonymous)),
+		middlewares.RateLimitMiddleware(cfg.RATE_LIMIT_PUBLIC_RPS),
+		validator,
+	}
+	internalMiddleware := []echo.MiddlewareFunc{
+		middlewares.RequestLoggerMiddleware(string(handlers.AudienceInternal)),
+		middlewares.RateLimitMiddleware(cfg.RATE_LIMIT_INTERNAL_RPS),
+		middlewares.ServiceTokenMiddleware,
+		validator,
+	}
+	adminMiddleware := []echo.MiddlewareFunc{
+		middlewares.RequestLoggerMiddleware(string(handlers.AudienceAdmin)),
+		middlewares.RateLimitMiddleware(cfg.RATE_LIMIT_ADMIN_RPS),
+		middlewares.AdminKeyMiddleware,
+		validator,
+	}
+
+	err = api.Register(e, internal, map[handlers.Audience][]echo.MiddlewareFunc{
+		handlers.AudiencePublic:    publicMiddleware,
+		handlers.AudienceAnonymous: anonymousMiddleware,
+		handlers.AudienceInternal:  internalMiddleware,
+		handlers.AudienceAdmin:     adminMiddleware,
 	})
-	e.Logger.Fatal(e.Start(":" + config.LoadEnv().PORT))
+
+	if err != nil {
+		logging.Fatal("Cannot start", "error", err)
+	}
+
+	err = openapi.CheckRoutes(spec, append(e.Routes(), internal.Routes()...))
+
+	if err != nil {
+		logging.Fatal("Cannot start", "error", err)
+	}
+
+	// the contract of the service
+	e.GET("/openapi.yaml", openapi.SpecYAMLHandler)
+	e.GET("/openapi.json", openapi.SpecJSONHandler(spec))
+
+	// probes; /health is kept for existing checks and only reports liveness
+	for _, server := range servers(e, internal) {
+		server.GET("/health", healthHandler.Live)
+		server.GET("/health/live", healthHandler.Live)
+		server.GET("/health/ready", healthHandler.Ready)
+	}
+
+	// prometheus metrics, served with the internal routes and, like them, only to
+	// scrapers holding a service token since internal may be the public server
+	internal.GET("/metrics", metrics.Handler(), middlewares.ServiceTokenMiddleware)
+
+	server_errors := make(chan error, 2)
+	start := func(server *echo.Echo, port string) {
+		slog.Info("Server listening", "port", port)
+
+		if err := server.Start(":" + port); !errors.Is(err, http.ErrServerClosed) {
+			server_errors <- err
+		}
+	}
+
+	go start(e, cfg.PORT)
+
+	if internal != e {
+		go start(internal, cfg.INTERNAL_PORT)
+	}
+
+	select {
+	case <-ctx.Done():
+		slog.Info("Shutting down")
+	case err := <-server_errors:
+		slog.Error("Server stopped, shutting down", "error", err)
+	}
+
+	stop()
+
+	shutdown_ctx, cancel := context.WithTimeout(context.Background(), time.Duration(cfg.SHUTDOWN_TIMEOUT_SECONDS)*time.Second)
+	defer cancel()
+
+	// stop accepting connections and let the requests in flight finish
+	for _, server := range servers(e, internal) {
+		if err := server.Shutdown(shutdown_ctx); err != nil {
+			slog.Error("Unable to drain HTTP server", "error", err)
+		}
+	}
+
+	// no new runs are started; a run in progress finishes its transaction
+	if err := workers.Stop(shutdown_ctx); err != nil {
+		slog.Error("Background jobs did not finish in time", "error", err)
+	}
+
+	// one last delivery of the events written by the drained requests and jobs
+	if err := jobs.RunOnce(shutdown_ctx, "flush outbox", outboxService.DispatchOutbox); err != nil {
+		slog.Error("Outbox was not flushed in time", "error", err)
+	}
+
+	if err := shutdownTracing(shutdown_ctx); err != nil {
+		slog.Error("Unable to flush traces", "error", err)
+	}
+
+	dbPool.Close()
+
+	slog.Info("Shutdown complete")
+}
+
+// servers returns the public server and, when it is a separate one, the internal server.
+func servers(e *echo.Echo, internal *echo.Echo) []*echo.Echo {
+	if internal == e {
+		return []*echo.Echo{e}
+	}
+
+	return []*echo.Echo{e, internal}
 }
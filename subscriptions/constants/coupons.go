@@ -0,0 +1,12 @@
+package constants
+
+type CouponDiscountType string
+
+const (
+	CouponDiscountPercentage CouponDiscountType = "percentage" // discount_value is a percentage of the order amount
+	CouponDiscountFixed      CouponDiscountType = "fixed"      // discount_value is an amount in the coupon currency's minor unit
+)
+
+// MinimumOrderAmount is the smallest amount razorpay accepts for an order, in the
+// currency's minor unit.
+const MinimumOrderAmount int64 = 100
@@ -0,0 +1,68 @@
+package constants
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"golang.org/x/text/language"
+)
+
+type Currency string
+
+const (
+	CurrencyINR Currency = "INR"
+	CurrencyUSD Currency = "USD"
+	CurrencyEUR Currency = "EUR"
+)
+
+// DefaultCurrency is charged when neither the request nor the user's locale picks one.
+const DefaultCurrency = CurrencyINR
+
+// every supported currency has two decimal places, so amounts in minor units are price * 100
+var SupportedCurrencies = []Currency{CurrencyINR, CurrencyUSD, CurrencyEUR}
+
+var euroRegions = []string{
+	"AT", "BE", "HR", "CY", "EE", "FI", "FR", "DE", "GR", "IE",
+	"IT", "LV", "LT", "LU", "MT", "NL", "PT", "SK", "SI", "ES",
+}
+
+// ParseCurrency returns the supported currency for an ISO 4217 code.
+func ParseCurrency(code string) (Currency, error) {
+	currency := Currency(strings.ToUpper(strings.TrimSpace(code)))
+
+	if !slices.Contains(SupportedCurrencies, currency) {
+		return "", fmt.Errorf("Unsupported currency %s", code)
+	}
+
+	return currency, nil
+}
+
+// CurrencyForLocale picks a currency from an Accept-Language header, falling back to
+// DefaultCurrency when no listed locale maps to a supported currency.
+func CurrencyForLocale(accept_language string) Currency {
+	tags, _, err := language.ParseAcceptLanguage(accept_language)
+
+	if err != nil {
+		return DefaultCurrency
+	}
+
+	for _, tag := range tags {
+		region, confidence := tag.Region()
+
+		if confidence == language.No {
+			continue
+		}
+
+		switch code := region.String(); {
+		case code == "IN":
+			return CurrencyINR
+		case code == "US":
+			return CurrencyUSD
+		case slices.Contains(euroRegions, code):
+			return CurrencyEUR
+		}
+	}
+
+	return DefaultCurrency
+}
@@ -0,0 +1,8 @@
+package constants
+
+type PaymentOrderStatus string
+
+const (
+	PaymentOrderStatusCreated PaymentOrderStatus = "created"
+	PaymentOrderStatusPaid    PaymentOrderStatus = "paid"
+)
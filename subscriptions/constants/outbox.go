@@ -0,0 +1,17 @@
+package constants
+
+type OutboxTopic string
+
+const (
+	OutboxTopicNotification              OutboxTopic = "notification"
+	OutboxTopicSubscriptionCreated       OutboxTopic = "subscription.created"
+	OutboxTopicSubscriptionStatusChanged OutboxTopic = "subscription.status_changed"
+)
+
+type OutboxStatus string
+
+const (
+	OutboxStatusPending   OutboxStatus = "pending"
+	OutboxStatusDelivered OutboxStatus = "delivered"
+	OutboxStatusDead      OutboxStatus = "dead" // gave up after OUTBOX_MAX_ATTEMPTS, needs a manual retry
+)
@@ -0,0 +1,22 @@
+package constants
+
+type PaymentAttemptOutcome string
+
+const (
+	PaymentAttemptSucceeded PaymentAttemptOutcome = "succeeded"
+	PaymentAttemptFailed    PaymentAttemptOutcome = "failed"
+)
+
+type PaymentAttemptReason string
+
+const (
+	PaymentAttemptReasonVerified         PaymentAttemptReason = "verified"
+	PaymentAttemptReasonDuplicatePayment PaymentAttemptReason = "duplicate_payment" // payment id already has a subscription
+	PaymentAttemptReasonInvalidSignature PaymentAttemptReason = "invalid_signature"
+	PaymentAttemptReasonDuplicateOrder   PaymentAttemptReason = "duplicate_order" // order already has a subscription
+	PaymentAttemptReasonOrderNotFound    PaymentAttemptReason = "order_not_found"
+	PaymentAttemptReasonOrderMismatch    PaymentAttemptReason = "order_mismatch" // order belongs to another user or plan
+	PaymentAttemptReasonUpgradeNotActive PaymentAttemptReason = "upgrade_not_active"
+	PaymentAttemptReasonCouponExhausted  PaymentAttemptReason = "coupon_exhausted" // coupon reached a redemption cap after the order was created
+	PaymentAttemptReasonInternalError    PaymentAttemptReason = "internal_error"   // database or other server side failure
+)
@@ -0,0 +1,9 @@
+package constants
+
+type PlanChangeStatus string
+
+const (
+	PlanChangeStatusPending  PlanChangeStatus = "pending"
+	PlanChangeStatusApplied  PlanChangeStatus = "applied"
+	PlanChangeStatusCanceled PlanChangeStatus = "canceled"
+)
@@ -2,6 +2,8 @@ package constants
 
 import (
 	"errors"
+	"fmt"
+	"math"
 
 	"github.com/google/uuid"
 	"github.com/parbhat-cpp/fuse/subscriptions/internal/config"
@@ -11,7 +13,7 @@ type Plan struct {
 	ID           uuid.UUID
 	Name         string
 	Description  string
-	Price        float64 // in INR
+	Prices       map[Currency]float64
 	ValidMonths  int
 	Features     []string
 	FeaturesJson map[string]int
@@ -25,7 +27,7 @@ func GetPlans() map[string]Plan {
 			ID:          cfg.SUBSCRIPTION_PLANS_ID["free"],
 			Name:        "Free",
 			Description: "Perfect for getting started — explore core features with limited access.",
-			Price:       0,
+			Prices:      map[Currency]float64{CurrencyINR: 0, CurrencyUSD: 0, CurrencyEUR: 0},
 			ValidMonths: -1, // forever
 			Features: []string{
 				"Create/Join rooms",
@@ -39,7 +41,7 @@ func GetPlans() map[string]Plan {
 			ID:          cfg.SUBSCRIPTION_PLANS_ID["basic"],
 			Name:        "Basic",
 			Description: "Ideal for regular users who want more access, flexibility, and control.",
-			Price:       149,
+			Prices:      map[Currency]float64{CurrencyINR: 149, CurrencyUSD: 2.99, CurrencyEUR: 2.79},
 			ValidMonths: 1,
 			Features: []string{
 				"Features of Free Plan with additional access and limits",
@@ -53,7 +55,7 @@ func GetPlans() map[string]Plan {
 			ID:          cfg.SUBSCRIPTION_PLANS_ID["pro"],
 			Name:        "Pro",
 			Description: "Built for power users — unlock full features, priority access, and maximum limits.",
-			Price:       399,
+			Prices:      map[Currency]float64{CurrencyINR: 399, CurrencyUSD: 6.99, CurrencyEUR: 6.49},
 			ValidMonths: 1,
 			Features: []string{
 				"Features of Free Plan with additional access and limits",
@@ -78,3 +80,14 @@ func GetPlanByID(id uuid.UUID) (*Plan, error) {
 	}
 	return nil, errors.New("plan not found")
 }
+
+// Amount returns the plan price in the minor unit of the currency (paise, cents).
+func (p Plan) Amount(currency Currency) (int64, error) {
+	price, exists := p.Prices[currency]
+
+	if !exists {
+		return 0, fmt.Errorf("%s plan is not sold in %s", p.Name, currency)
+	}
+
+	return int64(math.Round(price * 100)), nil
+}
@@ -0,0 +1,13 @@
+package constants
+
+type DiscrepancyKind string
+
+const (
+	DiscrepancyCapturedWithoutSubscription DiscrepancyKind = "captured_without_subscription" // money taken, no subscription granted
+	DiscrepancySubscriptionWithoutPayment  DiscrepancyKind = "subscription_without_payment"  // subscription granted, payment not captured
+	DiscrepancyAmountMismatch              DiscrepancyKind = "amount_mismatch"
+	DiscrepancyRefundNotRecorded           DiscrepancyKind = "refund_not_recorded" // refunded on razorpay, missing locally
+	DiscrepancyRefundFailed                DiscrepancyKind = "refund_failed"       // recorded locally, rejected by razorpay
+	DiscrepancyRefundMissingOnProvider     DiscrepancyKind = "refund_missing_on_provider"
+	DiscrepancyRefundAmountMismatch        DiscrepancyKind = "refund_amount_mismatch"
+)
@@ -0,0 +1,19 @@
+package constants
+
+type RefundStatus string
+
+const (
+	RefundStatusPending   RefundStatus = "pending"   // recorded, not yet accepted by razorpay
+	RefundStatusSubmitted RefundStatus = "submitted" // accepted by razorpay, waiting to be processed
+	RefundStatusProcessed RefundStatus = "processed"
+	RefundStatusFailed    RefundStatus = "failed" // rejected by razorpay or out of attempts
+)
+
+type RefundReason string
+
+const (
+	RefundReasonCancellation      RefundReason = "cancellation"
+	RefundReasonPlanChange        RefundReason = "plan_change"
+	RefundReasonPaymentNotApplied RefundReason = "payment_not_applied" // verified payment that could not be turned into a subscription
+	RefundReasonReconciliation    RefundReason = "reconciliation"      // made on razorpay, recorded by reconciliation
+)
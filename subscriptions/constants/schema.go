@@ -0,0 +1,5 @@
+package constants
+
+// SchemaVersion is the latest migration in migrations/. The service is not ready until
+// the database is migrated to at least this version, so bump it with every migration.
+const SchemaVersion int64 = 20
@@ -0,0 +1,33 @@
+package constants
+
+type SubscriptionStatus string
+
+const (
+	SubscriptionStatusPending  SubscriptionStatus = "pending"  // paid, period not started yet
+	SubscriptionStatusTrialing SubscriptionStatus = "trialing" // free trial of a paid plan
+	SubscriptionStatusActive   SubscriptionStatus = "active"
+	SubscriptionStatusPastDue  SubscriptionStatus = "past_due"
+	SubscriptionStatusCanceled SubscriptionStatus = "canceled" // keeps access until valid_until
+	SubscriptionStatusExpired  SubscriptionStatus = "expired"
+	SubscriptionStatusRefunded SubscriptionStatus = "refunded"
+)
+
+var subscriptionTransitions = map[SubscriptionStatus][]SubscriptionStatus{
+	SubscriptionStatusPending:  {SubscriptionStatusActive, SubscriptionStatusCanceled, SubscriptionStatusRefunded},
+	SubscriptionStatusTrialing: {SubscriptionStatusActive, SubscriptionStatusCanceled, SubscriptionStatusExpired},
+	SubscriptionStatusActive:   {SubscriptionStatusPastDue, SubscriptionStatusCanceled, SubscriptionStatusExpired, SubscriptionStatusRefunded},
+	SubscriptionStatusPastDue:  {SubscriptionStatusActive, SubscriptionStatusCanceled, SubscriptionStatusExpired},
+	SubscriptionStatusCanceled: {SubscriptionStatusExpired, SubscriptionStatusRefunded},
+	SubscriptionStatusExpired:  {SubscriptionStatusRefunded},
+	SubscriptionStatusRefunded: {},
+}
+
+// CanTransitionSubscription reports whether a subscription may move from one status to another.
+func CanTransitionSubscription(from SubscriptionStatus, to SubscriptionStatus) bool {
+	for _, next := range subscriptionTransitions[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
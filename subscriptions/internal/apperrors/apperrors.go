@@ -0,0 +1,107 @@
+package apperrors
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Error is an error a caller can be told about. Message is safe to send to the caller;
+// the cause in Err is internal and only logged.
+//
+// Services declare their errors as sentinels with New and add details with Withf, so
+// errors.Is keeps matching the sentinel:
+//
+//	var ErrInvalidCoupon = apperrors.New(apperrors.CodeInvalidRequest, "invalid coupon")
+//	return ErrInvalidCoupon.Withf("code %s has expired", code)
+type Error struct {
+	Code    Code
+	Message string
+	// extra payload sent with the error, e.g. the quota left when access is denied
+	Data any
+	Err  error
+
+	parent *Error
+}
+
+var ErrUnauthorized = New(CodeUnauthorized, "unauthorized")
+var ErrInternal = New(CodeInternal, "internal error")
+
+func New(code Code, message string) *Error {
+	return &Error{
+		Code:    code,
+		Message: message,
+	}
+}
+
+// InvalidRequest returns an error for a request that failed validation in a handler.
+func InvalidRequest(message string) *Error {
+	return New(CodeInvalidRequest, message)
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return e.Message + ": " + e.Err.Error()
+	}
+	return e.Message
+}
+
+func (e *Error) Unwrap() []error {
+	errs := []error{}
+
+	if e.parent != nil {
+		errs = append(errs, e.parent)
+	}
+
+	if e.Err != nil {
+		errs = append(errs, e.Err)
+	}
+
+	return errs
+}
+
+// Withf returns e with a detail the caller may see appended to its message.
+func (e *Error) Withf(format string, args ...any) *Error {
+	derived := e.derive()
+	derived.Message = e.Message + ": " + fmt.Sprintf(format, args...)
+	return derived
+}
+
+// Wrap returns e caused by err. err is logged but not sent to the caller.
+func (e *Error) Wrap(err error) *Error {
+	derived := e.derive()
+	derived.Err = err
+	return derived
+}
+
+// WithData returns e with a payload sent along with it.
+func (e *Error) WithData(data any) *Error {
+	derived := e.derive()
+	derived.Data = data
+	return derived
+}
+
+func (e *Error) derive() *Error {
+	return &Error{
+		Code:    e.Code,
+		Message: e.Message,
+		Data:    e.Data,
+		Err:     e.Err,
+		parent:  e,
+	}
+}
+
+/**
+ * Returns the outermost Error in err's chain. Any other error is internal and is
+ * returned as ErrInternal caused by err.
+ * @param err: error
+ * @return *Error
+ */
+func From(err error) *Error {
+	var app_err *Error
+
+	if errors.As(err, &app_err) {
+		return app_err
+	}
+
+	return ErrInternal.Wrap(err)
+}
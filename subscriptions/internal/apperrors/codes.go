@@ -0,0 +1,73 @@
+package apperrors
+
+import "net/http"
+
+// Code identifies the kind of an error. Clients switch on the code, never on the message.
+type Code string
+
+const (
+	CodeInvalidRequest Code = "invalid_request"
+	CodeUnauthorized   Code = "unauthorized"
+	CodeForbidden      Code = "forbidden"
+	CodeNotFound       Code = "not_found"
+	CodeConflict       Code = "conflict"
+	// a quota of the user's plan is used up
+	CodeQuotaExceeded Code = "quota_exceeded"
+	// the caller sent too many requests
+	CodeRateLimited Code = "rate_limited"
+	// a payment was made but could not be applied, and is refunded
+	CodePaymentFailed Code = "payment_failed"
+	// razorpay or another provider failed
+	CodeUpstream Code = "upstream_error"
+	CodeInternal Code = "internal_error"
+)
+
+var statuses = map[Code]int{
+	CodeInvalidRequest: http.StatusBadRequest,
+	CodeUnauthorized:   http.StatusUnauthorized,
+	CodeForbidden:      http.StatusForbidden,
+	CodeNotFound:       http.StatusNotFound,
+	CodeConflict:       http.StatusConflict,
+	CodeQuotaExceeded:  http.StatusTooManyRequests,
+	CodeRateLimited:    http.StatusTooManyRequests,
+	CodePaymentFailed:  http.StatusPaymentRequired,
+	CodeUpstream:       http.StatusBadGateway,
+	CodeInternal:       http.StatusInternalServerError,
+}
+
+// Status returns the HTTP status errors with the code are served with.
+func (c Code) Status() int {
+	status, exists := statuses[c]
+
+	if !exists {
+		return http.StatusInternalServerError
+	}
+
+	return status
+}
+
+// CodeForStatus returns the code of an HTTP status, for errors raised by echo itself.
+func CodeForStatus(status int) Code {
+	switch status {
+	case http.StatusBadRequest, http.StatusRequestEntityTooLarge, http.StatusUnsupportedMediaType:
+		return CodeInvalidRequest
+	case http.StatusUnauthorized:
+		return CodeUnauthorized
+	case http.StatusForbidden:
+		return CodeForbidden
+	case http.StatusNotFound, http.StatusMethodNotAllowed:
+		return CodeNotFound
+	case http.StatusConflict:
+		return CodeConflict
+	case http.StatusTooManyRequests:
+		return CodeRateLimited
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return CodeUpstream
+	}
+
+	if status >= 400 && status < 500 {
+		return CodeInvalidRequest
+	}
+
+	return CodeInternal
+}
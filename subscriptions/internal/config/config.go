@@ -2,17 +2,97 @@ package config
 
 import (
 	"os"
+	"strconv"
+	"strings"
 
 	"github.com/google/uuid"
 )
 
 type Config struct {
-	DB_URL                string
-	SUBSCRIPTION_PLANS_ID map[string]uuid.UUID
-	PORT                  string
-	RAZORPAY_API_KEY      string
-	RAZORPAY_API_SECRET   string
-	NOTIFICATION_URL      string
+	DB_URL                  string
+	SUBSCRIPTION_PLANS_ID   map[string]uuid.UUID
+	PORT                    string
+	INTERNAL_PORT           string
+	RAZORPAY_API_KEY        string
+	RAZORPAY_API_SECRET     string
+	RAZORPAY_WEBHOOK_SECRET string
+	NOTIFICATION_URL        string
+
+	// notification client; retries are per delivery attempt of an outbox event
+	NOTIFICATION_TIMEOUT_SECONDS          int
+	NOTIFICATION_MAX_RETRIES              int
+	NOTIFICATION_BREAKER_THRESHOLD        int
+	NOTIFICATION_BREAKER_COOLDOWN_SECONDS int
+
+	// refund policy applied when a subscription is cancelled with a refund
+	REFUND_FULL_REFUND_DAYS int
+	REFUND_PRORATE          bool
+
+	// attempts at submitting a refund to razorpay before it is marked failed
+	REFUND_MAX_ATTEMPTS int
+
+	ADMIN_API_KEY string
+
+	// bearer tokens other services use for internal routes; more than one while rotating
+	SERVICE_TOKENS []string
+
+	// networks of the reverse proxies whose X-Forwarded-For is trusted for client ips;
+	// with none set the address of the connection is used
+	TRUSTED_PROXY_CIDRS []string
+
+	// end users are verified from their supabase access token, signed with the legacy
+	// jwt secret or a key from the jwks, or from an assertion signed by the gateway
+	SUPABASE_JWT_SECRET      string
+	SUPABASE_JWKS_URL        string
+	SUPABASE_JWT_ISSUER      string
+	SUPABASE_JWT_AUDIENCE    string
+	GATEWAY_ASSERTION_SECRET string
+
+	// requests a second per caller for each route audience, 0 turns the limit off
+	RATE_LIMIT_PUBLIC_RPS   int
+	RATE_LIMIT_INTERNAL_RPS int
+	RATE_LIMIT_ADMIN_RPS    int
+
+	// free trial offered once per user
+	TRIAL_PLAN           string
+	TRIAL_DAYS           int
+	TRIAL_REMINDER_HOURS int
+
+	// notifications sent ahead of a subscription ending and when a quota is nearly used;
+	// 0 turns either off
+	RENEWAL_REMINDER_DAYS int
+	QUOTA_WARNING_PERCENT int
+
+	// GST invoicing; plan prices are inclusive of GST
+	GST_RATE           int
+	SELLER_NAME        string
+	SELLER_ADDRESS     string
+	SELLER_GSTIN       string
+	SELLER_STATE_CODE  string
+	INVOICE_PREFIX     string
+	CREDIT_NOTE_PREFIX string
+
+	// outbox delivery of notifications and domain events
+	OUTBOX_MAX_ATTEMPTS   int
+	OUTBOX_BATCH_SIZE     int
+	OUTBOX_RETENTION_DAYS int
+	EVENTS_URL            string
+
+	// reconciliation of razorpay payments and refunds against local records
+	RECONCILE_INTERVAL_HOURS int
+	RECONCILE_WINDOW_DAYS    int
+	RECONCILE_AUTO_REPAIR    bool
+
+	// OpenTelemetry tracing, exported over OTLP/HTTP; off when no endpoint is set
+	OTEL_EXPORTER_OTLP_ENDPOINT string
+	OTEL_SERVICE_NAME           string
+	OTEL_TRACES_SAMPLE_RATIO    float64
+
+	// logs are written as JSON at this level or above: debug, info, warn or error
+	LOG_LEVEL string
+
+	// on SIGTERM, time allowed for requests and background jobs in flight to finish
+	SHUTDOWN_TIMEOUT_SECONDS int
 }
 
 func LoadEnv() *Config {
@@ -27,9 +107,114 @@ func LoadEnv() *Config {
 
 		PORT: os.Getenv("PORT"),
 
+		// internal routes are served on PORT too when no INTERNAL_PORT is set
+		INTERNAL_PORT: os.Getenv("INTERNAL_PORT"),
+
 		RAZORPAY_API_KEY:    os.Getenv("RAZORPAY_API_KEY"),
 		RAZORPAY_API_SECRET: os.Getenv("RAZORPAY_API_SECRET"),
 
+		RAZORPAY_WEBHOOK_SECRET: os.Getenv("RAZORPAY_WEBHOOK_SECRET"),
+
 		NOTIFICATION_URL: os.Getenv("NOTIFICATION_URL"),
+
+		NOTIFICATION_TIMEOUT_SECONDS:          getEnvInt("NOTIFICATION_TIMEOUT_SECONDS", 5),
+		NOTIFICATION_MAX_RETRIES:              getEnvInt("NOTIFICATION_MAX_RETRIES", 2),
+		NOTIFICATION_BREAKER_THRESHOLD:        getEnvInt("NOTIFICATION_BREAKER_THRESHOLD", 5),
+		NOTIFICATION_BREAKER_COOLDOWN_SECONDS: getEnvInt("NOTIFICATION_BREAKER_COOLDOWN_SECONDS", 30),
+
+		REFUND_FULL_REFUND_DAYS: getEnvInt("REFUND_FULL_REFUND_DAYS", 7),
+		REFUND_PRORATE:          getEnvBool("REFUND_PRORATE", true),
+		REFUND_MAX_ATTEMPTS:     getEnvInt("REFUND_MAX_ATTEMPTS", 8),
+
+		ADMIN_API_KEY: os.Getenv("ADMIN_API_KEY"),
+
+		SERVICE_TOKENS: getEnvList("SERVICE_TOKENS"),
+
+		TRUSTED_PROXY_CIDRS: getEnvList("TRUSTED_PROXY_CIDRS"),
+
+		SUPABASE_JWT_SECRET:      os.Getenv("SUPABASE_JWT_SECRET"),
+		SUPABASE_JWKS_URL:        os.Getenv("SUPABASE_JWKS_URL"),
+		SUPABASE_JWT_ISSUER:      os.Getenv("SUPABASE_JWT_ISSUER"),
+		SUPABASE_JWT_AUDIENCE:    getEnvString("SUPABASE_JWT_AUDIENCE", "authenticated"),
+		GATEWAY_ASSERTION_SECRET: os.Getenv("GATEWAY_ASSERTION_SECRET"),
+
+		RATE_LIMIT_PUBLIC_RPS:   getEnvInt("RATE_LIMIT_PUBLIC_RPS", 10),
+		RATE_LIMIT_INTERNAL_RPS: getEnvInt("RATE_LIMIT_INTERNAL_RPS", 0),
+		RATE_LIMIT_ADMIN_RPS:    getEnvInt("RATE_LIMIT_ADMIN_RPS", 2),
+
+		TRIAL_PLAN:           getEnvString("TRIAL_PLAN", "pro"),
+		TRIAL_DAYS:           getEnvInt("TRIAL_DAYS", 7),
+		TRIAL_REMINDER_HOURS: getEnvInt("TRIAL_REMINDER_HOURS", 48),
+
+		RENEWAL_REMINDER_DAYS: getEnvInt("RENEWAL_REMINDER_DAYS", 3),
+		QUOTA_WARNING_PERCENT: getEnvInt("QUOTA_WARNING_PERCENT", 80),
+
+		GST_RATE:           getEnvInt("GST_RATE", 18),
+		SELLER_NAME:        getEnvString("SELLER_NAME", "Fuse"),
+		SELLER_ADDRESS:     os.Getenv("SELLER_ADDRESS"),
+		SELLER_GSTIN:       os.Getenv("SELLER_GSTIN"),
+		SELLER_STATE_CODE:  os.Getenv("SELLER_STATE_CODE"),
+		INVOICE_PREFIX:     getEnvString("INVOICE_PREFIX", "INV"),
+		CREDIT_NOTE_PREFIX: getEnvString("CREDIT_NOTE_PREFIX", "CN"),
+
+		OUTBOX_MAX_ATTEMPTS:   getEnvInt("OUTBOX_MAX_ATTEMPTS", 10),
+		OUTBOX_BATCH_SIZE:     getEnvInt("OUTBOX_BATCH_SIZE", 50),
+		OUTBOX_RETENTION_DAYS: getEnvInt("OUTBOX_RETENTION_DAYS", 7),
+		EVENTS_URL:            os.Getenv("EVENTS_URL"),
+
+		RECONCILE_INTERVAL_HOURS: getEnvInt("RECONCILE_INTERVAL_HOURS", 24),
+		RECONCILE_WINDOW_DAYS:    getEnvInt("RECONCILE_WINDOW_DAYS", 3),
+		RECONCILE_AUTO_REPAIR:    getEnvBool("RECONCILE_AUTO_REPAIR", false),
+
+		OTEL_EXPORTER_OTLP_ENDPOINT: os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT"),
+		OTEL_SERVICE_NAME:           getEnvString("OTEL_SERVICE_NAME", "subscriptions"),
+		OTEL_TRACES_SAMPLE_RATIO:    getEnvFloat("OTEL_TRACES_SAMPLE_RATIO", 1),
+
+		LOG_LEVEL: getEnvString("LOG_LEVEL", "info"),
+
+		SHUTDOWN_TIMEOUT_SECONDS: getEnvInt("SHUTDOWN_TIMEOUT_SECONDS", 30),
+	}
+}
+
+func getEnvString(key string, fallback string) string {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback
+	}
+	return value
+}
+
+func getEnvInt(key string, fallback int) int {
+	value, err := strconv.Atoi(os.Getenv(key))
+	if err != nil {
+		return fallback
+	}
+	return value
+}
+
+func getEnvFloat(key string, fallback float64) float64 {
+	value, err := strconv.ParseFloat(os.Getenv(key), 64)
+	if err != nil {
+		return fallback
+	}
+	return value
+}
+
+func getEnvBool(key string, fallback bool) bool {
+	value, err := strconv.ParseBool(os.Getenv(key))
+	if err != nil {
+		return fallback
+	}
+	return value
+}
+
+// getEnvList splits a comma separated value, dropping empty entries.
+func getEnvList(key string) []string {
+	values := []string{}
+	for _, value := range strings.Split(os.Getenv(key), ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
 	}
+	return values
 }
@@ -2,25 +2,38 @@ package config
 
 import (
 	"context"
-	"log"
+	"log/slog"
 
+	"github.com/jackc/pgx/v5/multitracer"
 	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/parbhat-cpp/fuse/subscriptions/internal/logging"
+	"github.com/parbhat-cpp/fuse/subscriptions/internal/metrics"
+	"github.com/parbhat-cpp/fuse/subscriptions/internal/tracing"
 )
 
 func ConnectDB() *pgxpool.Pool {
 	cfg := LoadEnv()
 
-	pool, err := pgxpool.New(context.Background(), cfg.DB_URL)
+	pool_config, err := pgxpool.ParseConfig(cfg.DB_URL)
 
 	if err != nil {
-		log.Fatalf("Unable to connect Database: %v", err)
+		logging.Fatal("Invalid DB_URL", "error", err)
+	}
+
+	// query latency is reported on /metrics and each query is traced
+	pool_config.ConnConfig.Tracer = multitracer.New(metrics.QueryTracer{}, tracing.QueryTracer{})
+
+	pool, err := pgxpool.NewWithConfig(context.Background(), pool_config)
+
+	if err != nil {
+		logging.Fatal("Unable to connect Database", "error", err)
 	}
 
 	err = pool.Ping(context.Background())
 
 	if err != nil {
-		log.Fatalf("Database ping failed: %v", err)
+		logging.Fatal("Database ping failed", "error", err)
 	}
-	log.Println("Database connected successfully")
+	slog.Info("Database connected successfully")
 	return pool
 }
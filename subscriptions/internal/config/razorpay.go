@@ -1,11 +1,13 @@
 package config
 
 import (
+	"github.com/parbhat-cpp/fuse/subscriptions/internal/metrics"
 	razorpay "github.com/razorpay/razorpay-go"
 )
 
 func GetRazorpayClient() *razorpay.Client {
 	cfg := LoadEnv()
 	client := razorpay.NewClient(cfg.RAZORPAY_API_KEY, cfg.RAZORPAY_API_SECRET)
+	client.Request.HTTPClient.Transport = metrics.RazorpayTransport(client.Request.HTTPClient.Transport)
 	return client
 }
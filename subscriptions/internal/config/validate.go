@@ -0,0 +1,64 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+)
+
+/**
+ * Checks that the settings the service cannot run without are present and that the
+ * rest are in range.
+ * @return error, listing every problem found
+ */
+func (c *Config) Validate() error {
+	problems := []error{}
+
+	required := []struct {
+		key   string
+		value string
+	}{
+		{"DB_URL", c.DB_URL},
+		{"PORT", c.PORT},
+		{"RAZORPAY_API_KEY", c.RAZORPAY_API_KEY},
+		{"RAZORPAY_API_SECRET", c.RAZORPAY_API_SECRET},
+		{"RAZORPAY_WEBHOOK_SECRET", c.RAZORPAY_WEBHOOK_SECRET},
+	}
+
+	for _, setting := range required {
+		if setting.value == "" {
+			problems = append(problems, fmt.Errorf("%s is not set", setting.key))
+		}
+	}
+
+	if c.SUPABASE_JWT_SECRET == "" && c.SUPABASE_JWKS_URL == "" && c.GATEWAY_ASSERTION_SECRET == "" {
+		problems = append(problems, errors.New("one of SUPABASE_JWT_SECRET, SUPABASE_JWKS_URL or GATEWAY_ASSERTION_SECRET must be set"))
+	}
+
+	if _, ok := c.SUBSCRIPTION_PLANS_ID[c.TRIAL_PLAN]; !ok {
+		problems = append(problems, fmt.Errorf("TRIAL_PLAN %q is not a plan", c.TRIAL_PLAN))
+	}
+
+	if c.OTEL_TRACES_SAMPLE_RATIO < 0 || c.OTEL_TRACES_SAMPLE_RATIO > 1 {
+		problems = append(problems, fmt.Errorf("OTEL_TRACES_SAMPLE_RATIO must be between 0 and 1, got %v", c.OTEL_TRACES_SAMPLE_RATIO))
+	}
+
+	var level slog.Level
+
+	if err := level.UnmarshalText([]byte(c.LOG_LEVEL)); err != nil {
+		problems = append(problems, fmt.Errorf("LOG_LEVEL %q is not a level", c.LOG_LEVEL))
+	}
+
+	for _, cidr := range c.TRUSTED_PROXY_CIDRS {
+		if _, _, err := net.ParseCIDR(cidr); err != nil {
+			problems = append(problems, fmt.Errorf("TRUSTED_PROXY_CIDRS has an invalid network %q", cidr))
+		}
+	}
+
+	if c.SHUTDOWN_TIMEOUT_SECONDS <= 0 {
+		problems = append(problems, errors.New("SHUTDOWN_TIMEOUT_SECONDS must be positive"))
+	}
+
+	return errors.Join(problems...)
+}
@@ -10,30 +10,209 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
-type Refund struct {
+type Coupon struct {
+	ID             pgtype.UUID
+	Code           string
+	DiscountType   string
+	DiscountValue  pgtype.Numeric
+	ValidFrom      pgtype.Timestamptz
+	ExpiresAt      pgtype.Timestamptz
+	MaxRedemptions pgtype.Int4
+	PerUserLimit   int32
+	PlanTypes      []string
+	IsActive       bool
+	CreatedAt      pgtype.Timestamptz
+	UpdatedAt      pgtype.Timestamptz
+	Currency       string
+}
+
+type CouponRedemption struct {
+	ID             pgtype.UUID
+	CouponID       pgtype.UUID
+	UserID         pgtype.UUID
+	SubscriptionID pgtype.UUID
+	PaymentOrderID pgtype.UUID
+	DiscountAmount pgtype.Numeric
+	CreatedAt      pgtype.Timestamptz
+}
+
+type CreditNote struct {
+	ID               pgtype.UUID
+	CreditNoteNumber string
+	InvoiceID        pgtype.UUID
+	RefundID         pgtype.UUID
+	UserID           pgtype.UUID
+	Currency         string
+	TaxRate          pgtype.Numeric
+	TaxableAmount    pgtype.Numeric
+	CgstAmount       pgtype.Numeric
+	SgstAmount       pgtype.Numeric
+	IgstAmount       pgtype.Numeric
+	TotalAmount      pgtype.Numeric
+	IssuedAt         pgtype.Timestamptz
+	CreatedAt        pgtype.Timestamptz
+}
+
+type CreditNoteSequence struct {
+	FinancialYear string
+	LastNumber    int64
+}
+
+type Invoice struct {
+	ID              pgtype.UUID
+	InvoiceNumber   string
+	UserID          pgtype.UUID
+	SubscriptionID  pgtype.UUID
+	PaymentOrderID  pgtype.UUID
+	PlanType        string
+	Currency        string
+	BillingName     pgtype.Text
+	Gstin           pgtype.Text
+	PlaceOfSupply   pgtype.Text
+	SellerGstin     string
+	SellerStateCode string
+	TaxRate         pgtype.Numeric
+	TaxableAmount   pgtype.Numeric
+	CgstAmount      pgtype.Numeric
+	SgstAmount      pgtype.Numeric
+	IgstAmount      pgtype.Numeric
+	TotalAmount     pgtype.Numeric
+	IssuedAt        pgtype.Timestamptz
+	CreatedAt       pgtype.Timestamptz
+}
+
+type InvoiceSequence struct {
+	FinancialYear string
+	LastNumber    int64
+}
+
+type OutboxEvent struct {
+	ID            pgtype.UUID
+	Topic         string
+	UserID        pgtype.UUID
+	AggregateID   pgtype.UUID
+	Payload       json.RawMessage
+	Status        string
+	Attempts      int32
+	NextAttemptAt pgtype.Timestamptz
+	LastError     pgtype.Text
+	DeliveredAt   pgtype.Timestamptz
+	CreatedAt     pgtype.Timestamptz
+}
+
+type PaymentAttempt struct {
 	ID                pgtype.UUID
-	SubscriptionID    pgtype.UUID
 	UserID            pgtype.UUID
+	PlanType          string
+	OrderID           string
+	RazorpayOrderID   string
 	RazorpayPaymentID string
-	Amount            pgtype.Numeric
+	Outcome           string
+	ReasonCode        string
+	ErrorMessage      pgtype.Text
+	Refunded          bool
+	ClientIp          pgtype.Text
 	CreatedAt         pgtype.Timestamptz
-	UpdatedAt         pgtype.Timestamptz
 }
 
-type Subscription struct {
+type PaymentOrder struct {
+	ID                        pgtype.UUID
+	UserID                    pgtype.UUID
+	PlanID                    pgtype.UUID
+	PlanType                  string
+	RazorpayOrderID           string
+	Amount                    pgtype.Numeric
+	ProrationCredit           pgtype.Numeric
+	UpgradeFromSubscriptionID pgtype.UUID
+	Status                    string
+	CreatedAt                 pgtype.Timestamptz
+	UpdatedAt                 pgtype.Timestamptz
+	CouponID                  pgtype.UUID
+	DiscountAmount            pgtype.Numeric
+	Currency                  string
+	BillingName               pgtype.Text
+	Gstin                     pgtype.Text
+	BillingStateCode          pgtype.Text
+}
+
+type Refund struct {
 	ID                pgtype.UUID
+	SubscriptionID    pgtype.UUID
 	UserID            pgtype.UUID
-	PlanID            pgtype.UUID
-	PlanType          string
-	PurchaseDate      pgtype.Timestamptz
-	ValidFrom         pgtype.Timestamptz
-	ValidUntil        pgtype.Timestamptz
-	OrderID           string
 	RazorpayPaymentID string
-	RazorpayOrderID   string
-	RazorpaySignature string
+	Amount            pgtype.Numeric
 	CreatedAt         pgtype.Timestamptz
 	UpdatedAt         pgtype.Timestamptz
+	IsDeleted         bool
+	Currency          string
+	Status            string
+	Reason            string
+	RazorpayRefundID  pgtype.Text
+	Attempts          int32
+	NextAttemptAt     pgtype.Timestamptz
+	LastError         pgtype.Text
+	ProcessedAt       pgtype.Timestamptz
+}
+
+type Subscription struct {
+	ID                    pgtype.UUID
+	UserID                pgtype.UUID
+	PlanID                pgtype.UUID
+	PlanType              string
+	PurchaseDate          pgtype.Timestamptz
+	ValidFrom             pgtype.Timestamptz
+	ValidUntil            pgtype.Timestamptz
+	OrderID               string
+	RazorpayPaymentID     string
+	RazorpayOrderID       string
+	RazorpaySignature     string
+	CreatedAt             pgtype.Timestamptz
+	UpdatedAt             pgtype.Timestamptz
+	IsDeleted             bool
+	Amount                pgtype.Numeric
+	ProrationCredit       pgtype.Numeric
+	UpgradedFrom          pgtype.UUID
+	CanceledAt            pgtype.Timestamptz
+	Status                string
+	Currency              string
+	RenewalReminderSentAt pgtype.Timestamptz
+}
+
+type SubscriptionEvent struct {
+	ID             pgtype.UUID
+	SubscriptionID pgtype.UUID
+	UserID         pgtype.UUID
+	FromStatus     pgtype.Text
+	ToStatus       string
+	Reason         string
+	CreatedAt      pgtype.Timestamptz
+}
+
+type SubscriptionPlanChange struct {
+	ID             pgtype.UUID
+	UserID         pgtype.UUID
+	SubscriptionID pgtype.UUID
+	FromPlanType   string
+	ToPlanType     string
+	EffectiveAt    pgtype.Timestamptz
+	Status         string
+	CreatedAt      pgtype.Timestamptz
+	UpdatedAt      pgtype.Timestamptz
+}
+
+type SubscriptionTrial struct {
+	ID             pgtype.UUID
+	UserID         pgtype.UUID
+	SubscriptionID pgtype.UUID
+	PlanType       string
+	StartedAt      pgtype.Timestamptz
+	EndsAt         pgtype.Timestamptz
+	ReminderSentAt pgtype.Timestamptz
+	ConvertedAt    pgtype.Timestamptz
+	EndedAt        pgtype.Timestamptz
+	CreatedAt      pgtype.Timestamptz
+	UpdatedAt      pgtype.Timestamptz
+	UserIDHash     string
 }
 
 type SubscriptionUsage struct {
@@ -0,0 +1,40 @@
+-- name: CreateCoupon :one
+INSERT INTO coupons (code, discount_type, discount_value, valid_from, expires_at, max_redemptions, per_user_limit, plan_types, currency)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+RETURNING id, code, discount_type, discount_value, valid_from, expires_at, max_redemptions, per_user_limit, plan_types, is_active, created_at, updated_at, currency;
+
+-- name: GetCouponByCode :one
+SELECT id, code, discount_type, discount_value, valid_from, expires_at, max_redemptions, per_user_limit, plan_types, is_active, created_at, updated_at, currency
+FROM coupons WHERE code = $1;
+
+-- name: GetCouponByID :one
+SELECT id, code, discount_type, discount_value, valid_from, expires_at, max_redemptions, per_user_limit, plan_types, is_active, created_at, updated_at, currency
+FROM coupons WHERE id = $1;
+
+-- name: LockCouponByID :one
+SELECT id, code, discount_type, discount_value, valid_from, expires_at, max_redemptions, per_user_limit, plan_types, is_active, created_at, updated_at, currency
+FROM coupons WHERE id = $1 FOR UPDATE;
+
+-- name: CountCouponRedemptions :one
+SELECT count(*) FROM coupon_redemptions WHERE coupon_id = $1;
+
+-- name: CountCouponRedemptionsByUserID :one
+SELECT count(*) FROM coupon_redemptions WHERE coupon_id = $1 AND user_id = $2;
+
+-- name: CreateCouponRedemption :exec
+INSERT INTO coupon_redemptions (coupon_id, user_id, subscription_id, payment_order_id, discount_amount)
+VALUES ($1, $2, $3, $4, $5);
+
+-- name: GetCouponReport :many
+SELECT c.id, c.code, c.discount_type, c.discount_value, c.expires_at, c.max_redemptions, c.is_active, c.currency,
+  count(r.id) AS redemptions, coalesce(sum(r.discount_amount), 0)::numeric AS total_discount
+FROM coupons AS c
+LEFT JOIN coupon_redemptions AS r ON r.coupon_id = c.id
+GROUP BY c.id ORDER BY c.created_at DESC;
+
+-- name: GetCouponRedemptionsByCouponID :many
+SELECT id, coupon_id, user_id, subscription_id, payment_order_id, discount_amount, created_at
+FROM coupon_redemptions WHERE coupon_id = $1 ORDER BY created_at DESC;
+
+-- name: UnlinkCouponRedemptionsByUserID :exec
+UPDATE coupon_redemptions SET user_id = NULL WHERE user_id = $1;
@@ -0,0 +1,20 @@
+-- name: NextCreditNoteSequence :one
+INSERT INTO credit_note_sequences (financial_year, last_number) VALUES ($1, 1)
+ON CONFLICT (financial_year) DO UPDATE SET last_number = credit_note_sequences.last_number + 1
+RETURNING last_number;
+
+-- name: CreateCreditNote :one
+INSERT INTO credit_notes (credit_note_number, invoice_id, refund_id, user_id, currency, tax_rate, taxable_amount, cgst_amount, sgst_amount, igst_amount, total_amount, issued_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+RETURNING id, credit_note_number, invoice_id, refund_id, user_id, currency, tax_rate, taxable_amount, cgst_amount, sgst_amount, igst_amount, total_amount, issued_at, created_at;
+
+-- name: GetCreditNotesByUserID :many
+SELECT id, credit_note_number, invoice_id, refund_id, user_id, currency, tax_rate, taxable_amount, cgst_amount, sgst_amount, igst_amount, total_amount, issued_at, created_at
+FROM credit_notes WHERE user_id = $1 ORDER BY issued_at DESC;
+
+-- name: GetCreditNoteByIDAndUserID :one
+SELECT id, credit_note_number, invoice_id, refund_id, user_id, currency, tax_rate, taxable_amount, cgst_amount, sgst_amount, igst_amount, total_amount, issued_at, created_at
+FROM credit_notes WHERE id = $1 AND user_id = $2;
+
+-- name: UnlinkCreditNotesByUserID :exec
+UPDATE credit_notes SET user_id = NULL WHERE user_id = $1;
@@ -0,0 +1,28 @@
+-- name: NextInvoiceSequence :one
+INSERT INTO invoice_sequences (financial_year, last_number) VALUES ($1, 1)
+ON CONFLICT (financial_year) DO UPDATE SET last_number = invoice_sequences.last_number + 1
+RETURNING last_number;
+
+-- name: CreateInvoice :one
+INSERT INTO invoices (invoice_number, user_id, subscription_id, payment_order_id, plan_type, currency, billing_name, gstin, place_of_supply, seller_gstin, seller_state_code, tax_rate, taxable_amount, cgst_amount, sgst_amount, igst_amount, total_amount, issued_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18)
+RETURNING id, invoice_number, user_id, subscription_id, payment_order_id, plan_type, currency, billing_name, gstin, place_of_supply, seller_gstin, seller_state_code, tax_rate, taxable_amount, cgst_amount, sgst_amount, igst_amount, total_amount, issued_at, created_at;
+
+-- name: GetInvoicesByUserID :many
+SELECT id, invoice_number, user_id, subscription_id, payment_order_id, plan_type, currency, billing_name, gstin, place_of_supply, seller_gstin, seller_state_code, tax_rate, taxable_amount, cgst_amount, sgst_amount, igst_amount, total_amount, issued_at, created_at
+FROM invoices WHERE user_id = $1 ORDER BY issued_at DESC;
+
+-- name: GetInvoiceByIDAndUserID :one
+SELECT id, invoice_number, user_id, subscription_id, payment_order_id, plan_type, currency, billing_name, gstin, place_of_supply, seller_gstin, seller_state_code, tax_rate, taxable_amount, cgst_amount, sgst_amount, igst_amount, total_amount, issued_at, created_at
+FROM invoices WHERE id = $1 AND user_id = $2;
+
+-- name: UnlinkInvoicesByUserID :exec
+UPDATE invoices SET user_id = NULL WHERE user_id = $1;
+
+-- name: GetInvoiceByID :one
+SELECT id, invoice_number, user_id, subscription_id, payment_order_id, plan_type, currency, billing_name, gstin, place_of_supply, seller_gstin, seller_state_code, tax_rate, taxable_amount, cgst_amount, sgst_amount, igst_amount, total_amount, issued_at, created_at
+FROM invoices WHERE id = $1;
+
+-- name: GetInvoiceBySubscriptionID :one
+SELECT id, invoice_number, user_id, subscription_id, payment_order_id, plan_type, currency, billing_name, gstin, place_of_supply, seller_gstin, seller_state_code, tax_rate, taxable_amount, cgst_amount, sgst_amount, igst_amount, total_amount, issued_at, created_at
+FROM invoices WHERE subscription_id = $1 ORDER BY issued_at DESC LIMIT 1;
@@ -0,0 +1,36 @@
+-- name: CreateOutboxEvent :exec
+INSERT INTO outbox_events (topic, user_id, aggregate_id, payload)
+VALUES ($1, $2, $3, $4);
+
+-- name: ClaimOutboxEvents :many
+UPDATE outbox_events SET next_attempt_at = sqlc.arg(lease_until)
+WHERE id IN (
+  SELECT id FROM outbox_events
+  WHERE status = 'pending' AND next_attempt_at <= now()
+  ORDER BY next_attempt_at
+  LIMIT sqlc.arg(batch_size)
+  FOR UPDATE SKIP LOCKED
+)
+RETURNING id, topic, user_id, aggregate_id, payload, status, attempts, next_attempt_at, last_error, delivered_at, created_at;
+
+-- name: MarkOutboxEventDelivered :exec
+UPDATE outbox_events SET status = 'delivered', attempts = attempts + 1, last_error = NULL, delivered_at = now() WHERE id = $1;
+
+-- name: MarkOutboxEventFailed :exec
+UPDATE outbox_events SET status = $2, attempts = attempts + 1, next_attempt_at = $3, last_error = $4 WHERE id = $1;
+
+-- name: GetDeadOutboxEvents :many
+SELECT id, topic, user_id, aggregate_id, payload, status, attempts, next_attempt_at, last_error, delivered_at, created_at
+FROM outbox_events
+WHERE status = 'dead'
+ORDER BY created_at DESC
+LIMIT $1;
+
+-- name: RetryDeadOutboxEvent :execrows
+UPDATE outbox_events SET status = 'pending', attempts = 0, next_attempt_at = now() WHERE id = $1 AND status = 'dead';
+
+-- name: PruneDeliveredOutboxEvents :execrows
+DELETE FROM outbox_events WHERE status = 'delivered' AND delivered_at < $1;
+
+-- name: RemoveOutboxEventsByUserID :exec
+DELETE FROM outbox_events WHERE user_id = $1;
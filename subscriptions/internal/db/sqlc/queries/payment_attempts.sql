@@ -0,0 +1,20 @@
+-- name: CreatePaymentAttempt :exec
+INSERT INTO payment_attempts (user_id, plan_type, order_id, razorpay_order_id, razorpay_payment_id, outcome, reason_code, error_message, refunded, client_ip)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10);
+
+-- name: SearchPaymentAttempts :many
+SELECT id, user_id, plan_type, order_id, razorpay_order_id, razorpay_payment_id, outcome, reason_code, error_message, refunded, client_ip, created_at
+FROM payment_attempts
+WHERE (sqlc.narg(user_id)::uuid IS NULL OR user_id = sqlc.narg(user_id))
+  AND (sqlc.narg(outcome)::text IS NULL OR outcome = sqlc.narg(outcome))
+  AND (sqlc.narg(reason_code)::text IS NULL OR reason_code = sqlc.narg(reason_code))
+  AND (sqlc.narg(razorpay_order_id)::text IS NULL OR razorpay_order_id = sqlc.narg(razorpay_order_id))
+  AND (sqlc.narg(razorpay_payment_id)::text IS NULL OR razorpay_payment_id = sqlc.narg(razorpay_payment_id))
+  AND (sqlc.narg(client_ip)::text IS NULL OR client_ip = sqlc.narg(client_ip))
+  AND (sqlc.narg(created_after)::timestamptz IS NULL OR created_at >= sqlc.narg(created_after))
+  AND (sqlc.narg(created_before)::timestamptz IS NULL OR created_at < sqlc.narg(created_before))
+ORDER BY created_at DESC
+LIMIT sqlc.arg(row_limit);
+
+-- name: UnlinkPaymentAttemptsByUserID :exec
+UPDATE payment_attempts SET user_id = NULL, client_ip = NULL WHERE user_id = $1;
@@ -0,0 +1,14 @@
+-- name: CreatePaymentOrder :one
+INSERT INTO payment_orders (user_id, plan_id, plan_type, razorpay_order_id, amount, proration_credit, upgrade_from_subscription_id, coupon_id, discount_amount, currency, billing_name, gstin, billing_state_code)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+RETURNING id, user_id, plan_id, plan_type, razorpay_order_id, amount, proration_credit, upgrade_from_subscription_id, status, created_at, updated_at, coupon_id, discount_amount, currency, billing_name, gstin, billing_state_code;
+
+-- name: GetPaymentOrderByRazorpayOrderID :one
+SELECT id, user_id, plan_id, plan_type, razorpay_order_id, amount, proration_credit, upgrade_from_subscription_id, status, created_at, updated_at, coupon_id, discount_amount, currency, billing_name, gstin, billing_state_code
+FROM payment_orders WHERE razorpay_order_id = $1;
+
+-- name: UpdatePaymentOrderStatus :exec
+UPDATE payment_orders SET status = $2, updated_at = NOW() WHERE id = $1;
+
+-- name: RemovePaymentOrdersByUserID :exec
+DELETE FROM payment_orders WHERE user_id = $1;
@@ -1,16 +1,80 @@
 -- name: CreateNewRefund :one
-INSERT INTO refunds (subscription_id, razorpay_payment_id, amount, user_id)
-VALUES ($1, $2, $3, $4)
-RETURNING id, subscription_id, razorpay_payment_id, amount, created_at, updated_at;
+INSERT INTO refunds (subscription_id, razorpay_payment_id, amount, user_id, currency, status, reason, razorpay_refund_id, processed_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+RETURNING id, subscription_id, user_id, razorpay_payment_id, amount, created_at, updated_at, is_deleted, currency, status, reason, razorpay_refund_id, attempts, next_attempt_at, last_error, processed_at;
 
--- name: GetRefundByPaymentID :one
-SELECT id, subscription_id, razorpay_payment_id, amount, created_at, updated_at
-FROM refunds WHERE razorpay_payment_id = $1;
+-- name: GetRefundsByPaymentID :many
+SELECT id, subscription_id, razorpay_payment_id, amount, currency, created_at, updated_at, status, razorpay_refund_id, processed_at
+FROM refunds WHERE razorpay_payment_id = $1 ORDER BY created_at;
+
+-- name: GetRefundForPayment :one
+SELECT id, subscription_id, user_id, razorpay_payment_id, amount, created_at, updated_at, is_deleted, currency, status, reason, razorpay_refund_id, attempts, next_attempt_at, last_error, processed_at
+FROM refunds
+WHERE razorpay_payment_id = $1 AND subscription_id IS NOT DISTINCT FROM $2 AND reason = $3;
+
+-- name: GetRefundedAmountByPaymentID :one
+SELECT COALESCE(SUM(amount), 0)::bigint AS refunded FROM refunds
+WHERE razorpay_payment_id = $1 AND status <> 'failed';
+
+-- name: LockPaymentRefunds :exec
+SELECT pg_advisory_xact_lock(hashtext($1));
 
 -- name: GetRefundsByUserID :many
-SELECT id, subscription_id, razorpay_payment_id, amount, created_at, updated_at
+SELECT id, subscription_id, razorpay_payment_id, amount, currency, created_at, updated_at, status, razorpay_refund_id, processed_at
 FROM refunds WHERE user_id = $1 ORDER BY created_at DESC;
 
 -- name: RemoveRefundByUserID :one
 UPDATE refunds SET is_deleted = true, user_id = NULL WHERE user_id = $1
-RETURNING id, subscription_id, razorpay_payment_id, amount, created_at, updated_at;
+RETURNING id, subscription_id, razorpay_payment_id, amount, currency, created_at, updated_at, status, razorpay_refund_id, processed_at;
+
+-- name: GetRefundsCreatedBetween :many
+SELECT id, subscription_id, razorpay_payment_id, amount, currency, created_at, updated_at, status, razorpay_refund_id, processed_at
+FROM refunds
+WHERE created_at >= sqlc.arg(created_from) AND created_at < sqlc.arg(created_to)
+ORDER BY created_at;
+
+-- name: GetRefundByID :one
+SELECT id, subscription_id, user_id, razorpay_payment_id, amount, created_at, updated_at, is_deleted, currency, status, reason, razorpay_refund_id, attempts, next_attempt_at, last_error, processed_at
+FROM refunds WHERE id = $1;
+
+-- name: GetRefundByRazorpayRefundID :one
+SELECT id, subscription_id, user_id, razorpay_payment_id, amount, created_at, updated_at, is_deleted, currency, status, reason, razorpay_refund_id, attempts, next_attempt_at, last_error, processed_at
+FROM refunds WHERE razorpay_refund_id = $1;
+
+-- name: ClaimDueRefunds :many
+UPDATE refunds SET next_attempt_at = sqlc.arg(lease_until)
+WHERE id IN (
+  SELECT id FROM refunds
+  WHERE status IN ('pending', 'submitted') AND next_attempt_at <= now()
+  ORDER BY next_attempt_at
+  LIMIT sqlc.arg(batch_size)
+  FOR UPDATE SKIP LOCKED
+)
+RETURNING id, subscription_id, user_id, razorpay_payment_id, amount, created_at, updated_at, is_deleted, currency, status, reason, razorpay_refund_id, attempts, next_attempt_at, last_error, processed_at;
+
+-- name: MarkRefundSubmitted :execrows
+UPDATE refunds SET status = 'submitted', razorpay_refund_id = $2, attempts = attempts + 1, next_attempt_at = $3, last_error = NULL, updated_at = NOW()
+WHERE id = $1 AND status IN ('pending', 'submitted');
+
+-- name: MarkRefundAttemptFailed :exec
+UPDATE refunds SET status = $2, attempts = attempts + 1, next_attempt_at = $3, last_error = $4, updated_at = NOW()
+WHERE id = $1 AND status IN ('pending', 'submitted');
+
+-- name: MarkRefundProcessed :execrows
+UPDATE refunds SET status = 'processed', razorpay_refund_id = $2, last_error = NULL, processed_at = NOW(), updated_at = NOW()
+WHERE id = $1 AND status <> 'processed';
+
+-- name: MarkRefundFailed :execrows
+UPDATE refunds SET status = 'failed', last_error = $2, updated_at = NOW()
+WHERE id = $1 AND status IN ('pending', 'submitted');
+
+-- name: GetRefundsByStatus :many
+SELECT id, subscription_id, user_id, razorpay_payment_id, amount, created_at, updated_at, is_deleted, currency, status, reason, razorpay_refund_id, attempts, next_attempt_at, last_error, processed_at
+FROM refunds
+WHERE status = $1
+ORDER BY updated_at DESC
+LIMIT $2;
+
+-- name: RetryFailedRefund :execrows
+UPDATE refunds SET status = 'pending', attempts = 0, next_attempt_at = NOW(), updated_at = NOW()
+WHERE id = $1 AND status = 'failed';
@@ -0,0 +1,10 @@
+-- name: CreateSubscriptionEvent :exec
+INSERT INTO subscription_events (subscription_id, user_id, from_status, to_status, reason)
+VALUES ($1, $2, $3, $4, $5);
+
+-- name: GetSubscriptionEventsBySubscriptionID :many
+SELECT id, subscription_id, user_id, from_status, to_status, reason, created_at
+FROM subscription_events WHERE subscription_id = $1 ORDER BY created_at ASC;
+
+-- name: UnlinkSubscriptionEventsByUserID :exec
+UPDATE subscription_events SET user_id = NULL WHERE user_id = $1;
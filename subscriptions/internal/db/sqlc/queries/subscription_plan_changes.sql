@@ -0,0 +1,23 @@
+-- name: CreatePlanChange :one
+INSERT INTO subscription_plan_changes (user_id, subscription_id, from_plan_type, to_plan_type, effective_at)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING id, user_id, subscription_id, from_plan_type, to_plan_type, effective_at, status, created_at, updated_at;
+
+-- name: GetPendingPlanChangeByUserID :one
+SELECT id, user_id, subscription_id, from_plan_type, to_plan_type, effective_at, status, created_at, updated_at
+FROM subscription_plan_changes WHERE user_id = $1 AND status = 'pending' LIMIT 1;
+
+-- name: GetDuePlanChanges :many
+SELECT id, user_id, subscription_id, from_plan_type, to_plan_type, effective_at, status, created_at, updated_at
+FROM subscription_plan_changes WHERE status = 'pending' AND effective_at <= NOW() ORDER BY effective_at ASC;
+
+-- name: CancelPendingPlanChange :one
+UPDATE subscription_plan_changes SET status = 'canceled', updated_at = NOW()
+WHERE user_id = $1 AND status = 'pending'
+RETURNING id, user_id, subscription_id, from_plan_type, to_plan_type, effective_at, status, created_at, updated_at;
+
+-- name: UpdatePlanChangeStatus :exec
+UPDATE subscription_plan_changes SET status = $2, updated_at = NOW() WHERE id = $1;
+
+-- name: RemovePlanChangesByUserID :exec
+DELETE FROM subscription_plan_changes WHERE user_id = $1;
@@ -0,0 +1,34 @@
+-- name: CreateTrial :one
+INSERT INTO subscription_trials (user_id, subscription_id, plan_type, started_at, ends_at, user_id_hash)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING id, user_id, subscription_id, plan_type, started_at, ends_at, reminder_sent_at, converted_at, ended_at, created_at, updated_at, user_id_hash;
+
+-- name: GetTrialByUserIDHash :one
+SELECT id, user_id, subscription_id, plan_type, started_at, ends_at, reminder_sent_at, converted_at, ended_at, created_at, updated_at, user_id_hash
+FROM subscription_trials WHERE user_id_hash = $1;
+
+-- name: GetTrialsDueForReminder :many
+SELECT t.id, t.user_id, t.subscription_id, t.plan_type, t.started_at, t.ends_at, t.reminder_sent_at, t.converted_at, t.ended_at, t.created_at, t.updated_at, t.user_id_hash
+FROM subscription_trials t
+JOIN subscriptions s ON s.id = t.subscription_id
+WHERE t.reminder_sent_at IS NULL AND t.ended_at IS NULL AND s.status = 'trialing'
+  AND t.ends_at > NOW() AND t.ends_at <= $1;
+
+-- name: MarkTrialReminderSent :exec
+UPDATE subscription_trials SET reminder_sent_at = NOW(), updated_at = NOW() WHERE id = $1;
+
+-- name: GetEndedTrials :many
+SELECT id, user_id, subscription_id, plan_type, started_at, ends_at, reminder_sent_at, converted_at, ended_at, created_at, updated_at, user_id_hash
+FROM subscription_trials WHERE ended_at IS NULL AND ends_at <= NOW();
+
+-- name: MarkTrialEnded :exec
+UPDATE subscription_trials SET ended_at = NOW(), updated_at = NOW() WHERE id = $1;
+
+-- name: MarkTrialConvertedBySubscriptionID :exec
+UPDATE subscription_trials SET converted_at = NOW(), ended_at = NOW(), updated_at = NOW()
+WHERE subscription_id = $1 AND ended_at IS NULL;
+
+-- name: AnonymiseTrialsByUserID :exec
+UPDATE subscription_trials
+SET user_id = NULL, subscription_id = NULL, ended_at = COALESCE(ended_at, NOW()), updated_at = NOW()
+WHERE user_id = $1;
@@ -7,6 +7,10 @@ RETURNING id, subscription_id, valid_from, valid_until, usage, subscription_id;
 SELECT id, subscription_id, valid_from, valid_until, usage
 FROM subscription_usage WHERE user_id = $1 ORDER BY created_at DESC LIMIT 1;
 
+-- name: GetSubscriptionUsageBySubscriptionID :one
+SELECT id, subscription_id, valid_from, valid_until, usage
+FROM subscription_usage WHERE subscription_id = $1 ORDER BY created_at DESC LIMIT 1;
+
 -- name: GetCurrentSubscriptionUsageByUserID :one
 SELECT id, subscription_id, valid_from, valid_until, usage
 FROM subscription_usage WHERE user_id = $1 AND valid_from <= NOW() AND valid_until >= NOW() ORDER BY created_at DESC LIMIT 1;
@@ -21,11 +25,17 @@ WHERE su.user_id = $1 AND su.valid_from <= NOW() AND su.valid_until >= NOW() ORD
 SELECT id, subscription_id, valid_from, valid_until, usage
 FROM subscription_usage WHERE user_id = $1 ORDER BY created_at DESC;
 
--- name: GetAllSubscriptionUsageWithSubscription :many
-SELECT su.id, su.valid_from, su.valid_until, su.usage, s.id AS subscription_id, coalesce(s.plan_type::varchar(40), 'Free') AS plan_type
+-- name: ListSubscriptionUsageWithSubscription :many
+SELECT su.id, su.valid_from, su.valid_until, su.usage, s.id AS subscription_id, coalesce(s.plan_type::varchar(40), 'Free') AS plan_type, su.created_at
 FROM subscription_usage AS su
-LEFT JOIN subscriptions AS s ON s.id = su.subscription_id 
-WHERE su.user_id = $1 ORDER BY su.created_at DESC;
+LEFT JOIN subscriptions AS s ON s.id = su.subscription_id
+WHERE su.user_id = sqlc.arg(user_id)
+  AND (sqlc.narg(plan_type)::text IS NULL OR lower(coalesce(s.plan_type, 'free')) = sqlc.narg(plan_type))
+  AND (sqlc.narg(period_from)::timestamptz IS NULL OR su.valid_until >= sqlc.narg(period_from))
+  AND (sqlc.narg(period_to)::timestamptz IS NULL OR su.valid_from < sqlc.narg(period_to))
+  AND (sqlc.narg(cursor_created_at)::timestamptz IS NULL OR (su.created_at, su.id) < (sqlc.narg(cursor_created_at), sqlc.narg(cursor_id)::uuid))
+ORDER BY su.created_at DESC, su.id DESC
+LIMIT sqlc.arg(row_limit);
 
 -- name: UpdateSubscriptionUsageDuration :one
 UPDATE subscription_usage SET valid_from = $3, valid_until = $4
@@ -37,6 +47,10 @@ UPDATE subscription_usage SET usage = $3::text::jsonb
 WHERE id = $1 AND user_id = $2
 RETURNING id, subscription_id, valid_from, valid_until, usage;
 
+-- name: MoveSubscriptionUsageToSubscription :execrows
+UPDATE subscription_usage SET subscription_id = sqlc.arg(new_subscription_id), valid_until = sqlc.arg(valid_until), updated_at = NOW()
+WHERE subscription_id = sqlc.arg(subscription_id);
+
 -- name: RemoveSubscriptionUsageByUserID :one
 DELETE FROM subscription_usage WHERE user_id = $1
 RETURNING id, subscription_id, valid_from, valid_until, usage;
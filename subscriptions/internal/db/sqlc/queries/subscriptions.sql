@@ -1,28 +1,102 @@
 -- name: CreateSubscription :one
-INSERT INTO subscriptions (user_id, plan_id, plan_type, purchase_date, valid_from, order_id, valid_until, razorpay_payment_id, razorpay_order_id, razorpay_signature)
-VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
-RETURNING id, user_id, plan_id, plan_type, purchase_date, valid_from, order_id, valid_until, razorpay_payment_id, razorpay_order_id, razorpay_signature;
+INSERT INTO subscriptions (user_id, plan_id, plan_type, purchase_date, valid_from, order_id, valid_until, razorpay_payment_id, razorpay_order_id, razorpay_signature, amount, proration_credit, upgraded_from, status, currency)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15)
+RETURNING id, user_id, plan_id, plan_type, purchase_date, valid_from, order_id, valid_until, razorpay_payment_id, razorpay_order_id, razorpay_signature, amount, proration_credit, upgraded_from, status, currency;
 
--- name: GetAllSubscriptions :many
-SELECT id, user_id, plan_id, plan_type, purchase_date, valid_from, order_id, valid_until, razorpay_payment_id, razorpay_order_id, razorpay_signature
-FROM subscriptions WHERE user_id = $1 ORDER BY created_at DESC;
+-- name: ListSubscriptions :many
+SELECT id, user_id, plan_id, plan_type, purchase_date, valid_from, order_id, valid_until, razorpay_payment_id, razorpay_order_id, razorpay_signature, created_at
+FROM subscriptions
+WHERE user_id = sqlc.arg(user_id)
+  AND (sqlc.narg(plan_type)::text IS NULL OR lower(plan_type) = sqlc.narg(plan_type))
+  AND (sqlc.narg(period_from)::timestamptz IS NULL OR valid_until >= sqlc.narg(period_from))
+  AND (sqlc.narg(period_to)::timestamptz IS NULL OR valid_from < sqlc.narg(period_to))
+  AND (sqlc.narg(cursor_created_at)::timestamptz IS NULL OR (created_at, id) < (sqlc.narg(cursor_created_at), sqlc.narg(cursor_id)::uuid))
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg(row_limit);
 
 -- name: GetSubscriptionByUserID :one
 SELECT id, user_id, plan_id, plan_type, purchase_date, valid_from, order_id, valid_until, razorpay_payment_id, razorpay_order_id, razorpay_signature
 FROM subscriptions WHERE user_id = $1 ORDER BY created_at DESC LIMIT 1;
 
+-- name: GetActiveSubscriptionByUserID :one
+SELECT id, user_id, plan_id, plan_type, purchase_date, valid_from, order_id, valid_until, razorpay_payment_id, razorpay_order_id, razorpay_signature, amount, canceled_at, status, currency
+FROM subscriptions
+WHERE user_id = $1 AND status IN ('trialing', 'active', 'past_due', 'canceled')
+  AND valid_from <= NOW() AND valid_until > NOW()
+ORDER BY valid_until DESC LIMIT 1;
+
+-- name: GetLatestLiveSubscriptionByUserID :one
+SELECT id, user_id, plan_id, plan_type, purchase_date, valid_from, order_id, valid_until, razorpay_payment_id, razorpay_order_id, razorpay_signature, status
+FROM subscriptions WHERE user_id = $1 AND status IN ('pending', 'trialing', 'active', 'past_due', 'canceled')
+  AND valid_until > NOW()
+ORDER BY valid_until DESC LIMIT 1;
+
+-- name: CountQueuedSubscriptionsByUserID :one
+SELECT count(*) FROM subscriptions WHERE user_id = $1 AND status = 'pending';
+
+-- name: GetQueuedSubscriptionsByUserID :many
+SELECT id, user_id, plan_id, plan_type, purchase_date, valid_from, order_id, valid_until, razorpay_payment_id, razorpay_order_id, razorpay_signature, amount, currency
+FROM subscriptions WHERE user_id = $1 AND status = 'pending' AND valid_from >= $2 ORDER BY valid_from ASC;
+
 -- name: GetSubscriptionByUserIDOrderID :one
 SELECT id, user_id, plan_id, plan_type, purchase_date, valid_from, order_id, valid_until, razorpay_payment_id, razorpay_order_id, razorpay_signature
 FROM subscriptions WHERE user_id = $1 AND order_id = $2 ORDER BY created_at DESC LIMIT 1;
 
 -- name: GetSubscriptionByPaymentID :one
-SELECT id, user_id, plan_id, plan_type, purchase_date, valid_from, order_id, valid_until, razorpay_payment_id, razorpay_order_id, razorpay_signature
+SELECT id, user_id, plan_id, plan_type, purchase_date, valid_from, order_id, valid_until, razorpay_payment_id, razorpay_order_id, razorpay_signature, amount, currency, status
 FROM subscriptions WHERE razorpay_payment_id = $1;
 
+-- name: GetPaidSubscriptionsCreatedBetween :many
+SELECT id, user_id, plan_type, razorpay_payment_id, razorpay_order_id, amount, currency, status, created_at
+FROM subscriptions
+WHERE razorpay_payment_id <> '' AND created_at >= sqlc.arg(created_from) AND created_at < sqlc.arg(created_to)
+ORDER BY created_at;
+
 -- name: GetSubscriptionByID :one
-SELECT id, user_id, plan_id, plan_type, purchase_date, valid_from, order_id, valid_until, razorpay_payment_id, razorpay_order_id, razorpay_signature
+SELECT id, user_id, plan_id, plan_type, purchase_date, valid_from, order_id, valid_until, razorpay_payment_id, razorpay_order_id, razorpay_signature, status
 FROM subscriptions WHERE id = $1 ORDER BY created_at DESC LIMIT 1;
 
+-- name: UpdateSubscriptionValidUntil :one
+UPDATE subscriptions SET valid_until = $2, updated_at = NOW() WHERE id = $1
+RETURNING id, user_id, plan_id, plan_type, purchase_date, valid_from, order_id, valid_until, razorpay_payment_id, razorpay_order_id, razorpay_signature;
+
+-- name: UpdateSubscriptionPlan :one
+UPDATE subscriptions SET plan_id = $2, plan_type = $3, updated_at = NOW() WHERE id = $1
+RETURNING id, user_id, plan_id, plan_type, purchase_date, valid_from, order_id, valid_until, razorpay_payment_id, razorpay_order_id, razorpay_signature;
+
+-- name: UpdateSubscriptionValidity :exec
+UPDATE subscriptions SET valid_from = $2, valid_until = $3, updated_at = NOW() WHERE id = $1;
+
+-- name: CancelSubscription :one
+UPDATE subscriptions SET canceled_at = NOW(), updated_at = NOW() WHERE id = $1
+RETURNING id, user_id, plan_id, plan_type, purchase_date, valid_from, order_id, valid_until, razorpay_payment_id, razorpay_order_id, razorpay_signature, canceled_at;
+
+-- name: GetSubscriptionStatusForUpdate :one
+SELECT status FROM subscriptions WHERE id = $1 FOR UPDATE;
+
+-- name: UpdateSubscriptionStatus :exec
+UPDATE subscriptions SET status = $2, updated_at = NOW() WHERE id = $1;
+
+-- name: GetSubscriptionsDueForActivation :many
+SELECT id, user_id FROM subscriptions WHERE status = 'pending' AND valid_from <= NOW();
+
+-- name: GetSubscriptionsDueForExpiry :many
+SELECT id, user_id FROM subscriptions
+WHERE status IN ('trialing', 'active', 'past_due', 'canceled') AND valid_until <= NOW();
+
 -- name: RemoveSubscriptionByUserID :one
 UPDATE subscriptions SET is_deleted = true, user_id = NULL WHERE user_id = $1
 RETURNING id, user_id, plan_id, plan_type, purchase_date, valid_from, order_id, valid_until, razorpay_payment_id, razorpay_order_id, razorpay_signature;
+
+-- name: GetSubscriptionsDueForRenewalReminder :many
+SELECT s.id, s.user_id, s.plan_type, s.valid_until
+FROM subscriptions s
+WHERE s.status = 'active' AND s.renewal_reminder_sent_at IS NULL
+  AND s.valid_until > NOW() AND s.valid_until <= $1
+  AND NOT EXISTS (
+    SELECT 1 FROM subscriptions q
+    WHERE q.user_id = s.user_id AND q.status = 'pending' AND q.valid_from >= s.valid_until
+  );
+
+-- name: MarkRenewalReminderSent :exec
+UPDATE subscriptions SET renewal_reminder_sent_at = NOW(), updated_at = NOW() WHERE id = $1;
@@ -11,10 +11,64 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+const claimDueRefunds = `-- name: ClaimDueRefunds :many
+UPDATE refunds SET next_attempt_at = $1
+WHERE id IN (
+  SELECT id FROM refunds
+  WHERE status IN ('pending', 'submitted') AND next_attempt_at <= now()
+  ORDER BY next_attempt_at
+  LIMIT $2
+  FOR UPDATE SKIP LOCKED
+)
+RETURNING id, subscription_id, user_id, razorpay_payment_id, amount, created_at, updated_at, is_deleted, currency, status, reason, razorpay_refund_id, attempts, next_attempt_at, last_error, processed_at
+`
+
+type ClaimDueRefundsParams struct {
+	LeaseUntil pgtype.Timestamptz
+	BatchSize  int32
+}
+
+func (q *Queries) ClaimDueRefunds(ctx context.Context, arg ClaimDueRefundsParams) ([]Refund, error) {
+	rows, err := q.db.Query(ctx, claimDueRefunds, arg.LeaseUntil, arg.BatchSize)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Refund
+	for rows.Next() {
+		var i Refund
+		if err := rows.Scan(
+			&i.ID,
+			&i.SubscriptionID,
+			&i.UserID,
+			&i.RazorpayPaymentID,
+			&i.Amount,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.IsDeleted,
+			&i.Currency,
+			&i.Status,
+			&i.Reason,
+			&i.RazorpayRefundID,
+			&i.Attempts,
+			&i.NextAttemptAt,
+			&i.LastError,
+			&i.ProcessedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const createNewRefund = `-- name: CreateNewRefund :one
-INSERT INTO refunds (subscription_id, razorpay_payment_id, amount, user_id)
-VALUES ($1, $2, $3, $4)
-RETURNING id, subscription_id, razorpay_payment_id, amount, created_at, updated_at
+INSERT INTO refunds (subscription_id, razorpay_payment_id, amount, user_id, currency, status, reason, razorpay_refund_id, processed_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+RETURNING id, subscription_id, user_id, razorpay_payment_id, amount, created_at, updated_at, is_deleted, currency, status, reason, razorpay_refund_id, attempts, next_attempt_at, last_error, processed_at
 `
 
 type CreateNewRefundParams struct {
@@ -22,66 +76,254 @@ type CreateNewRefundParams struct {
 	RazorpayPaymentID string
 	Amount            pgtype.Numeric
 	UserID            pgtype.UUID
+	Currency          string
+	Status            string
+	Reason            string
+	RazorpayRefundID  pgtype.Text
+	ProcessedAt       pgtype.Timestamptz
 }
 
-type CreateNewRefundRow struct {
-	ID                pgtype.UUID
-	SubscriptionID    pgtype.UUID
-	RazorpayPaymentID string
-	Amount            pgtype.Numeric
-	CreatedAt         pgtype.Timestamptz
-	UpdatedAt         pgtype.Timestamptz
-}
-
-func (q *Queries) CreateNewRefund(ctx context.Context, arg CreateNewRefundParams) (CreateNewRefundRow, error) {
+func (q *Queries) CreateNewRefund(ctx context.Context, arg CreateNewRefundParams) (Refund, error) {
 	row := q.db.QueryRow(ctx, createNewRefund,
 		arg.SubscriptionID,
 		arg.RazorpayPaymentID,
 		arg.Amount,
 		arg.UserID,
+		arg.Currency,
+		arg.Status,
+		arg.Reason,
+		arg.RazorpayRefundID,
+		arg.ProcessedAt,
 	)
-	var i CreateNewRefundRow
+	var i Refund
 	err := row.Scan(
 		&i.ID,
 		&i.SubscriptionID,
+		&i.UserID,
 		&i.RazorpayPaymentID,
 		&i.Amount,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.IsDeleted,
+		&i.Currency,
+		&i.Status,
+		&i.Reason,
+		&i.RazorpayRefundID,
+		&i.Attempts,
+		&i.NextAttemptAt,
+		&i.LastError,
+		&i.ProcessedAt,
 	)
 	return i, err
 }
 
-const getRefundByPaymentID = `-- name: GetRefundByPaymentID :one
-SELECT id, subscription_id, razorpay_payment_id, amount, created_at, updated_at
-FROM refunds WHERE razorpay_payment_id = $1
+const getRefundByID = `-- name: GetRefundByID :one
+SELECT id, subscription_id, user_id, razorpay_payment_id, amount, created_at, updated_at, is_deleted, currency, status, reason, razorpay_refund_id, attempts, next_attempt_at, last_error, processed_at
+FROM refunds WHERE id = $1
 `
 
-type GetRefundByPaymentIDRow struct {
-	ID                pgtype.UUID
-	SubscriptionID    pgtype.UUID
+func (q *Queries) GetRefundByID(ctx context.Context, id pgtype.UUID) (Refund, error) {
+	row := q.db.QueryRow(ctx, getRefundByID, id)
+	var i Refund
+	err := row.Scan(
+		&i.ID,
+		&i.SubscriptionID,
+		&i.UserID,
+		&i.RazorpayPaymentID,
+		&i.Amount,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.IsDeleted,
+		&i.Currency,
+		&i.Status,
+		&i.Reason,
+		&i.RazorpayRefundID,
+		&i.Attempts,
+		&i.NextAttemptAt,
+		&i.LastError,
+		&i.ProcessedAt,
+	)
+	return i, err
+}
+
+const getRefundByRazorpayRefundID = `-- name: GetRefundByRazorpayRefundID :one
+SELECT id, subscription_id, user_id, razorpay_payment_id, amount, created_at, updated_at, is_deleted, currency, status, reason, razorpay_refund_id, attempts, next_attempt_at, last_error, processed_at
+FROM refunds WHERE razorpay_refund_id = $1
+`
+
+func (q *Queries) GetRefundByRazorpayRefundID(ctx context.Context, razorpayRefundID pgtype.Text) (Refund, error) {
+	row := q.db.QueryRow(ctx, getRefundByRazorpayRefundID, razorpayRefundID)
+	var i Refund
+	err := row.Scan(
+		&i.ID,
+		&i.SubscriptionID,
+		&i.UserID,
+		&i.RazorpayPaymentID,
+		&i.Amount,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.IsDeleted,
+		&i.Currency,
+		&i.Status,
+		&i.Reason,
+		&i.RazorpayRefundID,
+		&i.Attempts,
+		&i.NextAttemptAt,
+		&i.LastError,
+		&i.ProcessedAt,
+	)
+	return i, err
+}
+
+const getRefundForPayment = `-- name: GetRefundForPayment :one
+SELECT id, subscription_id, user_id, razorpay_payment_id, amount, created_at, updated_at, is_deleted, currency, status, reason, razorpay_refund_id, attempts, next_attempt_at, last_error, processed_at
+FROM refunds
+WHERE razorpay_payment_id = $1 AND subscription_id IS NOT DISTINCT FROM $2 AND reason = $3
+`
+
+type GetRefundForPaymentParams struct {
 	RazorpayPaymentID string
-	Amount            pgtype.Numeric
-	CreatedAt         pgtype.Timestamptz
-	UpdatedAt         pgtype.Timestamptz
+	SubscriptionID    pgtype.UUID
+	Reason            string
 }
 
-func (q *Queries) GetRefundByPaymentID(ctx context.Context, razorpayPaymentID string) (GetRefundByPaymentIDRow, error) {
-	row := q.db.QueryRow(ctx, getRefundByPaymentID, razorpayPaymentID)
-	var i GetRefundByPaymentIDRow
+func (q *Queries) GetRefundForPayment(ctx context.Context, arg GetRefundForPaymentParams) (Refund, error) {
+	row := q.db.QueryRow(ctx, getRefundForPayment, arg.RazorpayPaymentID, arg.SubscriptionID, arg.Reason)
+	var i Refund
 	err := row.Scan(
 		&i.ID,
 		&i.SubscriptionID,
+		&i.UserID,
 		&i.RazorpayPaymentID,
 		&i.Amount,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.IsDeleted,
+		&i.Currency,
+		&i.Status,
+		&i.Reason,
+		&i.RazorpayRefundID,
+		&i.Attempts,
+		&i.NextAttemptAt,
+		&i.LastError,
+		&i.ProcessedAt,
 	)
 	return i, err
 }
 
+const getRefundedAmountByPaymentID = `-- name: GetRefundedAmountByPaymentID :one
+SELECT COALESCE(SUM(amount), 0)::bigint AS refunded FROM refunds
+WHERE razorpay_payment_id = $1 AND status <> 'failed'
+`
+
+func (q *Queries) GetRefundedAmountByPaymentID(ctx context.Context, razorpayPaymentID string) (int64, error) {
+	row := q.db.QueryRow(ctx, getRefundedAmountByPaymentID, razorpayPaymentID)
+	var refunded int64
+	err := row.Scan(&refunded)
+	return refunded, err
+}
+
+const getRefundsByPaymentID = `-- name: GetRefundsByPaymentID :many
+SELECT id, subscription_id, razorpay_payment_id, amount, currency, created_at, updated_at, status, razorpay_refund_id, processed_at
+FROM refunds WHERE razorpay_payment_id = $1 ORDER BY created_at
+`
+
+type GetRefundsByPaymentIDRow struct {
+	ID                pgtype.UUID
+	SubscriptionID    pgtype.UUID
+	RazorpayPaymentID string
+	Amount            pgtype.Numeric
+	Currency          string
+	CreatedAt         pgtype.Timestamptz
+	UpdatedAt         pgtype.Timestamptz
+	Status            string
+	RazorpayRefundID  pgtype.Text
+	ProcessedAt       pgtype.Timestamptz
+}
+
+func (q *Queries) GetRefundsByPaymentID(ctx context.Context, razorpayPaymentID string) ([]GetRefundsByPaymentIDRow, error) {
+	rows, err := q.db.Query(ctx, getRefundsByPaymentID, razorpayPaymentID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetRefundsByPaymentIDRow
+	for rows.Next() {
+		var i GetRefundsByPaymentIDRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.SubscriptionID,
+			&i.RazorpayPaymentID,
+			&i.Amount,
+			&i.Currency,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Status,
+			&i.RazorpayRefundID,
+			&i.ProcessedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getRefundsByStatus = `-- name: GetRefundsByStatus :many
+SELECT id, subscription_id, user_id, razorpay_payment_id, amount, created_at, updated_at, is_deleted, currency, status, reason, razorpay_refund_id, attempts, next_attempt_at, last_error, processed_at
+FROM refunds
+WHERE status = $1
+ORDER BY updated_at DESC
+LIMIT $2
+`
+
+type GetRefundsByStatusParams struct {
+	Status string
+	Limit  int32
+}
+
+func (q *Queries) GetRefundsByStatus(ctx context.Context, arg GetRefundsByStatusParams) ([]Refund, error) {
+	rows, err := q.db.Query(ctx, getRefundsByStatus, arg.Status, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Refund
+	for rows.Next() {
+		var i Refund
+		if err := rows.Scan(
+			&i.ID,
+			&i.SubscriptionID,
+			&i.UserID,
+			&i.RazorpayPaymentID,
+			&i.Amount,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.IsDeleted,
+			&i.Currency,
+			&i.Status,
+			&i.Reason,
+			&i.RazorpayRefundID,
+			&i.Attempts,
+			&i.NextAttemptAt,
+			&i.LastError,
+			&i.ProcessedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getRefundsByUserID = `-- name: GetRefundsByUserID :many
-SELECT id, subscription_id, razorpay_payment_id, amount, created_at, updated_at
+SELECT id, subscription_id, razorpay_payment_id, amount, currency, created_at, updated_at, status, razorpay_refund_id, processed_at
 FROM refunds WHERE user_id = $1 ORDER BY created_at DESC
 `
 
@@ -90,8 +332,12 @@ type GetRefundsByUserIDRow struct {
 	SubscriptionID    pgtype.UUID
 	RazorpayPaymentID string
 	Amount            pgtype.Numeric
+	Currency          string
 	CreatedAt         pgtype.Timestamptz
 	UpdatedAt         pgtype.Timestamptz
+	Status            string
+	RazorpayRefundID  pgtype.Text
+	ProcessedAt       pgtype.Timestamptz
 }
 
 func (q *Queries) GetRefundsByUserID(ctx context.Context, userID pgtype.UUID) ([]GetRefundsByUserIDRow, error) {
@@ -108,8 +354,68 @@ func (q *Queries) GetRefundsByUserID(ctx context.Context, userID pgtype.UUID) ([
 			&i.SubscriptionID,
 			&i.RazorpayPaymentID,
 			&i.Amount,
+			&i.Currency,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Status,
+			&i.RazorpayRefundID,
+			&i.ProcessedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getRefundsCreatedBetween = `-- name: GetRefundsCreatedBetween :many
+SELECT id, subscription_id, razorpay_payment_id, amount, currency, created_at, updated_at, status, razorpay_refund_id, processed_at
+FROM refunds
+WHERE created_at >= $1 AND created_at < $2
+ORDER BY created_at
+`
+
+type GetRefundsCreatedBetweenParams struct {
+	CreatedFrom pgtype.Timestamptz
+	CreatedTo   pgtype.Timestamptz
+}
+
+type GetRefundsCreatedBetweenRow struct {
+	ID                pgtype.UUID
+	SubscriptionID    pgtype.UUID
+	RazorpayPaymentID string
+	Amount            pgtype.Numeric
+	Currency          string
+	CreatedAt         pgtype.Timestamptz
+	UpdatedAt         pgtype.Timestamptz
+	Status            string
+	RazorpayRefundID  pgtype.Text
+	ProcessedAt       pgtype.Timestamptz
+}
+
+func (q *Queries) GetRefundsCreatedBetween(ctx context.Context, arg GetRefundsCreatedBetweenParams) ([]GetRefundsCreatedBetweenRow, error) {
+	rows, err := q.db.Query(ctx, getRefundsCreatedBetween, arg.CreatedFrom, arg.CreatedTo)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetRefundsCreatedBetweenRow
+	for rows.Next() {
+		var i GetRefundsCreatedBetweenRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.SubscriptionID,
+			&i.RazorpayPaymentID,
+			&i.Amount,
+			&i.Currency,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.Status,
+			&i.RazorpayRefundID,
+			&i.ProcessedAt,
 		); err != nil {
 			return nil, err
 		}
@@ -121,9 +427,95 @@ func (q *Queries) GetRefundsByUserID(ctx context.Context, userID pgtype.UUID) ([
 	return items, nil
 }
 
+const lockPaymentRefunds = `-- name: LockPaymentRefunds :exec
+SELECT pg_advisory_xact_lock(hashtext($1))
+`
+
+func (q *Queries) LockPaymentRefunds(ctx context.Context, hashtext string) error {
+	_, err := q.db.Exec(ctx, lockPaymentRefunds, hashtext)
+	return err
+}
+
+const markRefundAttemptFailed = `-- name: MarkRefundAttemptFailed :exec
+UPDATE refunds SET status = $2, attempts = attempts + 1, next_attempt_at = $3, last_error = $4, updated_at = NOW()
+WHERE id = $1 AND status IN ('pending', 'submitted')
+`
+
+type MarkRefundAttemptFailedParams struct {
+	ID            pgtype.UUID
+	Status        string
+	NextAttemptAt pgtype.Timestamptz
+	LastError     pgtype.Text
+}
+
+func (q *Queries) MarkRefundAttemptFailed(ctx context.Context, arg MarkRefundAttemptFailedParams) error {
+	_, err := q.db.Exec(ctx, markRefundAttemptFailed,
+		arg.ID,
+		arg.Status,
+		arg.NextAttemptAt,
+		arg.LastError,
+	)
+	return err
+}
+
+const markRefundFailed = `-- name: MarkRefundFailed :execrows
+UPDATE refunds SET status = 'failed', last_error = $2, updated_at = NOW()
+WHERE id = $1 AND status IN ('pending', 'submitted')
+`
+
+type MarkRefundFailedParams struct {
+	ID        pgtype.UUID
+	LastError pgtype.Text
+}
+
+func (q *Queries) MarkRefundFailed(ctx context.Context, arg MarkRefundFailedParams) (int64, error) {
+	result, err := q.db.Exec(ctx, markRefundFailed, arg.ID, arg.LastError)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const markRefundProcessed = `-- name: MarkRefundProcessed :execrows
+UPDATE refunds SET status = 'processed', razorpay_refund_id = $2, last_error = NULL, processed_at = NOW(), updated_at = NOW()
+WHERE id = $1 AND status <> 'processed'
+`
+
+type MarkRefundProcessedParams struct {
+	ID               pgtype.UUID
+	RazorpayRefundID pgtype.Text
+}
+
+func (q *Queries) MarkRefundProcessed(ctx context.Context, arg MarkRefundProcessedParams) (int64, error) {
+	result, err := q.db.Exec(ctx, markRefundProcessed, arg.ID, arg.RazorpayRefundID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const markRefundSubmitted = `-- name: MarkRefundSubmitted :execrows
+UPDATE refunds SET status = 'submitted', razorpay_refund_id = $2, attempts = attempts + 1, next_attempt_at = $3, last_error = NULL, updated_at = NOW()
+WHERE id = $1 AND status IN ('pending', 'submitted')
+`
+
+type MarkRefundSubmittedParams struct {
+	ID               pgtype.UUID
+	RazorpayRefundID pgtype.Text
+	NextAttemptAt    pgtype.Timestamptz
+}
+
+func (q *Queries) MarkRefundSubmitted(ctx context.Context, arg MarkRefundSubmittedParams) (int64, error) {
+	result, err := q.db.Exec(ctx, markRefundSubmitted, arg.ID, arg.RazorpayRefundID, arg.NextAttemptAt)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
 const removeRefundByUserID = `-- name: RemoveRefundByUserID :one
 UPDATE refunds SET is_deleted = true, user_id = NULL WHERE user_id = $1
-RETURNING id, subscription_id, razorpay_payment_id, amount, created_at, updated_at
+RETURNING id, subscription_id, razorpay_payment_id, amount, currency, created_at, updated_at, status, razorpay_refund_id, processed_at
 `
 
 type RemoveRefundByUserIDRow struct {
@@ -131,8 +523,12 @@ type RemoveRefundByUserIDRow struct {
 	SubscriptionID    pgtype.UUID
 	RazorpayPaymentID string
 	Amount            pgtype.Numeric
+	Currency          string
 	CreatedAt         pgtype.Timestamptz
 	UpdatedAt         pgtype.Timestamptz
+	Status            string
+	RazorpayRefundID  pgtype.Text
+	ProcessedAt       pgtype.Timestamptz
 }
 
 func (q *Queries) RemoveRefundByUserID(ctx context.Context, userID pgtype.UUID) (RemoveRefundByUserIDRow, error) {
@@ -143,8 +539,25 @@ func (q *Queries) RemoveRefundByUserID(ctx context.Context, userID pgtype.UUID)
 		&i.SubscriptionID,
 		&i.RazorpayPaymentID,
 		&i.Amount,
+		&i.Currency,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Status,
+		&i.RazorpayRefundID,
+		&i.ProcessedAt,
 	)
 	return i, err
 }
+
+const retryFailedRefund = `-- name: RetryFailedRefund :execrows
+UPDATE refunds SET status = 'pending', attempts = 0, next_attempt_at = NOW(), updated_at = NOW()
+WHERE id = $1 AND status = 'failed'
+`
+
+func (q *Queries) RetryFailedRefund(ctx context.Context, id pgtype.UUID) (int64, error) {
+	result, err := q.db.Exec(ctx, retryFailedRefund, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
@@ -94,49 +94,6 @@ func (q *Queries) GetAllSubscriptionUsage(ctx context.Context, userID pgtype.UUI
 	return items, nil
 }
 
-const getAllSubscriptionUsageWithSubscription = `-- name: GetAllSubscriptionUsageWithSubscription :many
-SELECT su.id, su.valid_from, su.valid_until, su.usage, s.id AS subscription_id, coalesce(s.plan_type::varchar(40), 'Free') AS plan_type
-FROM subscription_usage AS su
-LEFT JOIN subscriptions AS s ON s.id = su.subscription_id 
-WHERE su.user_id = $1 ORDER BY su.created_at DESC
-`
-
-type GetAllSubscriptionUsageWithSubscriptionRow struct {
-	ID             pgtype.UUID
-	ValidFrom      pgtype.Timestamptz
-	ValidUntil     pgtype.Timestamptz
-	Usage          json.RawMessage
-	SubscriptionID pgtype.UUID
-	PlanType       interface{}
-}
-
-func (q *Queries) GetAllSubscriptionUsageWithSubscription(ctx context.Context, userID pgtype.UUID) ([]GetAllSubscriptionUsageWithSubscriptionRow, error) {
-	rows, err := q.db.Query(ctx, getAllSubscriptionUsageWithSubscription, userID)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-	var items []GetAllSubscriptionUsageWithSubscriptionRow
-	for rows.Next() {
-		var i GetAllSubscriptionUsageWithSubscriptionRow
-		if err := rows.Scan(
-			&i.ID,
-			&i.ValidFrom,
-			&i.ValidUntil,
-			&i.Usage,
-			&i.SubscriptionID,
-			&i.PlanType,
-		); err != nil {
-			return nil, err
-		}
-		items = append(items, i)
-	}
-	if err := rows.Err(); err != nil {
-		return nil, err
-	}
-	return items, nil
-}
-
 const getCurrentSubscriptionUsageByUserID = `-- name: GetCurrentSubscriptionUsageByUserID :one
 SELECT id, subscription_id, valid_from, valid_until, usage
 FROM subscription_usage WHERE user_id = $1 AND valid_from <= NOW() AND valid_until >= NOW() ORDER BY created_at DESC LIMIT 1
@@ -219,6 +176,120 @@ func (q *Queries) GetSubscriptionUsageByID(ctx context.Context, userID pgtype.UU
 	return i, err
 }
 
+const getSubscriptionUsageBySubscriptionID = `-- name: GetSubscriptionUsageBySubscriptionID :one
+SELECT id, subscription_id, valid_from, valid_until, usage
+FROM subscription_usage WHERE subscription_id = $1 ORDER BY created_at DESC LIMIT 1
+`
+
+type GetSubscriptionUsageBySubscriptionIDRow struct {
+	ID             pgtype.UUID
+	SubscriptionID pgtype.UUID
+	ValidFrom      pgtype.Timestamptz
+	ValidUntil     pgtype.Timestamptz
+	Usage          json.RawMessage
+}
+
+func (q *Queries) GetSubscriptionUsageBySubscriptionID(ctx context.Context, subscriptionID pgtype.UUID) (GetSubscriptionUsageBySubscriptionIDRow, error) {
+	row := q.db.QueryRow(ctx, getSubscriptionUsageBySubscriptionID, subscriptionID)
+	var i GetSubscriptionUsageBySubscriptionIDRow
+	err := row.Scan(
+		&i.ID,
+		&i.SubscriptionID,
+		&i.ValidFrom,
+		&i.ValidUntil,
+		&i.Usage,
+	)
+	return i, err
+}
+
+const listSubscriptionUsageWithSubscription = `-- name: ListSubscriptionUsageWithSubscription :many
+SELECT su.id, su.valid_from, su.valid_until, su.usage, s.id AS subscription_id, coalesce(s.plan_type::varchar(40), 'Free') AS plan_type, su.created_at
+FROM subscription_usage AS su
+LEFT JOIN subscriptions AS s ON s.id = su.subscription_id
+WHERE su.user_id = $1
+  AND ($2::text IS NULL OR lower(coalesce(s.plan_type, 'free')) = $2)
+  AND ($3::timestamptz IS NULL OR su.valid_until >= $3)
+  AND ($4::timestamptz IS NULL OR su.valid_from < $4)
+  AND ($5::timestamptz IS NULL OR (su.created_at, su.id) < ($5, $6::uuid))
+ORDER BY su.created_at DESC, su.id DESC
+LIMIT $7
+`
+
+type ListSubscriptionUsageWithSubscriptionParams struct {
+	UserID          pgtype.UUID
+	PlanType        pgtype.Text
+	PeriodFrom      pgtype.Timestamptz
+	PeriodTo        pgtype.Timestamptz
+	CursorCreatedAt pgtype.Timestamptz
+	CursorID        pgtype.UUID
+	RowLimit        int32
+}
+
+type ListSubscriptionUsageWithSubscriptionRow struct {
+	ID             pgtype.UUID
+	ValidFrom      pgtype.Timestamptz
+	ValidUntil     pgtype.Timestamptz
+	Usage          json.RawMessage
+	SubscriptionID pgtype.UUID
+	PlanType       interface{}
+	CreatedAt      pgtype.Timestamptz
+}
+
+func (q *Queries) ListSubscriptionUsageWithSubscription(ctx context.Context, arg ListSubscriptionUsageWithSubscriptionParams) ([]ListSubscriptionUsageWithSubscriptionRow, error) {
+	rows, err := q.db.Query(ctx, listSubscriptionUsageWithSubscription,
+		arg.UserID,
+		arg.PlanType,
+		arg.PeriodFrom,
+		arg.PeriodTo,
+		arg.CursorCreatedAt,
+		arg.CursorID,
+		arg.RowLimit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListSubscriptionUsageWithSubscriptionRow
+	for rows.Next() {
+		var i ListSubscriptionUsageWithSubscriptionRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.ValidFrom,
+			&i.ValidUntil,
+			&i.Usage,
+			&i.SubscriptionID,
+			&i.PlanType,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const moveSubscriptionUsageToSubscription = `-- name: MoveSubscriptionUsageToSubscription :execrows
+UPDATE subscription_usage SET subscription_id = $1, valid_until = $2, updated_at = NOW()
+WHERE subscription_id = $3
+`
+
+type MoveSubscriptionUsageToSubscriptionParams struct {
+	NewSubscriptionID pgtype.UUID
+	ValidUntil        pgtype.Timestamptz
+	SubscriptionID    pgtype.UUID
+}
+
+func (q *Queries) MoveSubscriptionUsageToSubscription(ctx context.Context, arg MoveSubscriptionUsageToSubscriptionParams) (int64, error) {
+	result, err := q.db.Exec(ctx, moveSubscriptionUsageToSubscription, arg.NewSubscriptionID, arg.ValidUntil, arg.SubscriptionID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
 const removeSubscriptionUsageByUserID = `-- name: RemoveSubscriptionUsageByUserID :one
 DELETE FROM subscription_usage WHERE user_id = $1
 RETURNING id, subscription_id, valid_from, valid_until, usage
@@ -11,10 +11,61 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+const cancelSubscription = `-- name: CancelSubscription :one
+UPDATE subscriptions SET canceled_at = NOW(), updated_at = NOW() WHERE id = $1
+RETURNING id, user_id, plan_id, plan_type, purchase_date, valid_from, order_id, valid_until, razorpay_payment_id, razorpay_order_id, razorpay_signature, canceled_at
+`
+
+type CancelSubscriptionRow struct {
+	ID                pgtype.UUID
+	UserID            pgtype.UUID
+	PlanID            pgtype.UUID
+	PlanType          string
+	PurchaseDate      pgtype.Timestamptz
+	ValidFrom         pgtype.Timestamptz
+	OrderID           string
+	ValidUntil        pgtype.Timestamptz
+	RazorpayPaymentID string
+	RazorpayOrderID   string
+	RazorpaySignature string
+	CanceledAt        pgtype.Timestamptz
+}
+
+func (q *Queries) CancelSubscription(ctx context.Context, id pgtype.UUID) (CancelSubscriptionRow, error) {
+	row := q.db.QueryRow(ctx, cancelSubscription, id)
+	var i CancelSubscriptionRow
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.PlanID,
+		&i.PlanType,
+		&i.PurchaseDate,
+		&i.ValidFrom,
+		&i.OrderID,
+		&i.ValidUntil,
+		&i.RazorpayPaymentID,
+		&i.RazorpayOrderID,
+		&i.RazorpaySignature,
+		&i.CanceledAt,
+	)
+	return i, err
+}
+
+const countQueuedSubscriptionsByUserID = `-- name: CountQueuedSubscriptionsByUserID :one
+SELECT count(*) FROM subscriptions WHERE user_id = $1 AND status = 'pending'
+`
+
+func (q *Queries) CountQueuedSubscriptionsByUserID(ctx context.Context, userID pgtype.UUID) (int64, error) {
+	row := q.db.QueryRow(ctx, countQueuedSubscriptionsByUserID, userID)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
 const createSubscription = `-- name: CreateSubscription :one
-INSERT INTO subscriptions (user_id, plan_id, plan_type, purchase_date, valid_from, order_id, valid_until, razorpay_payment_id, razorpay_order_id, razorpay_signature)
-VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
-RETURNING id, user_id, plan_id, plan_type, purchase_date, valid_from, order_id, valid_until, razorpay_payment_id, razorpay_order_id, razorpay_signature
+INSERT INTO subscriptions (user_id, plan_id, plan_type, purchase_date, valid_from, order_id, valid_until, razorpay_payment_id, razorpay_order_id, razorpay_signature, amount, proration_credit, upgraded_from, status, currency)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15)
+RETURNING id, user_id, plan_id, plan_type, purchase_date, valid_from, order_id, valid_until, razorpay_payment_id, razorpay_order_id, razorpay_signature, amount, proration_credit, upgraded_from, status, currency
 `
 
 type CreateSubscriptionParams struct {
@@ -28,6 +79,11 @@ type CreateSubscriptionParams struct {
 	RazorpayPaymentID string
 	RazorpayOrderID   string
 	RazorpaySignature string
+	Amount            pgtype.Numeric
+	ProrationCredit   pgtype.Numeric
+	UpgradedFrom      pgtype.UUID
+	Status            string
+	Currency          string
 }
 
 type CreateSubscriptionRow struct {
@@ -42,6 +98,11 @@ type CreateSubscriptionRow struct {
 	RazorpayPaymentID string
 	RazorpayOrderID   string
 	RazorpaySignature string
+	Amount            pgtype.Numeric
+	ProrationCredit   pgtype.Numeric
+	UpgradedFrom      pgtype.UUID
+	Status            string
+	Currency          string
 }
 
 func (q *Queries) CreateSubscription(ctx context.Context, arg CreateSubscriptionParams) (CreateSubscriptionRow, error) {
@@ -56,6 +117,11 @@ func (q *Queries) CreateSubscription(ctx context.Context, arg CreateSubscription
 		arg.RazorpayPaymentID,
 		arg.RazorpayOrderID,
 		arg.RazorpaySignature,
+		arg.Amount,
+		arg.ProrationCredit,
+		arg.UpgradedFrom,
+		arg.Status,
+		arg.Currency,
 	)
 	var i CreateSubscriptionRow
 	err := row.Scan(
@@ -70,16 +136,171 @@ func (q *Queries) CreateSubscription(ctx context.Context, arg CreateSubscription
 		&i.RazorpayPaymentID,
 		&i.RazorpayOrderID,
 		&i.RazorpaySignature,
+		&i.Amount,
+		&i.ProrationCredit,
+		&i.UpgradedFrom,
+		&i.Status,
+		&i.Currency,
 	)
 	return i, err
 }
 
-const getAllSubscriptions = `-- name: GetAllSubscriptions :many
-SELECT id, user_id, plan_id, plan_type, purchase_date, valid_from, order_id, valid_until, razorpay_payment_id, razorpay_order_id, razorpay_signature
-FROM subscriptions WHERE user_id = $1 ORDER BY created_at DESC
+const getActiveSubscriptionByUserID = `-- name: GetActiveSubscriptionByUserID :one
+SELECT id, user_id, plan_id, plan_type, purchase_date, valid_from, order_id, valid_until, razorpay_payment_id, razorpay_order_id, razorpay_signature, amount, canceled_at, status, currency
+FROM subscriptions
+WHERE user_id = $1 AND status IN ('trialing', 'active', 'past_due', 'canceled')
+  AND valid_from <= NOW() AND valid_until > NOW()
+ORDER BY valid_until DESC LIMIT 1
+`
+
+type GetActiveSubscriptionByUserIDRow struct {
+	ID                pgtype.UUID
+	UserID            pgtype.UUID
+	PlanID            pgtype.UUID
+	PlanType          string
+	PurchaseDate      pgtype.Timestamptz
+	ValidFrom         pgtype.Timestamptz
+	OrderID           string
+	ValidUntil        pgtype.Timestamptz
+	RazorpayPaymentID string
+	RazorpayOrderID   string
+	RazorpaySignature string
+	Amount            pgtype.Numeric
+	CanceledAt        pgtype.Timestamptz
+	Status            string
+	Currency          string
+}
+
+func (q *Queries) GetActiveSubscriptionByUserID(ctx context.Context, userID pgtype.UUID) (GetActiveSubscriptionByUserIDRow, error) {
+	row := q.db.QueryRow(ctx, getActiveSubscriptionByUserID, userID)
+	var i GetActiveSubscriptionByUserIDRow
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.PlanID,
+		&i.PlanType,
+		&i.PurchaseDate,
+		&i.ValidFrom,
+		&i.OrderID,
+		&i.ValidUntil,
+		&i.RazorpayPaymentID,
+		&i.RazorpayOrderID,
+		&i.RazorpaySignature,
+		&i.Amount,
+		&i.CanceledAt,
+		&i.Status,
+		&i.Currency,
+	)
+	return i, err
+}
+
+const getLatestLiveSubscriptionByUserID = `-- name: GetLatestLiveSubscriptionByUserID :one
+SELECT id, user_id, plan_id, plan_type, purchase_date, valid_from, order_id, valid_until, razorpay_payment_id, razorpay_order_id, razorpay_signature, status
+FROM subscriptions WHERE user_id = $1 AND status IN ('pending', 'trialing', 'active', 'past_due', 'canceled')
+  AND valid_until > NOW()
+ORDER BY valid_until DESC LIMIT 1
+`
+
+type GetLatestLiveSubscriptionByUserIDRow struct {
+	ID                pgtype.UUID
+	UserID            pgtype.UUID
+	PlanID            pgtype.UUID
+	PlanType          string
+	PurchaseDate      pgtype.Timestamptz
+	ValidFrom         pgtype.Timestamptz
+	OrderID           string
+	ValidUntil        pgtype.Timestamptz
+	RazorpayPaymentID string
+	RazorpayOrderID   string
+	RazorpaySignature string
+	Status            string
+}
+
+func (q *Queries) GetLatestLiveSubscriptionByUserID(ctx context.Context, userID pgtype.UUID) (GetLatestLiveSubscriptionByUserIDRow, error) {
+	row := q.db.QueryRow(ctx, getLatestLiveSubscriptionByUserID, userID)
+	var i GetLatestLiveSubscriptionByUserIDRow
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.PlanID,
+		&i.PlanType,
+		&i.PurchaseDate,
+		&i.ValidFrom,
+		&i.OrderID,
+		&i.ValidUntil,
+		&i.RazorpayPaymentID,
+		&i.RazorpayOrderID,
+		&i.RazorpaySignature,
+		&i.Status,
+	)
+	return i, err
+}
+
+const getPaidSubscriptionsCreatedBetween = `-- name: GetPaidSubscriptionsCreatedBetween :many
+SELECT id, user_id, plan_type, razorpay_payment_id, razorpay_order_id, amount, currency, status, created_at
+FROM subscriptions
+WHERE razorpay_payment_id <> '' AND created_at >= $1 AND created_at < $2
+ORDER BY created_at
+`
+
+type GetPaidSubscriptionsCreatedBetweenParams struct {
+	CreatedFrom pgtype.Timestamptz
+	CreatedTo   pgtype.Timestamptz
+}
+
+type GetPaidSubscriptionsCreatedBetweenRow struct {
+	ID                pgtype.UUID
+	UserID            pgtype.UUID
+	PlanType          string
+	RazorpayPaymentID string
+	RazorpayOrderID   string
+	Amount            pgtype.Numeric
+	Currency          string
+	Status            string
+	CreatedAt         pgtype.Timestamptz
+}
+
+func (q *Queries) GetPaidSubscriptionsCreatedBetween(ctx context.Context, arg GetPaidSubscriptionsCreatedBetweenParams) ([]GetPaidSubscriptionsCreatedBetweenRow, error) {
+	rows, err := q.db.Query(ctx, getPaidSubscriptionsCreatedBetween, arg.CreatedFrom, arg.CreatedTo)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetPaidSubscriptionsCreatedBetweenRow
+	for rows.Next() {
+		var i GetPaidSubscriptionsCreatedBetweenRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.PlanType,
+			&i.RazorpayPaymentID,
+			&i.RazorpayOrderID,
+			&i.Amount,
+			&i.Currency,
+			&i.Status,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getQueuedSubscriptionsByUserID = `-- name: GetQueuedSubscriptionsByUserID :many
+SELECT id, user_id, plan_id, plan_type, purchase_date, valid_from, order_id, valid_until, razorpay_payment_id, razorpay_order_id, razorpay_signature, amount, currency
+FROM subscriptions WHERE user_id = $1 AND status = 'pending' AND valid_from >= $2 ORDER BY valid_from ASC
 `
 
-type GetAllSubscriptionsRow struct {
+type GetQueuedSubscriptionsByUserIDParams struct {
+	UserID    pgtype.UUID
+	ValidFrom pgtype.Timestamptz
+}
+
+type GetQueuedSubscriptionsByUserIDRow struct {
 	ID                pgtype.UUID
 	UserID            pgtype.UUID
 	PlanID            pgtype.UUID
@@ -91,17 +312,19 @@ type GetAllSubscriptionsRow struct {
 	RazorpayPaymentID string
 	RazorpayOrderID   string
 	RazorpaySignature string
+	Amount            pgtype.Numeric
+	Currency          string
 }
 
-func (q *Queries) GetAllSubscriptions(ctx context.Context, userID pgtype.UUID) ([]GetAllSubscriptionsRow, error) {
-	rows, err := q.db.Query(ctx, getAllSubscriptions, userID)
+func (q *Queries) GetQueuedSubscriptionsByUserID(ctx context.Context, arg GetQueuedSubscriptionsByUserIDParams) ([]GetQueuedSubscriptionsByUserIDRow, error) {
+	rows, err := q.db.Query(ctx, getQueuedSubscriptionsByUserID, arg.UserID, arg.ValidFrom)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []GetAllSubscriptionsRow
+	var items []GetQueuedSubscriptionsByUserIDRow
 	for rows.Next() {
-		var i GetAllSubscriptionsRow
+		var i GetQueuedSubscriptionsByUserIDRow
 		if err := rows.Scan(
 			&i.ID,
 			&i.UserID,
@@ -114,6 +337,8 @@ func (q *Queries) GetAllSubscriptions(ctx context.Context, userID pgtype.UUID) (
 			&i.RazorpayPaymentID,
 			&i.RazorpayOrderID,
 			&i.RazorpaySignature,
+			&i.Amount,
+			&i.Currency,
 		); err != nil {
 			return nil, err
 		}
@@ -126,7 +351,7 @@ func (q *Queries) GetAllSubscriptions(ctx context.Context, userID pgtype.UUID) (
 }
 
 const getSubscriptionByID = `-- name: GetSubscriptionByID :one
-SELECT id, user_id, plan_id, plan_type, purchase_date, valid_from, order_id, valid_until, razorpay_payment_id, razorpay_order_id, razorpay_signature
+SELECT id, user_id, plan_id, plan_type, purchase_date, valid_from, order_id, valid_until, razorpay_payment_id, razorpay_order_id, razorpay_signature, status
 FROM subscriptions WHERE id = $1 ORDER BY created_at DESC LIMIT 1
 `
 
@@ -142,6 +367,7 @@ type GetSubscriptionByIDRow struct {
 	RazorpayPaymentID string
 	RazorpayOrderID   string
 	RazorpaySignature string
+	Status            string
 }
 
 func (q *Queries) GetSubscriptionByID(ctx context.Context, id pgtype.UUID) (GetSubscriptionByIDRow, error) {
@@ -159,12 +385,13 @@ func (q *Queries) GetSubscriptionByID(ctx context.Context, id pgtype.UUID) (GetS
 		&i.RazorpayPaymentID,
 		&i.RazorpayOrderID,
 		&i.RazorpaySignature,
+		&i.Status,
 	)
 	return i, err
 }
 
 const getSubscriptionByPaymentID = `-- name: GetSubscriptionByPaymentID :one
-SELECT id, user_id, plan_id, plan_type, purchase_date, valid_from, order_id, valid_until, razorpay_payment_id, razorpay_order_id, razorpay_signature
+SELECT id, user_id, plan_id, plan_type, purchase_date, valid_from, order_id, valid_until, razorpay_payment_id, razorpay_order_id, razorpay_signature, amount, currency, status
 FROM subscriptions WHERE razorpay_payment_id = $1
 `
 
@@ -180,6 +407,9 @@ type GetSubscriptionByPaymentIDRow struct {
 	RazorpayPaymentID string
 	RazorpayOrderID   string
 	RazorpaySignature string
+	Amount            pgtype.Numeric
+	Currency          string
+	Status            string
 }
 
 func (q *Queries) GetSubscriptionByPaymentID(ctx context.Context, razorpayPaymentID string) (GetSubscriptionByPaymentIDRow, error) {
@@ -197,6 +427,9 @@ func (q *Queries) GetSubscriptionByPaymentID(ctx context.Context, razorpayPaymen
 		&i.RazorpayPaymentID,
 		&i.RazorpayOrderID,
 		&i.RazorpaySignature,
+		&i.Amount,
+		&i.Currency,
+		&i.Status,
 	)
 	return i, err
 }
@@ -282,6 +515,212 @@ func (q *Queries) GetSubscriptionByUserIDOrderID(ctx context.Context, arg GetSub
 	return i, err
 }
 
+const getSubscriptionStatusForUpdate = `-- name: GetSubscriptionStatusForUpdate :one
+SELECT status FROM subscriptions WHERE id = $1 FOR UPDATE
+`
+
+func (q *Queries) GetSubscriptionStatusForUpdate(ctx context.Context, id pgtype.UUID) (string, error) {
+	row := q.db.QueryRow(ctx, getSubscriptionStatusForUpdate, id)
+	var status string
+	err := row.Scan(&status)
+	return status, err
+}
+
+const getSubscriptionsDueForActivation = `-- name: GetSubscriptionsDueForActivation :many
+SELECT id, user_id FROM subscriptions WHERE status = 'pending' AND valid_from <= NOW()
+`
+
+type GetSubscriptionsDueForActivationRow struct {
+	ID     pgtype.UUID
+	UserID pgtype.UUID
+}
+
+func (q *Queries) GetSubscriptionsDueForActivation(ctx context.Context) ([]GetSubscriptionsDueForActivationRow, error) {
+	rows, err := q.db.Query(ctx, getSubscriptionsDueForActivation)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetSubscriptionsDueForActivationRow
+	for rows.Next() {
+		var i GetSubscriptionsDueForActivationRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getSubscriptionsDueForExpiry = `-- name: GetSubscriptionsDueForExpiry :many
+SELECT id, user_id FROM subscriptions
+WHERE status IN ('trialing', 'active', 'past_due', 'canceled') AND valid_until <= NOW()
+`
+
+type GetSubscriptionsDueForExpiryRow struct {
+	ID     pgtype.UUID
+	UserID pgtype.UUID
+}
+
+func (q *Queries) GetSubscriptionsDueForExpiry(ctx context.Context) ([]GetSubscriptionsDueForExpiryRow, error) {
+	rows, err := q.db.Query(ctx, getSubscriptionsDueForExpiry)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetSubscriptionsDueForExpiryRow
+	for rows.Next() {
+		var i GetSubscriptionsDueForExpiryRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getSubscriptionsDueForRenewalReminder = `-- name: GetSubscriptionsDueForRenewalReminder :many
+SELECT s.id, s.user_id, s.plan_type, s.valid_until
+FROM subscriptions s
+WHERE s.status = 'active' AND s.renewal_reminder_sent_at IS NULL
+  AND s.valid_until > NOW() AND s.valid_until <= $1
+  AND NOT EXISTS (
+    SELECT 1 FROM subscriptions q
+    WHERE q.user_id = s.user_id AND q.status = 'pending' AND q.valid_from >= s.valid_until
+  )
+`
+
+type GetSubscriptionsDueForRenewalReminderRow struct {
+	ID         pgtype.UUID
+	UserID     pgtype.UUID
+	PlanType   string
+	ValidUntil pgtype.Timestamptz
+}
+
+func (q *Queries) GetSubscriptionsDueForRenewalReminder(ctx context.Context, validUntil pgtype.Timestamptz) ([]GetSubscriptionsDueForRenewalReminderRow, error) {
+	rows, err := q.db.Query(ctx, getSubscriptionsDueForRenewalReminder, validUntil)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetSubscriptionsDueForRenewalReminderRow
+	for rows.Next() {
+		var i GetSubscriptionsDueForRenewalReminderRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.PlanType,
+			&i.ValidUntil,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listSubscriptions = `-- name: ListSubscriptions :many
+SELECT id, user_id, plan_id, plan_type, purchase_date, valid_from, order_id, valid_until, razorpay_payment_id, razorpay_order_id, razorpay_signature, created_at
+FROM subscriptions
+WHERE user_id = $1
+  AND ($2::text IS NULL OR lower(plan_type) = $2)
+  AND ($3::timestamptz IS NULL OR valid_until >= $3)
+  AND ($4::timestamptz IS NULL OR valid_from < $4)
+  AND ($5::timestamptz IS NULL OR (created_at, id) < ($5, $6::uuid))
+ORDER BY created_at DESC, id DESC
+LIMIT $7
+`
+
+type ListSubscriptionsParams struct {
+	UserID          pgtype.UUID
+	PlanType        pgtype.Text
+	PeriodFrom      pgtype.Timestamptz
+	PeriodTo        pgtype.Timestamptz
+	CursorCreatedAt pgtype.Timestamptz
+	CursorID        pgtype.UUID
+	RowLimit        int32
+}
+
+type ListSubscriptionsRow struct {
+	ID                pgtype.UUID
+	UserID            pgtype.UUID
+	PlanID            pgtype.UUID
+	PlanType          string
+	PurchaseDate      pgtype.Timestamptz
+	ValidFrom         pgtype.Timestamptz
+	OrderID           string
+	ValidUntil        pgtype.Timestamptz
+	RazorpayPaymentID string
+	RazorpayOrderID   string
+	RazorpaySignature string
+	CreatedAt         pgtype.Timestamptz
+}
+
+func (q *Queries) ListSubscriptions(ctx context.Context, arg ListSubscriptionsParams) ([]ListSubscriptionsRow, error) {
+	rows, err := q.db.Query(ctx, listSubscriptions,
+		arg.UserID,
+		arg.PlanType,
+		arg.PeriodFrom,
+		arg.PeriodTo,
+		arg.CursorCreatedAt,
+		arg.CursorID,
+		arg.RowLimit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListSubscriptionsRow
+	for rows.Next() {
+		var i ListSubscriptionsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.PlanID,
+			&i.PlanType,
+			&i.PurchaseDate,
+			&i.ValidFrom,
+			&i.OrderID,
+			&i.ValidUntil,
+			&i.RazorpayPaymentID,
+			&i.RazorpayOrderID,
+			&i.RazorpaySignature,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const markRenewalReminderSent = `-- name: MarkRenewalReminderSent :exec
+UPDATE subscriptions SET renewal_reminder_sent_at = NOW(), updated_at = NOW() WHERE id = $1
+`
+
+func (q *Queries) MarkRenewalReminderSent(ctx context.Context, id pgtype.UUID) error {
+	_, err := q.db.Exec(ctx, markRenewalReminderSent, id)
+	return err
+}
+
 const removeSubscriptionByUserID = `-- name: RemoveSubscriptionByUserID :one
 UPDATE subscriptions SET is_deleted = true, user_id = NULL WHERE user_id = $1
 RETURNING id, user_id, plan_id, plan_type, purchase_date, valid_from, order_id, valid_until, razorpay_payment_id, razorpay_order_id, razorpay_signature
@@ -319,3 +758,119 @@ func (q *Queries) RemoveSubscriptionByUserID(ctx context.Context, userID pgtype.
 	)
 	return i, err
 }
+
+const updateSubscriptionPlan = `-- name: UpdateSubscriptionPlan :one
+UPDATE subscriptions SET plan_id = $2, plan_type = $3, updated_at = NOW() WHERE id = $1
+RETURNING id, user_id, plan_id, plan_type, purchase_date, valid_from, order_id, valid_until, razorpay_payment_id, razorpay_order_id, razorpay_signature
+`
+
+type UpdateSubscriptionPlanParams struct {
+	ID       pgtype.UUID
+	PlanID   pgtype.UUID
+	PlanType string
+}
+
+type UpdateSubscriptionPlanRow struct {
+	ID                pgtype.UUID
+	UserID            pgtype.UUID
+	PlanID            pgtype.UUID
+	PlanType          string
+	PurchaseDate      pgtype.Timestamptz
+	ValidFrom         pgtype.Timestamptz
+	OrderID           string
+	ValidUntil        pgtype.Timestamptz
+	RazorpayPaymentID string
+	RazorpayOrderID   string
+	RazorpaySignature string
+}
+
+func (q *Queries) UpdateSubscriptionPlan(ctx context.Context, arg UpdateSubscriptionPlanParams) (UpdateSubscriptionPlanRow, error) {
+	row := q.db.QueryRow(ctx, updateSubscriptionPlan, arg.ID, arg.PlanID, arg.PlanType)
+	var i UpdateSubscriptionPlanRow
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.PlanID,
+		&i.PlanType,
+		&i.PurchaseDate,
+		&i.ValidFrom,
+		&i.OrderID,
+		&i.ValidUntil,
+		&i.RazorpayPaymentID,
+		&i.RazorpayOrderID,
+		&i.RazorpaySignature,
+	)
+	return i, err
+}
+
+const updateSubscriptionStatus = `-- name: UpdateSubscriptionStatus :exec
+UPDATE subscriptions SET status = $2, updated_at = NOW() WHERE id = $1
+`
+
+type UpdateSubscriptionStatusParams struct {
+	ID     pgtype.UUID
+	Status string
+}
+
+func (q *Queries) UpdateSubscriptionStatus(ctx context.Context, arg UpdateSubscriptionStatusParams) error {
+	_, err := q.db.Exec(ctx, updateSubscriptionStatus, arg.ID, arg.Status)
+	return err
+}
+
+const updateSubscriptionValidUntil = `-- name: UpdateSubscriptionValidUntil :one
+UPDATE subscriptions SET valid_until = $2, updated_at = NOW() WHERE id = $1
+RETURNING id, user_id, plan_id, plan_type, purchase_date, valid_from, order_id, valid_until, razorpay_payment_id, razorpay_order_id, razorpay_signature
+`
+
+type UpdateSubscriptionValidUntilParams struct {
+	ID         pgtype.UUID
+	ValidUntil pgtype.Timestamptz
+}
+
+type UpdateSubscriptionValidUntilRow struct {
+	ID                pgtype.UUID
+	UserID            pgtype.UUID
+	PlanID            pgtype.UUID
+	PlanType          string
+	PurchaseDate      pgtype.Timestamptz
+	ValidFrom         pgtype.Timestamptz
+	OrderID           string
+	ValidUntil        pgtype.Timestamptz
+	RazorpayPaymentID string
+	RazorpayOrderID   string
+	RazorpaySignature string
+}
+
+func (q *Queries) UpdateSubscriptionValidUntil(ctx context.Context, arg UpdateSubscriptionValidUntilParams) (UpdateSubscriptionValidUntilRow, error) {
+	row := q.db.QueryRow(ctx, updateSubscriptionValidUntil, arg.ID, arg.ValidUntil)
+	var i UpdateSubscriptionValidUntilRow
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.PlanID,
+		&i.PlanType,
+		&i.PurchaseDate,
+		&i.ValidFrom,
+		&i.OrderID,
+		&i.ValidUntil,
+		&i.RazorpayPaymentID,
+		&i.RazorpayOrderID,
+		&i.RazorpaySignature,
+	)
+	return i, err
+}
+
+const updateSubscriptionValidity = `-- name: UpdateSubscriptionValidity :exec
+UPDATE subscriptions SET valid_from = $2, valid_until = $3, updated_at = NOW() WHERE id = $1
+`
+
+type UpdateSubscriptionValidityParams struct {
+	ID         pgtype.UUID
+	ValidFrom  pgtype.Timestamptz
+	ValidUntil pgtype.Timestamptz
+}
+
+func (q *Queries) UpdateSubscriptionValidity(ctx context.Context, arg UpdateSubscriptionValidityParams) error {
+	_, err := q.db.Exec(ctx, updateSubscriptionValidity, arg.ID, arg.ValidFrom, arg.ValidUntil)
+	return err
+}
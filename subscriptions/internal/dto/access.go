@@ -0,0 +1,56 @@
+package dto
+
+import (
+	"github.com/google/uuid"
+	"github.com/parbhat-cpp/fuse/subscriptions/constants"
+	"github.com/parbhat-cpp/fuse/subscriptions/internal/services"
+)
+
+// Plan is a plan with its prices and limits. A limit of -1 is unlimited.
+type Plan struct {
+	ID          uuid.UUID                      `json:"id"`
+	Name        string                         `json:"name"`
+	Description string                         `json:"description"`
+	Prices      map[constants.Currency]float64 `json:"prices"`
+	ValidMonths int                            `json:"valid_months"`
+	Features    []string                       `json:"features"`
+	Limits      map[string]int                 `json:"limits"`
+}
+
+// Access is the answer to an access request and the quota left after it.
+type Access struct {
+	Plan        *Plan `json:"plan"`
+	Allowed     bool  `json:"allowed"`
+	LimitLeft   int   `json:"limit_left"`
+	PlanExpired bool  `json:"plan_expired"`
+}
+
+func NewAccess(res *services.AccessResponse) *Access {
+	if res == nil {
+		return nil
+	}
+
+	access := &Access{
+		Allowed:     res.IsAllowed,
+		LimitLeft:   res.LimitLeft,
+		PlanExpired: res.PlanExpired,
+	}
+
+	if res.Plan != nil && res.Plan.ID != uuid.Nil {
+		access.Plan = NewPlan(*res.Plan)
+	}
+
+	return access
+}
+
+func NewPlan(plan constants.Plan) *Plan {
+	return &Plan{
+		ID:          plan.ID,
+		Name:        plan.Name,
+		Description: plan.Description,
+		Prices:      plan.Prices,
+		ValidMonths: plan.ValidMonths,
+		Features:    plan.Features,
+		Limits:      plan.FeaturesJson,
+	}
+}
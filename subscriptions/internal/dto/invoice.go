@@ -0,0 +1,108 @@
+package dto
+
+import (
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/parbhat-cpp/fuse/subscriptions/internal/db/sqlc"
+	"github.com/parbhat-cpp/fuse/subscriptions/pkg/utils"
+)
+
+// Invoice is a tax invoice issued for a payment. Amounts are in the currency's minor unit.
+type Invoice struct {
+	ID              uuid.UUID  `json:"id"`
+	InvoiceNumber   string     `json:"invoice_number"`
+	SubscriptionID  *uuid.UUID `json:"subscription_id"`
+	PlanType        string     `json:"plan_type"`
+	Currency        string     `json:"currency"`
+	BillingName     *string    `json:"billing_name"`
+	GSTIN           *string    `json:"gstin"`
+	PlaceOfSupply   *string    `json:"place_of_supply"`
+	SellerGSTIN     string     `json:"seller_gstin"`
+	SellerStateCode string     `json:"seller_state_code"`
+	TaxRate         int64      `json:"tax_rate"`
+	TaxableAmount   int64      `json:"taxable_amount"`
+	CGSTAmount      int64      `json:"cgst_amount"`
+	SGSTAmount      int64      `json:"sgst_amount"`
+	IGSTAmount      int64      `json:"igst_amount"`
+	TotalAmount     int64      `json:"total_amount"`
+	IssuedAt        *time.Time `json:"issued_at"`
+}
+
+func NewInvoices(rows []sqlc.Invoice) []Invoice {
+	invoices := make([]Invoice, 0, len(rows))
+
+	for _, row := range rows {
+		invoices = append(invoices, Invoice{
+			ID:              uuid.UUID(row.ID.Bytes),
+			InvoiceNumber:   row.InvoiceNumber,
+			SubscriptionID:  utils.ConvertPgtypeUUIDToGoogleUUID(row.SubscriptionID),
+			PlanType:        strings.ToLower(row.PlanType),
+			Currency:        row.Currency,
+			BillingName:     textOrNil(row.BillingName),
+			GSTIN:           textOrNil(row.Gstin),
+			PlaceOfSupply:   textOrNil(row.PlaceOfSupply),
+			SellerGSTIN:     row.SellerGstin,
+			SellerStateCode: row.SellerStateCode,
+			TaxRate:         utils.ConvertPgtypeNumericToInt64(row.TaxRate),
+			TaxableAmount:   utils.ConvertPgtypeNumericToInt64(row.TaxableAmount),
+			CGSTAmount:      utils.ConvertPgtypeNumericToInt64(row.CgstAmount),
+			SGSTAmount:      utils.ConvertPgtypeNumericToInt64(row.SgstAmount),
+			IGSTAmount:      utils.ConvertPgtypeNumericToInt64(row.IgstAmount),
+			TotalAmount:     utils.ConvertPgtypeNumericToInt64(row.TotalAmount),
+			IssuedAt:        utils.ConvertPgtypeTimestamptzToTime(row.IssuedAt),
+		})
+	}
+
+	return invoices
+}
+
+// CreditNote is a credit note issued against an invoice for a refund. Amounts are in the
+// currency's minor unit.
+type CreditNote struct {
+	ID               uuid.UUID  `json:"id"`
+	CreditNoteNumber string     `json:"credit_note_number"`
+	InvoiceID        uuid.UUID  `json:"invoice_id"`
+	RefundID         uuid.UUID  `json:"refund_id"`
+	Currency         string     `json:"currency"`
+	TaxRate          int64      `json:"tax_rate"`
+	TaxableAmount    int64      `json:"taxable_amount"`
+	CGSTAmount       int64      `json:"cgst_amount"`
+	SGSTAmount       int64      `json:"sgst_amount"`
+	IGSTAmount       int64      `json:"igst_amount"`
+	TotalAmount      int64      `json:"total_amount"`
+	IssuedAt         *time.Time `json:"issued_at"`
+}
+
+func NewCreditNotes(rows []sqlc.CreditNote) []CreditNote {
+	credit_notes := make([]CreditNote, 0, len(rows))
+
+	for _, row := range rows {
+		credit_notes = append(credit_notes, CreditNote{
+			ID:               uuid.UUID(row.ID.Bytes),
+			CreditNoteNumber: row.CreditNoteNumber,
+			InvoiceID:        uuid.UUID(row.InvoiceID.Bytes),
+			RefundID:         uuid.UUID(row.RefundID.Bytes),
+			Currency:         row.Currency,
+			TaxRate:          utils.ConvertPgtypeNumericToInt64(row.TaxRate),
+			TaxableAmount:    utils.ConvertPgtypeNumericToInt64(row.TaxableAmount),
+			CGSTAmount:       utils.ConvertPgtypeNumericToInt64(row.CgstAmount),
+			SGSTAmount:       utils.ConvertPgtypeNumericToInt64(row.SgstAmount),
+			IGSTAmount:       utils.ConvertPgtypeNumericToInt64(row.IgstAmount),
+			TotalAmount:      utils.ConvertPgtypeNumericToInt64(row.TotalAmount),
+			IssuedAt:         utils.ConvertPgtypeTimestamptzToTime(row.IssuedAt),
+		})
+	}
+
+	return credit_notes
+}
+
+func textOrNil(t pgtype.Text) *string {
+	if !t.Valid {
+		return nil
+	}
+
+	return &t.String
+}
@@ -0,0 +1,18 @@
+package dto
+
+// Page is a page of a list. NextCursor is passed back as the cursor query param to get
+// the next page and is null on the last page.
+type Page[T any] struct {
+	Items      []T     `json:"items"`
+	NextCursor *string `json:"next_cursor"`
+}
+
+func NewPage[T any](items []T, next_cursor string) Page[T] {
+	page := Page[T]{Items: items}
+
+	if next_cursor != "" {
+		page.NextCursor = &next_cursor
+	}
+
+	return page
+}
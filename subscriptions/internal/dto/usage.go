@@ -0,0 +1,144 @@
+package dto
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/parbhat-cpp/fuse/subscriptions/internal/db/sqlc"
+	"github.com/parbhat-cpp/fuse/subscriptions/internal/services"
+	"github.com/parbhat-cpp/fuse/subscriptions/internal/types"
+	"github.com/parbhat-cpp/fuse/subscriptions/pkg/utils"
+)
+
+// Usage is how much of a plan's quota was used in a period.
+type Usage struct {
+	PublicRoomsJoined int `json:"public_rooms_joined"`
+	RoomsScheduled    int `json:"rooms_scheduled"`
+}
+
+// UsagePeriod is a period of a plan and the quota used in it.
+type UsagePeriod struct {
+	ID             uuid.UUID  `json:"id"`
+	SubscriptionID *uuid.UUID `json:"subscription_id"`
+	PlanType       string     `json:"plan_type"`
+	ValidFrom      *time.Time `json:"valid_from"`
+	ValidUntil     *time.Time `json:"valid_until"`
+	Usage          Usage      `json:"usage"`
+}
+
+// PlanChange is a plan change scheduled for the end of the current period.
+type PlanChange struct {
+	ID           uuid.UUID  `json:"id"`
+	FromPlanType string     `json:"from_plan_type"`
+	ToPlanType   string     `json:"to_plan_type"`
+	EffectiveAt  *time.Time `json:"effective_at"`
+	Status       string     `json:"status"`
+}
+
+type CurrentUsage struct {
+	UsagePeriod
+	PendingPlanChange *PlanChange `json:"pending_plan_change"`
+}
+
+// Subscription is a paid subscription of the user. Payment signatures stay internal.
+type Subscription struct {
+	ID                uuid.UUID  `json:"id"`
+	PlanID            *uuid.UUID `json:"plan_id"`
+	PlanType          string     `json:"plan_type"`
+	OrderID           string     `json:"order_id"`
+	RazorpayOrderID   string     `json:"razorpay_order_id"`
+	RazorpayPaymentID string     `json:"razorpay_payment_id"`
+	PurchaseDate      *time.Time `json:"purchase_date"`
+	ValidFrom         *time.Time `json:"valid_from"`
+	ValidUntil        *time.Time `json:"valid_until"`
+}
+
+func NewCurrentUsage(res services.CurrentUsageResponse) CurrentUsage {
+	row := res.GetCurrentSubscriptionUsageWithSubscriptionByUserIDRow
+
+	current := CurrentUsage{
+		UsagePeriod: newUsagePeriod(row),
+	}
+
+	if res.PendingPlanChange != nil {
+		change := res.PendingPlanChange
+
+		current.PendingPlanChange = &PlanChange{
+			ID:           uuid.UUID(change.ID.Bytes),
+			FromPlanType: change.FromPlanType,
+			ToPlanType:   change.ToPlanType,
+			EffectiveAt:  utils.ConvertPgtypeTimestamptzToTime(change.EffectiveAt),
+			Status:       change.Status,
+		}
+	}
+
+	return current
+}
+
+func NewUsagePeriods(rows []sqlc.ListSubscriptionUsageWithSubscriptionRow) []UsagePeriod {
+	periods := make([]UsagePeriod, 0, len(rows))
+
+	for _, row := range rows {
+		periods = append(periods, newUsagePeriod(sqlc.GetCurrentSubscriptionUsageWithSubscriptionByUserIDRow{
+			ID:             row.ID,
+			ValidFrom:      row.ValidFrom,
+			ValidUntil:     row.ValidUntil,
+			Usage:          row.Usage,
+			SubscriptionID: row.SubscriptionID,
+			PlanType:       row.PlanType,
+		}))
+	}
+
+	return periods
+}
+
+func NewSubscriptions(rows []sqlc.ListSubscriptionsRow) []Subscription {
+	subscriptions := make([]Subscription, 0, len(rows))
+
+	for _, row := range rows {
+		subscriptions = append(subscriptions, Subscription{
+			ID:                uuid.UUID(row.ID.Bytes),
+			PlanID:            utils.ConvertPgtypeUUIDToGoogleUUID(row.PlanID),
+			PlanType:          strings.ToLower(row.PlanType),
+			OrderID:           row.OrderID,
+			RazorpayOrderID:   row.RazorpayOrderID,
+			RazorpayPaymentID: row.RazorpayPaymentID,
+			PurchaseDate:      utils.ConvertPgtypeTimestamptzToTime(row.PurchaseDate),
+			ValidFrom:         utils.ConvertPgtypeTimestamptzToTime(row.ValidFrom),
+			ValidUntil:        utils.ConvertPgtypeTimestamptzToTime(row.ValidUntil),
+		})
+	}
+
+	return subscriptions
+}
+
+func newUsagePeriod(row sqlc.GetCurrentSubscriptionUsageWithSubscriptionByUserIDRow) UsagePeriod {
+	return UsagePeriod{
+		ID:             uuid.UUID(row.ID.Bytes),
+		SubscriptionID: utils.ConvertPgtypeUUIDToGoogleUUID(row.SubscriptionID),
+		// periods without a subscription are on the free plan, which the query returns as 'Free'
+		PlanType:   strings.ToLower(fmt.Sprint(row.PlanType)),
+		ValidFrom:  utils.ConvertPgtypeTimestamptzToTime(row.ValidFrom),
+		ValidUntil: utils.ConvertPgtypeTimestamptzToTime(row.ValidUntil),
+		Usage:      newUsage(row.Usage),
+	}
+}
+
+func newUsage(raw json.RawMessage) Usage {
+	var counts struct {
+		types.Usage
+		// the free plan period created on a user's first access counts schedules under this key
+		RoomScheduleQuota int `json:"room_schedule_quota"`
+	}
+
+	// a period with unreadable usage is shown as unused rather than failing the list
+	_ = json.Unmarshal(raw, &counts)
+
+	return Usage{
+		PublicRoomsJoined: counts.PublicRoomQuota,
+		RoomsScheduled:    max(counts.RoomSchedulingQuota, counts.RoomScheduleQuota),
+	}
+}
@@ -8,6 +8,8 @@ import (
 	"github.com/labstack/echo/v4"
 
 	"github.com/parbhat-cpp/fuse/subscriptions/constants"
+	"github.com/parbhat-cpp/fuse/subscriptions/internal/apperrors"
+	"github.com/parbhat-cpp/fuse/subscriptions/internal/dto"
 	"github.com/parbhat-cpp/fuse/subscriptions/internal/services"
 )
 
@@ -22,46 +24,65 @@ func NewAccessHandler(accessService *services.AccessService) *AccessHandler {
 }
 
 func (h *AccessHandler) HandleAccessRequest(ctx echo.Context) error {
+	user_id, access_request, err := parseAccessRequest(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	res, err := h.s.HandleAccessRequest(ctx.Request().Context(), user_id, access_request)
+
+	if err != nil {
+		// the plan and quota are sent along so callers can explain the denial
+		if errors.Is(err, services.ErrAccessLimitReached) {
+			return apperrors.From(err).WithData(res)
+		}
+
+		return err
+	}
+	return ctx.JSON(http.StatusOK, res)
+}
+
+func (h *AccessHandler) HandleAccessRequestV2(ctx echo.Context) error {
+	user_id, access_request, err := parseAccessRequest(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	res, err := h.s.HandleAccessRequest(ctx.Request().Context(), user_id, access_request)
+
+	if err != nil {
+		// the plan and quota are sent along so callers can explain the denial
+		if errors.Is(err, services.ErrAccessLimitReached) {
+			return apperrors.From(err).WithData(dto.NewAccess(res))
+		}
+
+		return err
+	}
+	return ctx.JSON(http.StatusOK, dto.NewAccess(res))
+}
+
+// parseAccessRequest returns the user and action of an access request.
+func parseAccessRequest(ctx echo.Context) (uuid.UUID, constants.AccessType, error) {
 	user_id, parseErr := uuid.Parse(ctx.QueryParam("user_id"))
 	access_request := constants.AccessType(ctx.QueryParam("access_request"))
 
 	if parseErr != nil {
-		return ctx.JSON(http.StatusBadRequest, map[string]string{
-			"error": "invalid user_id",
-		})
+		return uuid.Nil, "", apperrors.InvalidRequest("invalid user_id")
 	}
 
 	if user_id == uuid.Nil {
-		return ctx.JSON(http.StatusBadRequest, map[string]string{
-			"error": "user_id is required",
-		})
+		return uuid.Nil, "", apperrors.InvalidRequest("user_id is required")
 	}
 
 	if access_request == "" {
-		return ctx.JSON(http.StatusBadRequest, map[string]string{
-			"error": "access_request is required",
-		})
+		return uuid.Nil, "", apperrors.InvalidRequest("access_request is required")
 	}
 
 	if access_request != constants.AccessTypeJoinRoom && access_request != constants.AccessTypeSchedule {
-		return ctx.JSON(http.StatusBadRequest, map[string]string{
-			"error": "invalid access_request",
-		})
+		return uuid.Nil, "", apperrors.InvalidRequest("invalid access_request")
 	}
 
-	res, err := h.s.HandleAccessRequest(user_id, access_request)
-
-	if err != nil {
-		if errors.Is(err, services.ErrAccessLimitReached) {
-			return ctx.JSON(http.StatusTooManyRequests, map[string]any{
-				"error": err.Error(),
-				"data":  res,
-			})
-		}
-
-		return ctx.JSON(http.StatusInternalServerError, map[string]string{
-			"error": err.Error(),
-		})
-	}
-	return ctx.JSON(http.StatusOK, res)
+	return user_id, access_request, nil
 }
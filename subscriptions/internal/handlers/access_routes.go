@@ -3,9 +3,21 @@ package handlers
 func AccessRoutes(h *AccessHandler) []Route {
 	return []Route{
 		{
-			Method:  "GET",
-			Path:    "/access",
-			Handler: h.HandleAccessRequest,
+			Method:   "GET",
+			Path:     "/access",
+			Handler:  h.HandleAccessRequest,
+			Audience: AudienceInternal,
+		},
+	}
+}
+
+func AccessRoutesV2(h *AccessHandler) []Route {
+	return []Route{
+		{
+			Method:   "GET",
+			Path:     "/access",
+			Handler:  h.HandleAccessRequestV2,
+			Audience: AudienceInternal,
 		},
 	}
 }
@@ -0,0 +1,83 @@
+package handlers
+
+import (
+	"fmt"
+
+	"github.com/labstack/echo/v4"
+)
+
+// API holds the handlers of every versioned route, so the server and the tests that
+// check the OpenAPI document register the same route table.
+type API struct {
+	Access         *AccessHandler
+	Payment        *PaymentHandler
+	Usage          *UsageHandler
+	Invoice        *InvoiceHandler
+	Subscription   *SubscriptionHandler
+	Deletion       *DeletionHandler
+	Refund         *RefundHandler
+	Coupon         *CouponHandler
+	PaymentAttempt *PaymentAttemptHandler
+	Outbox         *OutboxHandler
+}
+
+// Routes returns the v1 routes.
+func (a API) Routes() []Route {
+	routes := []Route{}
+
+	routes = append(routes, AccessRoutes(a.Access)...)
+	routes = append(routes, PaymentRoutes(a.Payment)...)
+	routes = append(routes, UsageRoutes(a.Usage)...)
+	routes = append(routes, InvoiceRoutes(a.Invoice)...)
+	routes = append(routes, SubscriptionRoutes(a.Subscription)...)
+	routes = append(routes, DeletionRoutes(a.Deletion)...)
+	routes = append(routes, RefundRoutes(a.Refund)...)
+	routes = append(routes, CouponAdminRoutes(a.Coupon)...)
+	routes = append(routes, PaymentAttemptAdminRoutes(a.PaymentAttempt)...)
+	routes = append(routes, OutboxAdminRoutes(a.Outbox)...)
+	routes = append(routes, RefundAdminRoutes(a.Refund)...)
+
+	return routes
+}
+
+// RoutesV2 returns the v2 routes, which serve explicit snake_case response types.
+func (a API) RoutesV2() []Route {
+	routes := []Route{}
+
+	routes = append(routes, AccessRoutesV2(a.Access)...)
+	routes = append(routes, UsageRoutesV2(a.Usage)...)
+
+	return routes
+}
+
+/**
+ * Registers the v1 and v2 routes. Internal and admin routes go to internal, which is e
+ * itself when the service has a single listener.
+ * @param e: *echo.Echo
+ * @param internal: *echo.Echo
+ * @param middleware: map[Audience][]echo.MiddlewareFunc, the policy of each audience
+ * @return error
+ */
+func (a API) Register(e *echo.Echo, internal *echo.Echo, middleware map[Audience][]echo.MiddlewareFunc) error {
+	err := RegisterRoutes(map[Audience]RouteGroup{
+		AudiencePublic:    {Group: e.Group("/v1"), Middleware: middleware[AudiencePublic]},
+		AudienceAnonymous: {Group: e.Group("/v1"), Middleware: middleware[AudienceAnonymous]},
+		AudienceInternal:  {Group: internal.Group("/v1"), Middleware: middleware[AudienceInternal]},
+		AudienceAdmin:     {Group: internal.Group("/v1/admin"), Middleware: middleware[AudienceAdmin]},
+	}, a.Routes())
+
+	if err != nil {
+		return fmt.Errorf("Cannot register routes: %w", err)
+	}
+
+	err = RegisterRoutes(map[Audience]RouteGroup{
+		AudiencePublic:   {Group: e.Group("/v2"), Middleware: middleware[AudiencePublic]},
+		AudienceInternal: {Group: internal.Group("/v2"), Middleware: middleware[AudienceInternal]},
+	}, a.RoutesV2())
+
+	if err != nil {
+		return fmt.Errorf("Cannot register v2 routes: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,55 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/parbhat-cpp/fuse/subscriptions/internal/apperrors"
+	"github.com/parbhat-cpp/fuse/subscriptions/internal/services"
+)
+
+type CouponHandler struct {
+	s *services.CouponService
+}
+
+func NewCouponHandler(s *services.CouponService) *CouponHandler {
+	return &CouponHandler{
+		s: s,
+	}
+}
+
+func (h *CouponHandler) CreateCoupon(ctx echo.Context) error {
+	req := new(services.CreateCouponRequest)
+
+	if err := ctx.Bind(req); err != nil {
+		return apperrors.InvalidRequest("invalid request payload")
+	}
+
+	res, err := h.s.CreateCoupon(ctx.Request().Context(), *req)
+
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(http.StatusCreated, res)
+}
+
+func (h *CouponHandler) GetCouponReport(ctx echo.Context) error {
+	res, err := h.s.GetCouponReport(ctx.Request().Context())
+
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(http.StatusOK, map[string]any{"coupons": res})
+}
+
+func (h *CouponHandler) GetCouponRedemptions(ctx echo.Context) error {
+	res, err := h.s.GetCouponRedemptions(ctx.Request().Context(), ctx.Param("code"))
+
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(http.StatusOK, res)
+}
@@ -0,0 +1,27 @@
+package handlers
+
+import "net/http"
+
+// CouponAdminRoutes are only served to admins.
+func CouponAdminRoutes(h *CouponHandler) []Route {
+	return []Route{
+		{
+			Method:   http.MethodPost,
+			Path:     "/coupons",
+			Handler:  h.CreateCoupon,
+			Audience: AudienceAdmin,
+		},
+		{
+			Method:   http.MethodGet,
+			Path:     "/coupons",
+			Handler:  h.GetCouponReport,
+			Audience: AudienceAdmin,
+		},
+		{
+			Method:   http.MethodGet,
+			Path:     "/coupons/:code/redemptions",
+			Handler:  h.GetCouponRedemptions,
+			Audience: AudienceAdmin,
+		},
+	}
+}
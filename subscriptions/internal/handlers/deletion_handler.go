@@ -5,7 +5,7 @@ import (
 
 	"github.com/google/uuid"
 	"github.com/labstack/echo/v4"
-	"github.com/labstack/gommon/log"
+	"github.com/parbhat-cpp/fuse/subscriptions/internal/apperrors"
 	"github.com/parbhat-cpp/fuse/subscriptions/internal/services"
 )
 
@@ -20,18 +20,16 @@ func NewDeletionHandler(s *services.DeletionService) *DeletionHandler {
 }
 
 func (h *DeletionHandler) DeleteUserData(ctx echo.Context) error {
-	user_id := ctx.Param("user_id")
-	user_uuid := uuid.MustParse(user_id)
+	user_uuid, err := uuid.Parse(ctx.Param("user_id"))
 
-	if user_id == "" {
-		return echo.NewHTTPError(http.StatusBadRequest, "Invalid user ID")
+	if err != nil {
+		return apperrors.InvalidRequest("Invalid user ID")
 	}
 
-	err := h.s.DeleteUserData(user_uuid)
+	err = h.s.DeleteUserData(ctx.Request().Context(), user_uuid)
 
 	if err != nil {
-		log.Errorf("%s", err)
-		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to delete user data")
+		return err
 	}
 
 	return ctx.JSON(http.StatusOK, map[string]string{
@@ -0,0 +1,71 @@
+package handlers
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/parbhat-cpp/fuse/subscriptions/internal/apperrors"
+)
+
+// ErrorResponse is the body of every error response. error is the message, kept as a
+// plain string so v1 clients reading it keep working.
+type ErrorResponse struct {
+	Error     string         `json:"error"`
+	Code      apperrors.Code `json:"code"`
+	RequestID string         `json:"request_id,omitempty"`
+	Data      any            `json:"data,omitempty"`
+}
+
+// HTTPErrorHandler renders errors returned by handlers and middleware. Errors that are
+// not apperrors.Error are internal: they are logged and the caller only gets a generic
+// message.
+func HTTPErrorHandler(err error, c echo.Context) {
+	if c.Response().Committed {
+		return
+	}
+
+	app_err, status := toAppError(err)
+	request_id := c.Response().Header().Get(echo.HeaderXRequestID)
+
+	if status >= http.StatusInternalServerError {
+		slog.ErrorContext(c.Request().Context(), "Request failed", "method", c.Request().Method, "route", c.Path(), "error", err)
+	}
+
+	if c.Request().Method == http.MethodHead {
+		err = c.NoContent(status)
+	} else {
+		err = c.JSON(status, ErrorResponse{
+			Error:     app_err.Message,
+			Code:      app_err.Code,
+			RequestID: request_id,
+			Data:      app_err.Data,
+		})
+	}
+
+	if err != nil {
+		slog.ErrorContext(c.Request().Context(), "Unable to send error response", "error", err)
+	}
+}
+
+// toAppError returns the error to render and its status. It also covers the errors echo
+// raises itself, like unknown routes, which keep their status.
+func toAppError(err error) (*apperrors.Error, int) {
+	var app_err *apperrors.Error
+	var http_err *echo.HTTPError
+
+	if !errors.As(err, &app_err) && errors.As(err, &http_err) {
+		message, ok := http_err.Message.(string)
+
+		if !ok || http_err.Code >= http.StatusInternalServerError {
+			message = http.StatusText(http_err.Code)
+		}
+
+		return apperrors.New(apperrors.CodeForStatus(http_err.Code), message), http_err.Code
+	}
+
+	app_err = apperrors.From(err)
+
+	return app_err, app_err.Code.Status()
+}
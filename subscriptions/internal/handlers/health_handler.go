@@ -0,0 +1,33 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/parbhat-cpp/fuse/subscriptions/internal/services"
+)
+
+type HealthHandler struct {
+	s *services.HealthService
+}
+
+func NewHealthHandler(s *services.HealthService) *HealthHandler {
+	return &HealthHandler{
+		s: s,
+	}
+}
+
+func (h *HealthHandler) Live(ctx echo.Context) error {
+	return ctx.JSON(http.StatusOK, h.s.Liveness())
+}
+
+// Ready responds with 503 when any check fails, so the instance is taken out of rotation.
+func (h *HealthHandler) Ready(ctx echo.Context) error {
+	report := h.s.Readiness(ctx.Request().Context())
+
+	if !report.Healthy() {
+		return ctx.JSON(http.StatusServiceUnavailable, report)
+	}
+
+	return ctx.JSON(http.StatusOK, report)
+}
@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/parbhat-cpp/fuse/subscriptions/internal/apperrors"
+	"github.com/parbhat-cpp/fuse/subscriptions/internal/dto"
+	"github.com/parbhat-cpp/fuse/subscriptions/internal/identity"
+	"github.com/parbhat-cpp/fuse/subscriptions/internal/services"
+)
+
+type InvoiceHandler struct {
+	s *services.InvoiceService
+}
+
+func NewInvoiceHandler(s *services.InvoiceService) *InvoiceHandler {
+	return &InvoiceHandler{
+		s: s,
+	}
+}
+
+func (h *InvoiceHandler) GetInvoices(ctx echo.Context) error {
+	principal, ok := identity.FromContext(ctx)
+
+	if !ok {
+		return apperrors.ErrUnauthorized
+	}
+
+	res, err := h.s.GetInvoices(ctx.Request().Context(), principal.UserID)
+
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(http.StatusOK, dto.NewInvoices(res))
+}
+
+func (h *InvoiceHandler) DownloadInvoice(ctx echo.Context) error {
+	principal, ok := identity.FromContext(ctx)
+
+	if !ok {
+		return apperrors.ErrUnauthorized
+	}
+
+	invoice_id, err := uuid.Parse(ctx.Param("invoice_id"))
+
+	if err != nil {
+		return apperrors.InvalidRequest("invalid invoice_id")
+	}
+
+	invoice, pdf, err := h.s.GetInvoicePDF(ctx.Request().Context(), principal.UserID, invoice_id)
+
+	if err != nil {
+		return err
+	}
+
+	filename := strings.ReplaceAll(invoice.InvoiceNumber, "/", "-") + ".pdf"
+	ctx.Response().Header().Set(echo.HeaderContentDisposition, `attachment; filename="`+filename+`"`)
+
+	return ctx.Blob(http.StatusOK, "application/pdf", pdf)
+}
+
+func (h *InvoiceHandler) GetCreditNotes(ctx echo.Context) error {
+	principal, ok := identity.FromContext(ctx)
+
+	if !ok {
+		return apperrors.ErrUnauthorized
+	}
+
+	res, err := h.s.GetCreditNotes(ctx.Request().Context(), principal.UserID)
+
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(http.StatusOK, dto.NewCreditNotes(res))
+}
+
+func (h *InvoiceHandler) DownloadCreditNote(ctx echo.Context) error {
+	principal, ok := identity.FromContext(ctx)
+
+	if !ok {
+		return apperrors.ErrUnauthorized
+	}
+
+	credit_note_id, err := uuid.Parse(ctx.Param("credit_note_id"))
+
+	if err != nil {
+		return apperrors.InvalidRequest("invalid credit_note_id")
+	}
+
+	credit_note, pdf, err := h.s.GetCreditNotePDF(ctx.Request().Context(), principal.UserID, credit_note_id)
+
+	if err != nil {
+		return err
+	}
+
+	filename := strings.ReplaceAll(credit_note.CreditNoteNumber, "/", "-") + ".pdf"
+	ctx.Response().Header().Set(echo.HeaderContentDisposition, `attachment; filename="`+filename+`"`)
+
+	return ctx.Blob(http.StatusOK, "application/pdf", pdf)
+}
@@ -0,0 +1,30 @@
+package handlers
+
+func InvoiceRoutes(h *InvoiceHandler) []Route {
+	return []Route{
+		{
+			Method:   "GET",
+			Path:     "/invoices",
+			Handler:  h.GetInvoices,
+			Audience: AudiencePublic,
+		},
+		{
+			Method:   "GET",
+			Path:     "/invoices/:invoice_id/pdf",
+			Handler:  h.DownloadInvoice,
+			Audience: AudiencePublic,
+		},
+		{
+			Method:   "GET",
+			Path:     "/credit-notes",
+			Handler:  h.GetCreditNotes,
+			Audience: AudiencePublic,
+		},
+		{
+			Method:   "GET",
+			Path:     "/credit-notes/:credit_note_id/pdf",
+			Handler:  h.DownloadCreditNote,
+			Audience: AudiencePublic,
+		},
+	}
+}
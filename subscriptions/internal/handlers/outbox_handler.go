@@ -0,0 +1,61 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/parbhat-cpp/fuse/subscriptions/internal/apperrors"
+	"github.com/parbhat-cpp/fuse/subscriptions/internal/services"
+)
+
+type OutboxHandler struct {
+	s *services.OutboxService
+}
+
+func NewOutboxHandler(s *services.OutboxService) *OutboxHandler {
+	return &OutboxHandler{
+		s: s,
+	}
+}
+
+func (h *OutboxHandler) GetDeadOutboxEvents(ctx echo.Context) error {
+	limit := 0
+
+	if value := ctx.QueryParam("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+
+		if err != nil {
+			return apperrors.InvalidRequest("invalid limit")
+		}
+
+		limit = parsed
+	}
+
+	res, err := h.s.GetDeadOutboxEvents(ctx.Request().Context(), limit)
+
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(http.StatusOK, map[string]any{"events": res})
+}
+
+func (h *OutboxHandler) RetryOutboxEvent(ctx echo.Context) error {
+	event_id, err := uuid.Parse(ctx.Param("event_id"))
+
+	if err != nil {
+		return apperrors.InvalidRequest("invalid event_id")
+	}
+
+	err = h.s.RetryOutboxEvent(ctx.Request().Context(), event_id)
+
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(http.StatusOK, map[string]string{
+		"message": "Event queued for delivery",
+	})
+}
@@ -0,0 +1,21 @@
+package handlers
+
+import "net/http"
+
+// OutboxAdminRoutes are only served to admins.
+func OutboxAdminRoutes(h *OutboxHandler) []Route {
+	return []Route{
+		{
+			Method:   http.MethodGet,
+			Path:     "/outbox/dead",
+			Handler:  h.GetDeadOutboxEvents,
+			Audience: AudienceAdmin,
+		},
+		{
+			Method:   http.MethodPost,
+			Path:     "/outbox/:event_id/retry",
+			Handler:  h.RetryOutboxEvent,
+			Audience: AudienceAdmin,
+		},
+	}
+}
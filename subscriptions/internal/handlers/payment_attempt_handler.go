@@ -0,0 +1,81 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/parbhat-cpp/fuse/subscriptions/internal/apperrors"
+	"github.com/parbhat-cpp/fuse/subscriptions/internal/services"
+)
+
+type PaymentAttemptHandler struct {
+	s *services.PaymentAttemptService
+}
+
+func NewPaymentAttemptHandler(s *services.PaymentAttemptService) *PaymentAttemptHandler {
+	return &PaymentAttemptHandler{
+		s: s,
+	}
+}
+
+func (h *PaymentAttemptHandler) SearchPaymentAttempts(ctx echo.Context) error {
+	filter := services.PaymentAttemptFilter{
+		Outcome:           ctx.QueryParam("outcome"),
+		ReasonCode:        ctx.QueryParam("reason_code"),
+		RazorpayOrderID:   ctx.QueryParam("razorpay_order_id"),
+		RazorpayPaymentID: ctx.QueryParam("razorpay_payment_id"),
+		ClientIP:          ctx.QueryParam("client_ip"),
+	}
+
+	if value := ctx.QueryParam("user_id"); value != "" {
+		user_id, err := uuid.Parse(value)
+
+		if err != nil {
+			return apperrors.InvalidRequest("invalid user_id")
+		}
+
+		filter.UserID = &user_id
+	}
+
+	// from and to are RFC 3339 timestamps
+	if value := ctx.QueryParam("from"); value != "" {
+		from, err := time.Parse(time.RFC3339, value)
+
+		if err != nil {
+			return apperrors.InvalidRequest("invalid from")
+		}
+
+		filter.CreatedAfter = &from
+	}
+
+	if value := ctx.QueryParam("to"); value != "" {
+		to, err := time.Parse(time.RFC3339, value)
+
+		if err != nil {
+			return apperrors.InvalidRequest("invalid to")
+		}
+
+		filter.CreatedBefore = &to
+	}
+
+	if value := ctx.QueryParam("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+
+		if err != nil {
+			return apperrors.InvalidRequest("invalid limit")
+		}
+
+		filter.Limit = limit
+	}
+
+	res, err := h.s.SearchPaymentAttempts(ctx.Request().Context(), filter)
+
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(http.StatusOK, map[string]any{"payment_attempts": res})
+}
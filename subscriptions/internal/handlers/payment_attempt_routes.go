@@ -0,0 +1,15 @@
+package handlers
+
+import "net/http"
+
+// PaymentAttemptAdminRoutes are only served to admins.
+func PaymentAttemptAdminRoutes(h *PaymentAttemptHandler) []Route {
+	return []Route{
+		{
+			Method:   http.MethodGet,
+			Path:     "/payment-attempts",
+			Handler:  h.SearchPaymentAttempts,
+			Audience: AudienceAdmin,
+		},
+	}
+}
@@ -3,9 +3,10 @@ package handlers
 import (
 	"net/http"
 
-	"github.com/google/uuid"
 	"github.com/labstack/echo/v4"
 	"github.com/parbhat-cpp/fuse/subscriptions/constants"
+	"github.com/parbhat-cpp/fuse/subscriptions/internal/apperrors"
+	"github.com/parbhat-cpp/fuse/subscriptions/internal/identity"
 	"github.com/parbhat-cpp/fuse/subscriptions/internal/services"
 )
 
@@ -14,12 +15,11 @@ type PaymentHandler struct {
 }
 
 type PaymentVerifyRequest struct {
-	UserID            uuid.UUID `json:"user_id"`
-	PlanType          string    `json:"plan_type"`
-	OrderID           string    `json:"order_id"`
-	RazorpayOrderID   string    `json:"razorpay_order_id"`
-	RazorpayPaymentID string    `json:"razorpay_payment_id"`
-	RazorpaySignature string    `json:"razorpay_signature"`
+	PlanType          string `json:"plan_type"`
+	OrderID           string `json:"order_id"`
+	RazorpayOrderID   string `json:"razorpay_order_id"`
+	RazorpayPaymentID string `json:"razorpay_payment_id"`
+	RazorpaySignature string `json:"razorpay_signature"`
 }
 
 func NewPaymentHandler(s *services.PaymentService) *PaymentHandler {
@@ -29,37 +29,74 @@ func NewPaymentHandler(s *services.PaymentService) *PaymentHandler {
 }
 
 func (h *PaymentHandler) GetPlans(ctx echo.Context) error {
-	return ctx.JSON(http.StatusOK, map[string]any{"plans": constants.GetPlans()})
+	currency, err := requestCurrency(ctx)
+
+	if err != nil {
+		return apperrors.InvalidRequest(err.Error())
+	}
+
+	return ctx.JSON(http.StatusOK, map[string]any{
+		"plans":      constants.GetPlans(),
+		"currency":   currency,
+		"currencies": constants.SupportedCurrencies,
+	})
 }
 
 func (h *PaymentHandler) InitializePayment(ctx echo.Context) error {
 	plan_type := ctx.QueryParam("plan_type")
+	principal, ok := identity.FromContext(ctx)
+
+	if !ok {
+		return apperrors.ErrUnauthorized
+	}
 
-	res, err := h.s.InitializePayment(plan_type)
+	currency, err := requestCurrency(ctx)
 
 	if err != nil {
-		return ctx.JSON(http.StatusInternalServerError, map[string]string{
-			"error": err.Error(),
-		})
+		return apperrors.InvalidRequest(err.Error())
+	}
+
+	billing := services.BillingDetails{
+		Name:      ctx.QueryParam("billing_name"),
+		GSTIN:     ctx.QueryParam("gstin"),
+		StateCode: ctx.QueryParam("state_code"),
+	}
+
+	res, err := h.s.InitializePayment(ctx.Request().Context(), principal.UserID, plan_type, ctx.QueryParam("coupon_code"), currency, billing)
+
+	if err != nil {
+		return err
 	}
 	return ctx.JSON(http.StatusOK, res)
 }
 
 func (h *PaymentHandler) VerifyPayment(ctx echo.Context) error {
+	principal, ok := identity.FromContext(ctx)
+
+	if !ok {
+		return apperrors.ErrUnauthorized
+	}
+
 	req := new(PaymentVerifyRequest)
 
 	if err := ctx.Bind(req); err != nil {
-		return ctx.JSON(http.StatusBadRequest, map[string]string{
-			"error": "invalid request payload",
-		})
+		return apperrors.InvalidRequest("invalid request payload")
 	}
 
-	res, err := h.s.VerifyPayment(req.UserID, req.PlanType, req.OrderID, req.RazorpayOrderID, req.RazorpayPaymentID, req.RazorpaySignature)
+	res, err := h.s.VerifyPayment(ctx.Request().Context(), principal.UserID, req.PlanType, req.OrderID, req.RazorpayOrderID, req.RazorpayPaymentID, req.RazorpaySignature, ctx.RealIP())
 
 	if err != nil {
-		return ctx.JSON(http.StatusInternalServerError, map[string]string{
-			"error": err.Error(),
-		})
+		return err
 	}
 	return ctx.JSON(http.StatusOK, res)
 }
+
+// requestCurrency returns the currency named by the currency query param, or the one
+// matching the caller's Accept-Language header.
+func requestCurrency(ctx echo.Context) (constants.Currency, error) {
+	if code := ctx.QueryParam("currency"); code != "" {
+		return constants.ParseCurrency(code)
+	}
+
+	return constants.CurrencyForLocale(ctx.Request().Header.Get("Accept-Language")), nil
+}
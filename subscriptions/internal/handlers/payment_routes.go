@@ -3,19 +3,22 @@ package handlers
 func PaymentRoutes(h *PaymentHandler) []Route {
 	return []Route{
 		{
-			Method:  "GET",
-			Path:    "/payment/initialize",
-			Handler: h.InitializePayment,
+			Method:   "GET",
+			Path:     "/payment/initialize",
+			Handler:  h.InitializePayment,
+			Audience: AudiencePublic,
 		},
 		{
-			Method:  "POST",
-			Path:    "/payment/verify",
-			Handler: h.VerifyPayment,
+			Method:   "POST",
+			Path:     "/payment/verify",
+			Handler:  h.VerifyPayment,
+			Audience: AudiencePublic,
 		},
 		{
-			Method:  "GET",
-			Path:    "/payment/plans",
-			Handler: h.GetPlans,
+			Method:   "GET",
+			Path:     "/payment/plans",
+			Handler:  h.GetPlans,
+			Audience: AudienceAnonymous,
 		},
 	}
 }
@@ -0,0 +1,81 @@
+package handlers
+
+import (
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/parbhat-cpp/fuse/subscriptions/internal/apperrors"
+	"github.com/parbhat-cpp/fuse/subscriptions/internal/services"
+)
+
+type RefundHandler struct {
+	s *services.RefundService
+}
+
+func NewRefundHandler(s *services.RefundService) *RefundHandler {
+	return &RefundHandler{
+		s: s,
+	}
+}
+
+func (h *RefundHandler) RazorpayWebhook(ctx echo.Context) error {
+	// the signature is computed over the raw body, so it is read before any binding
+	body, err := io.ReadAll(ctx.Request().Body)
+
+	if err != nil {
+		return apperrors.InvalidRequest("unable to read body")
+	}
+
+	err = h.s.HandleRazorpayWebhook(ctx.Request().Context(), body, ctx.Request().Header.Get("X-Razorpay-Signature"))
+
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(http.StatusOK, map[string]string{
+		"message": "Webhook processed",
+	})
+}
+
+func (h *RefundHandler) GetRefunds(ctx echo.Context) error {
+	limit := 0
+
+	if value := ctx.QueryParam("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+
+		if err != nil {
+			return apperrors.InvalidRequest("invalid limit")
+		}
+
+		limit = parsed
+	}
+
+	res, err := h.s.GetRefunds(ctx.Request().Context(), ctx.QueryParam("status"), limit)
+
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(http.StatusOK, map[string]any{"refunds": res})
+}
+
+func (h *RefundHandler) RetryRefund(ctx echo.Context) error {
+	refund_id, err := uuid.Parse(ctx.Param("refund_id"))
+
+	if err != nil {
+		return apperrors.InvalidRequest("invalid refund_id")
+	}
+
+	err = h.s.RetryRefund(ctx.Request().Context(), refund_id)
+
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(http.StatusOK, map[string]string{
+		"message": "Refund queued for retry",
+	})
+}
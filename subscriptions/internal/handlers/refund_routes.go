@@ -0,0 +1,32 @@
+package handlers
+
+import "net/http"
+
+func RefundRoutes(h *RefundHandler) []Route {
+	return []Route{
+		{
+			Method:   http.MethodPost,
+			Path:     "/webhooks/razorpay",
+			Handler:  h.RazorpayWebhook,
+			Audience: AudienceAnonymous,
+		},
+	}
+}
+
+// RefundAdminRoutes are only served to admins.
+func RefundAdminRoutes(h *RefundHandler) []Route {
+	return []Route{
+		{
+			Method:   http.MethodGet,
+			Path:     "/refunds",
+			Handler:  h.GetRefunds,
+			Audience: AudienceAdmin,
+		},
+		{
+			Method:   http.MethodPost,
+			Path:     "/refunds/:refund_id/retry",
+			Handler:  h.RetryRefund,
+			Audience: AudienceAdmin,
+		},
+	}
+}
@@ -1,15 +1,52 @@
 package handlers
 
-import "github.com/labstack/echo/v4"
+import (
+	"fmt"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Audience is who a route is served to. Each audience has its own auth, rate limit and
+// logging middleware.
+type Audience string
+
+const (
+	// users of the app, verified by IdentityMiddleware
+	AudiencePublic Audience = "public"
+	// anyone, e.g. plan prices and provider webhooks that carry their own signature
+	AudienceAnonymous Audience = "anonymous"
+	// other fuse services, authenticated with a service token
+	AudienceInternal Audience = "internal"
+	// operators, authenticated with the admin api key
+	AudienceAdmin Audience = "admin"
+)
 
 type Route struct {
-	Method  string
-	Path    string
-	Handler echo.HandlerFunc
+	Method   string
+	Path     string
+	Handler  echo.HandlerFunc
+	Audience Audience
+}
+
+// RouteGroup is the group routes of an audience are added to and the middleware that
+// enforces the audience's policy on them.
+type RouteGroup struct {
+	Group      *echo.Group
+	Middleware []echo.MiddlewareFunc
 }
 
-func RegisterRoutes(e *echo.Group, routes []Route) {
+// RegisterRoutes adds each route to the group of its audience. The middleware is added
+// per route rather than to the group so audiences can share a path prefix.
+func RegisterRoutes(groups map[Audience]RouteGroup, routes []Route) error {
 	for _, route := range routes {
-		e.Add(route.Method, route.Path, route.Handler)
+		group, exists := groups[route.Audience]
+
+		if !exists {
+			return fmt.Errorf("No route group for %s %s with audience %q", route.Method, route.Path, route.Audience)
+		}
+
+		group.Group.Add(route.Method, route.Path, route.Handler, group.Middleware...)
 	}
+
+	return nil
 }
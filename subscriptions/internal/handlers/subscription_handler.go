@@ -0,0 +1,96 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+	"github.com/parbhat-cpp/fuse/subscriptions/internal/apperrors"
+	"github.com/parbhat-cpp/fuse/subscriptions/internal/identity"
+	"github.com/parbhat-cpp/fuse/subscriptions/internal/services"
+)
+
+type SubscriptionHandler struct {
+	s *services.SubscriptionService
+}
+
+func NewSubscriptionHandler(s *services.SubscriptionService) *SubscriptionHandler {
+	return &SubscriptionHandler{
+		s: s,
+	}
+}
+
+func (h *SubscriptionHandler) RequestDowngrade(ctx echo.Context) error {
+	principal, ok := identity.FromContext(ctx)
+
+	if !ok {
+		return apperrors.ErrUnauthorized
+	}
+
+	res, err := h.s.RequestDowngrade(ctx.Request().Context(), principal.UserID, ctx.QueryParam("plan_type"))
+
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(http.StatusOK, res)
+}
+
+func (h *SubscriptionHandler) CancelDowngrade(ctx echo.Context) error {
+	principal, ok := identity.FromContext(ctx)
+
+	if !ok {
+		return apperrors.ErrUnauthorized
+	}
+
+	res, err := h.s.CancelDowngrade(ctx.Request().Context(), principal.UserID)
+
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(http.StatusOK, res)
+}
+
+func (h *SubscriptionHandler) CancelSubscription(ctx echo.Context) error {
+	principal, ok := identity.FromContext(ctx)
+
+	if !ok {
+		return apperrors.ErrUnauthorized
+	}
+
+	refund := false
+
+	if ctx.QueryParam("refund") != "" {
+		var err error
+		refund, err = strconv.ParseBool(ctx.QueryParam("refund"))
+
+		if err != nil {
+			return apperrors.InvalidRequest("invalid refund")
+		}
+	}
+
+	res, err := h.s.CancelSubscription(ctx.Request().Context(), principal.UserID, refund)
+
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(http.StatusOK, res)
+}
+
+func (h *SubscriptionHandler) StartTrial(ctx echo.Context) error {
+	principal, ok := identity.FromContext(ctx)
+
+	if !ok {
+		return apperrors.ErrUnauthorized
+	}
+
+	res, err := h.s.StartTrial(ctx.Request().Context(), principal.UserID)
+
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(http.StatusOK, res)
+}
@@ -0,0 +1,32 @@
+package handlers
+
+import "net/http"
+
+func SubscriptionRoutes(h *SubscriptionHandler) []Route {
+	return []Route{
+		{
+			Method:   http.MethodPost,
+			Path:     "/subscription/downgrade",
+			Handler:  h.RequestDowngrade,
+			Audience: AudiencePublic,
+		},
+		{
+			Method:   http.MethodDelete,
+			Path:     "/subscription/downgrade",
+			Handler:  h.CancelDowngrade,
+			Audience: AudiencePublic,
+		},
+		{
+			Method:   http.MethodPost,
+			Path:     "/subscription/cancel",
+			Handler:  h.CancelSubscription,
+			Audience: AudiencePublic,
+		},
+		{
+			Method:   http.MethodPost,
+			Path:     "/subscription/trial",
+			Handler:  h.StartTrial,
+			Audience: AudiencePublic,
+		},
+	}
+}
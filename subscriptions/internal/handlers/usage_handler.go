@@ -1,11 +1,19 @@
 package handlers
 
 import (
-	"github.com/google/uuid"
+	"net/http"
+	"strconv"
+	"time"
+
 	"github.com/labstack/echo/v4"
+	"github.com/parbhat-cpp/fuse/subscriptions/internal/apperrors"
+	"github.com/parbhat-cpp/fuse/subscriptions/internal/dto"
+	"github.com/parbhat-cpp/fuse/subscriptions/internal/identity"
 	"github.com/parbhat-cpp/fuse/subscriptions/internal/services"
 )
 
+const nextCursorHeader = "X-Next-Cursor"
+
 type UsageHandler struct {
 	s *services.UsageService
 }
@@ -17,43 +25,182 @@ func NewUsageHandler(s *services.UsageService) *UsageHandler {
 }
 
 func (h *UsageHandler) GetCurrentUsage(ctx echo.Context) error {
-	user_id := ctx.Request().Header.Get("X-User-ID")
+	principal, ok := identity.FromContext(ctx)
 
-	res, err := h.s.GetCurrentUsage(uuid.MustParse(user_id))
+	if !ok {
+		return apperrors.ErrUnauthorized
+	}
+
+	res, err := h.s.GetCurrentUsage(ctx.Request().Context(), principal.UserID)
 
 	if err != nil {
-		return ctx.JSON(500, map[string]string{
-			"error": err.Error(),
-		})
+		return err
 	}
 
 	return ctx.JSON(200, res)
 }
 
 func (h *UsageHandler) GetPreviousUsage(ctx echo.Context) error {
-	user_id := ctx.Request().Header.Get("X-User-ID")
+	principal, ok := identity.FromContext(ctx)
+
+	if !ok {
+		return apperrors.ErrUnauthorized
+	}
 
-	res, err := h.s.GetPreviousUsage(uuid.MustParse(user_id))
+	filter, err := parseHistoryFilter(ctx)
 
 	if err != nil {
-		return ctx.JSON(500, map[string]string{
-			"error": err.Error(),
-		})
+		return err
 	}
 
-	return ctx.JSON(200, res)
+	filter.Unpaged = true
+
+	res, err := h.s.GetPreviousUsage(ctx.Request().Context(), principal.UserID, filter)
+
+	if err != nil {
+		return err
+	}
+
+	// v1 bodies are bare lists, so the cursor of the next page goes in a header
+	setNextCursor(ctx, res.NextCursor)
+
+	return ctx.JSON(200, res.Items)
 }
 
 func (h *UsageHandler) GetPreviousSubscription(ctx echo.Context) error {
-	user_id := ctx.Request().Header.Get("X-User-ID")
+	principal, ok := identity.FromContext(ctx)
+
+	if !ok {
+		return apperrors.ErrUnauthorized
+	}
 
-	res, err := h.s.GetPreviousSubscription(uuid.MustParse(user_id))
+	filter, err := parseHistoryFilter(ctx)
 
 	if err != nil {
-		return ctx.JSON(500, map[string]string{
-			"error": err.Error(),
-		})
+		return err
 	}
 
-	return ctx.JSON(200, res)
+	filter.Unpaged = true
+
+	res, err := h.s.GetPreviousSubscription(ctx.Request().Context(), principal.UserID, filter)
+
+	if err != nil {
+		return err
+	}
+
+	// v1 bodies are bare lists, so the cursor of the next page goes in a header
+	setNextCursor(ctx, res.NextCursor)
+
+	return ctx.JSON(200, res.Items)
+}
+
+func (h *UsageHandler) GetCurrentUsageV2(ctx echo.Context) error {
+	principal, ok := identity.FromContext(ctx)
+
+	if !ok {
+		return apperrors.ErrUnauthorized
+	}
+
+	res, err := h.s.GetCurrentUsage(ctx.Request().Context(), principal.UserID)
+
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(http.StatusOK, dto.NewCurrentUsage(res))
+}
+
+func (h *UsageHandler) GetPreviousUsageV2(ctx echo.Context) error {
+	principal, ok := identity.FromContext(ctx)
+
+	if !ok {
+		return apperrors.ErrUnauthorized
+	}
+
+	filter, err := parseHistoryFilter(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	res, err := h.s.GetPreviousUsage(ctx.Request().Context(), principal.UserID, filter)
+
+	if err != nil {
+		return err
+	}
+
+	setNextCursor(ctx, res.NextCursor)
+
+	return ctx.JSON(http.StatusOK, dto.NewPage(dto.NewUsagePeriods(res.Items), res.NextCursor))
+}
+
+func (h *UsageHandler) GetPreviousSubscriptionV2(ctx echo.Context) error {
+	principal, ok := identity.FromContext(ctx)
+
+	if !ok {
+		return apperrors.ErrUnauthorized
+	}
+
+	filter, err := parseHistoryFilter(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	res, err := h.s.GetPreviousSubscription(ctx.Request().Context(), principal.UserID, filter)
+
+	if err != nil {
+		return err
+	}
+
+	setNextCursor(ctx, res.NextCursor)
+
+	return ctx.JSON(http.StatusOK, dto.NewPage(dto.NewSubscriptions(res.Items), res.NextCursor))
+}
+
+// parseHistoryFilter reads the plan_type, from, to, cursor and limit query params. from
+// and to are RFC 3339 timestamps.
+func parseHistoryFilter(ctx echo.Context) (services.HistoryFilter, error) {
+	filter := services.HistoryFilter{
+		PlanType: ctx.QueryParam("plan_type"),
+		Cursor:   ctx.QueryParam("cursor"),
+	}
+
+	if value := ctx.QueryParam("from"); value != "" {
+		from, err := time.Parse(time.RFC3339, value)
+
+		if err != nil {
+			return filter, apperrors.InvalidRequest("invalid from")
+		}
+
+		filter.From = &from
+	}
+
+	if value := ctx.QueryParam("to"); value != "" {
+		to, err := time.Parse(time.RFC3339, value)
+
+		if err != nil {
+			return filter, apperrors.InvalidRequest("invalid to")
+		}
+
+		filter.To = &to
+	}
+
+	if value := ctx.QueryParam("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+
+		if err != nil {
+			return filter, apperrors.InvalidRequest("invalid limit")
+		}
+
+		filter.Limit = limit
+	}
+
+	return filter, nil
+}
+
+func setNextCursor(ctx echo.Context, cursor string) {
+	if cursor != "" {
+		ctx.Response().Header().Set(nextCursorHeader, cursor)
+	}
 }
@@ -3,19 +3,46 @@ package handlers
 func UsageRoutes(h *UsageHandler) []Route {
 	return []Route{
 		{
-			Method:  "GET",
-			Path:    "/usage/current",
-			Handler: h.GetCurrentUsage,
+			Method:   "GET",
+			Path:     "/usage/current",
+			Handler:  h.GetCurrentUsage,
+			Audience: AudiencePublic,
 		},
 		{
-			Method:  "GET",
-			Path:    "/usage/previous",
-			Handler: h.GetPreviousUsage,
+			Method:   "GET",
+			Path:     "/usage/previous",
+			Handler:  h.GetPreviousUsage,
+			Audience: AudiencePublic,
 		},
 		{
-			Method:  "GET",
-			Path:    "/usage/previous-subscription",
-			Handler: h.GetPreviousSubscription,
+			Method:   "GET",
+			Path:     "/usage/previous-subscription",
+			Handler:  h.GetPreviousSubscription,
+			Audience: AudiencePublic,
+		},
+	}
+}
+
+// UsageRoutesV2 serve the same data as UsageRoutes with snake_case response types.
+func UsageRoutesV2(h *UsageHandler) []Route {
+	return []Route{
+		{
+			Method:   "GET",
+			Path:     "/usage/current",
+			Handler:  h.GetCurrentUsageV2,
+			Audience: AudiencePublic,
+		},
+		{
+			Method:   "GET",
+			Path:     "/usage/previous",
+			Handler:  h.GetPreviousUsageV2,
+			Audience: AudiencePublic,
+		},
+		{
+			Method:   "GET",
+			Path:     "/usage/previous-subscription",
+			Handler:  h.GetPreviousSubscriptionV2,
+			Audience: AudiencePublic,
 		},
 	}
 }
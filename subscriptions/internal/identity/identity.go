@@ -0,0 +1,184 @@
+package identity
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/parbhat-cpp/fuse/subscriptions/internal/config"
+)
+
+// Principal is the verified user a request is made by.
+type Principal struct {
+	UserID uuid.UUID
+	Email  string
+	Name   string
+	Source Source
+}
+
+// Source is the credential a principal was verified from.
+type Source string
+
+const (
+	SourceSupabase Source = "supabase"
+	SourceGateway  Source = "gateway"
+)
+
+var ErrNoCredentials = errors.New("no credentials")
+var ErrInvalidCredentials = errors.New("invalid credentials")
+
+// the gateway assertion is a short lived HS256 token the auth service signs for nginx
+const AssertionHeader = "X-User-Assertion"
+const assertionIssuer = "fuse-gateway"
+const assertionAudience = "fuse-services"
+
+// clock skew allowed between the token issuer and this service
+const leeway = 30 * time.Second
+
+const principalKey = "identity.principal"
+
+type claims struct {
+	Email        string `json:"email"`
+	Name         string `json:"name"`
+	UserMetadata struct {
+		FullName string `json:"full_name"`
+	} `json:"user_metadata"`
+	jwt.RegisteredClaims
+}
+
+// Verifier verifies the credentials of a request: a Supabase access token in the
+// Authorization header or, failing that, an assertion signed by the gateway.
+type Verifier struct {
+	supabase_secret   []byte
+	supabase_jwks     *JWKS
+	supabase_issuer   string
+	supabase_audience string
+	gateway_secret    []byte
+}
+
+func NewVerifier() *Verifier {
+	cfg := config.LoadEnv()
+
+	verifier := &Verifier{
+		supabase_secret:   []byte(cfg.SUPABASE_JWT_SECRET),
+		supabase_issuer:   cfg.SUPABASE_JWT_ISSUER,
+		supabase_audience: cfg.SUPABASE_JWT_AUDIENCE,
+		gateway_secret:    []byte(cfg.GATEWAY_ASSERTION_SECRET),
+	}
+
+	if cfg.SUPABASE_JWKS_URL != "" {
+		verifier.supabase_jwks = NewJWKS(cfg.SUPABASE_JWKS_URL)
+	}
+
+	return verifier
+}
+
+/**
+ * Returns the principal of a request. ErrNoCredentials is returned when the request
+ * carries neither a bearer token nor a gateway assertion.
+ * @param r: *http.Request
+ * @return Principal, error
+ */
+func (v *Verifier) Verify(r *http.Request) (Principal, error) {
+	if token, found := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); found && token != "" {
+		return v.verifySupabase(token)
+	}
+
+	if assertion := r.Header.Get(AssertionHeader); assertion != "" {
+		return v.verifyGateway(assertion)
+	}
+
+	return Principal{}, ErrNoCredentials
+}
+
+func (v *Verifier) verifySupabase(token string) (Principal, error) {
+	options := []jwt.ParserOption{
+		jwt.WithValidMethods([]string{"HS256", "RS256", "ES256"}),
+		jwt.WithAudience(v.supabase_audience),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(leeway),
+	}
+
+	if v.supabase_issuer != "" {
+		options = append(options, jwt.WithIssuer(v.supabase_issuer))
+	}
+
+	return parse(token, SourceSupabase, func(t *jwt.Token) (any, error) {
+		// legacy projects sign with a shared secret, newer ones with keys published as a JWKS
+		if _, ok := t.Method.(*jwt.SigningMethodHMAC); ok {
+			if len(v.supabase_secret) == 0 {
+				return nil, fmt.Errorf("No supabase jwt secret configured")
+			}
+			return v.supabase_secret, nil
+		}
+
+		if v.supabase_jwks == nil {
+			return nil, fmt.Errorf("No supabase jwks configured")
+		}
+
+		kid, _ := t.Header["kid"].(string)
+
+		return v.supabase_jwks.Key(kid)
+	}, options...)
+}
+
+func (v *Verifier) verifyGateway(assertion string) (Principal, error) {
+	if len(v.gateway_secret) == 0 {
+		return Principal{}, fmt.Errorf("%w: gateway assertions are not accepted", ErrInvalidCredentials)
+	}
+
+	return parse(assertion, SourceGateway, func(t *jwt.Token) (any, error) {
+		return v.gateway_secret, nil
+	},
+		jwt.WithValidMethods([]string{"HS256"}),
+		jwt.WithIssuer(assertionIssuer),
+		jwt.WithAudience(assertionAudience),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(leeway),
+	)
+}
+
+func parse(token string, source Source, keyfunc jwt.Keyfunc, options ...jwt.ParserOption) (Principal, error) {
+	parsed_claims := &claims{}
+
+	_, err := jwt.ParseWithClaims(token, parsed_claims, keyfunc, options...)
+
+	if err != nil {
+		return Principal{}, fmt.Errorf("%w: %v", ErrInvalidCredentials, err)
+	}
+
+	user_id, err := uuid.Parse(parsed_claims.Subject)
+
+	if err != nil || user_id == uuid.Nil {
+		return Principal{}, fmt.Errorf("%w: subject is not a user id", ErrInvalidCredentials)
+	}
+
+	name := parsed_claims.Name
+
+	if name == "" {
+		name = parsed_claims.UserMetadata.FullName
+	}
+
+	return Principal{
+		UserID: user_id,
+		Email:  parsed_claims.Email,
+		Name:   name,
+		Source: source,
+	}, nil
+}
+
+// SetPrincipal stores the verified principal of a request.
+func SetPrincipal(c echo.Context, principal Principal) {
+	c.Set(principalKey, principal)
+}
+
+// FromContext returns the verified principal of a request, if any.
+func FromContext(c echo.Context) (Principal, bool) {
+	principal, ok := c.Get(principalKey).(Principal)
+	return principal, ok
+}
@@ -0,0 +1,209 @@
+package identity
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// keys are refetched this often, and at most once a minute when a token names an
+// unknown key, which happens right after the signing key is rotated
+const jwksTTL = time.Hour
+const jwksMinRefresh = time.Minute
+
+// JWKS caches the public keys published at a JSON Web Key Set url. The set is fetched
+// without holding the lock, so requests with a known key are not held up by a slow url.
+type JWKS struct {
+	url    string
+	client *http.Client
+
+	mu         sync.Mutex
+	keys       map[string]any
+	fetched_at time.Time
+	// closed when the fetch in progress is done; nil when none is
+	fetching  chan struct{}
+	fetch_err error
+}
+
+type jsonWebKey struct {
+	Kid string `json:"kid"`
+	Kty string `json:"kty"`
+	Use string `json:"use"`
+	Crv string `json:"crv"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+func NewJWKS(url string) *JWKS {
+	return &JWKS{
+		url:    url,
+		client: &http.Client{Timeout: 5 * time.Second},
+		keys:   map[string]any{},
+	}
+}
+
+// Key returns the public key with the given key id.
+func (j *JWKS) Key(kid string) (any, error) {
+	j.mu.Lock()
+
+	key, exists := j.keys[kid]
+	age := time.Since(j.fetched_at)
+	done := j.fetching
+
+	if exists && (age < jwksTTL || done != nil) {
+		// a known key is used while another request refetches
+		j.mu.Unlock()
+		return key, nil
+	}
+
+	if done == nil && !exists && age < jwksMinRefresh {
+		j.mu.Unlock()
+		return nil, fmt.Errorf("Unknown signing key %q", kid)
+	}
+
+	if done == nil {
+		done = make(chan struct{})
+		j.fetching = done
+		j.mu.Unlock()
+
+		j.refresh(done)
+	} else {
+		j.mu.Unlock()
+		<-done
+	}
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if refreshed, found := j.keys[kid]; found {
+		return refreshed, nil
+	}
+
+	// keep using a known key while the jwks url is unreachable
+	if exists && j.fetch_err != nil {
+		return key, nil
+	}
+
+	if j.fetch_err != nil {
+		return nil, j.fetch_err
+	}
+
+	return nil, fmt.Errorf("Unknown signing key %q", kid)
+}
+
+// refresh fetches the set and swaps it in, then closes done.
+func (j *JWKS) refresh(done chan struct{}) {
+	keys, err := j.fetch()
+
+	j.mu.Lock()
+
+	if err == nil {
+		j.keys = keys
+	}
+	j.fetch_err = err
+	j.fetched_at = time.Now()
+	j.fetching = nil
+
+	j.mu.Unlock()
+	close(done)
+}
+
+func (j *JWKS) fetch() (map[string]any, error) {
+	res, err := j.client.Get(j.url)
+
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("Fetching jwks failed with status %d", res.StatusCode)
+	}
+
+	var set struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+
+	err = json.NewDecoder(res.Body).Decode(&set)
+
+	if err != nil {
+		return nil, err
+	}
+
+	keys := map[string]any{}
+
+	for _, jwk := range set.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+
+		key, err := jwk.publicKey()
+
+		if err != nil {
+			continue
+		}
+
+		keys[jwk.Kid] = key
+	}
+
+	return keys, nil
+}
+
+func (jwk jsonWebKey) publicKey() (any, error) {
+	switch jwk.Kty {
+	case "RSA":
+		n, err := decodeBigInt(jwk.N)
+
+		if err != nil {
+			return nil, err
+		}
+
+		e, err := decodeBigInt(jwk.E)
+
+		if err != nil {
+			return nil, err
+		}
+
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+
+	case "EC":
+		if jwk.Crv != "P-256" {
+			return nil, fmt.Errorf("Unsupported curve %s", jwk.Crv)
+		}
+
+		x, err := decodeBigInt(jwk.X)
+
+		if err != nil {
+			return nil, err
+		}
+
+		y, err := decodeBigInt(jwk.Y)
+
+		if err != nil {
+			return nil, err
+		}
+
+		return &ecdsa.PublicKey{Curve: elliptic.P256(), X: x, Y: y}, nil
+	}
+
+	return nil, fmt.Errorf("Unsupported key type %s", jwk.Kty)
+}
+
+func decodeBigInt(value string) (*big.Int, error) {
+	bytes, err := base64.RawURLEncoding.DecodeString(value)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return new(big.Int).SetBytes(bytes), nil
+}
@@ -0,0 +1,99 @@
+package jobs
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// RunEvery calls fn once per interval until ctx is cancelled. Errors are
+// logged and do not stop the loop. A run in progress is not interrupted.
+func RunEvery(ctx context.Context, name string, interval time.Duration, fn func() error) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			// the tick and the cancellation can arrive together
+			if ctx.Err() != nil {
+				return
+			}
+
+			if err := fn(); err != nil {
+				slog.Error("Job failed", "job", name, "error", err)
+			}
+		}
+	}
+}
+
+/**
+ * Calls fn once and waits for it to finish or for ctx to be done, whichever is first.
+ * @param ctx: context.Context
+ * @param name: string
+ * @param fn: func() error
+ * @return error, ctx.Err() when fn was still running
+ */
+func RunOnce(ctx context.Context, name string, fn func() error) error {
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+
+		if err := fn(); err != nil {
+			slog.Error("Job failed", "job", name, "error", err)
+		}
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Group runs jobs that are stopped together, so shutdown can wait for the runs in
+// progress instead of cutting them off mid-transaction.
+type Group struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+func NewGroup() *Group {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	return &Group{
+		ctx:    ctx,
+		cancel: cancel,
+	}
+}
+
+// Every starts a job calling fn once per interval until the group is stopped.
+func (g *Group) Every(name string, interval time.Duration, fn func() error) {
+	g.wg.Add(1)
+
+	go func() {
+		defer g.wg.Done()
+		RunEvery(g.ctx, name, interval, fn)
+	}()
+}
+
+/**
+ * Stops scheduling runs and waits for the ones in progress to finish or for ctx to be
+ * done, whichever is first.
+ * @param ctx: context.Context
+ * @return error, ctx.Err() when runs were still in progress
+ */
+func (g *Group) Stop(ctx context.Context) error {
+	g.cancel()
+
+	return RunOnce(ctx, "stop jobs", func() error {
+		g.wg.Wait()
+		return nil
+	})
+}
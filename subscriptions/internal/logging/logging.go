@@ -0,0 +1,100 @@
+// Package logging sets up structured JSON logging with log/slog. Fields added to a
+// context with With are written with every record logged with that context, along with
+// the ids of the active trace and span. Values of sensitive fields are redacted.
+package logging
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+type contextKey struct{}
+
+/**
+ * Makes a JSON logger writing to w the default logger. Output of the standard log
+ * package goes through it too.
+ * @param w: io.Writer
+ * @param level: string, one of debug, info, warn or error
+ */
+func Setup(w io.Writer, level string) {
+	var log_level slog.Level
+
+	// unknown levels fall back to info
+	_ = log_level.UnmarshalText([]byte(level))
+
+	slog.SetDefault(slog.New(&contextHandler{
+		Handler: slog.NewJSONHandler(w, &slog.HandlerOptions{
+			Level:       log_level,
+			ReplaceAttr: redactAttr,
+		}),
+	}))
+}
+
+// With returns ctx carrying args, as key value pairs or slog.Attr, in addition to the
+// fields it already carries.
+func With(ctx context.Context, args ...any) context.Context {
+	attrs := append([]slog.Attr{}, fromContext(ctx)...)
+	record := slog.Record{}
+	record.Add(args...)
+	record.Attrs(func(attr slog.Attr) bool {
+		attrs = append(attrs, attr)
+		return true
+	})
+
+	return context.WithValue(ctx, contextKey{}, attrs)
+}
+
+// Fatal logs msg at error level and exits.
+func Fatal(msg string, args ...any) {
+	slog.Error(msg, args...)
+	os.Exit(1)
+}
+
+func fromContext(ctx context.Context) []slog.Attr {
+	attrs, _ := ctx.Value(contextKey{}).([]slog.Attr)
+	return attrs
+}
+
+// contextHandler adds the fields of the context a record is logged with.
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h *contextHandler) Handle(ctx context.Context, record slog.Record) error {
+	record.AddAttrs(fromContext(ctx)...)
+
+	if span := trace.SpanContextFromContext(ctx); span.IsValid() {
+		record.AddAttrs(slog.String("trace_id", span.TraceID().String()), slog.String("span_id", span.SpanID().String()))
+	}
+
+	return h.Handler.Handle(ctx, record)
+}
+
+func (h *contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &contextHandler{Handler: h.Handler.WithAttrs(attrs)}
+}
+
+func (h *contextHandler) WithGroup(name string) slog.Handler {
+	return &contextHandler{Handler: h.Handler.WithGroup(name)}
+}
+
+// keys are redacted when they contain any of these, e.g. razorpay_signature or
+// Authorization
+var sensitiveKeys = []string{"signature", "secret", "password", "token", "authorization", "api_key", "apikey", "x-admin-key"}
+
+func isSensitive(key string) bool {
+	key = strings.ToLower(key)
+
+	for _, sensitive := range sensitiveKeys {
+		if strings.Contains(key, sensitive) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,65 @@
+package logging
+
+import (
+	"encoding/json"
+	"log/slog"
+	"reflect"
+)
+
+const redacted = "[REDACTED]"
+
+// redactAttr hides the value of sensitive attributes. Structs and maps are logged as
+// their JSON, with sensitive fields at any depth hidden as well.
+func redactAttr(_ []string, attr slog.Attr) slog.Attr {
+	if isSensitive(attr.Key) {
+		return slog.String(attr.Key, redacted)
+	}
+
+	if attr.Value.Kind() != slog.KindAny {
+		return attr
+	}
+
+	value := attr.Value.Any()
+
+	if _, ok := value.(error); ok || !isComposite(value) {
+		return attr
+	}
+
+	raw, err := json.Marshal(value)
+
+	if err != nil {
+		return attr
+	}
+
+	var decoded any
+
+	if json.Unmarshal(raw, &decoded) != nil {
+		return attr
+	}
+
+	return slog.Any(attr.Key, redactValue(decoded))
+}
+
+func isComposite(value any) bool {
+	kind := reflect.Indirect(reflect.ValueOf(value)).Kind()
+	return kind == reflect.Struct || kind == reflect.Map || kind == reflect.Slice || kind == reflect.Array
+}
+
+func redactValue(value any) any {
+	switch value := value.(type) {
+	case map[string]any:
+		for key, nested := range value {
+			if isSensitive(key) {
+				value[key] = redacted
+			} else {
+				value[key] = redactValue(nested)
+			}
+		}
+	case []any:
+		for i, nested := range value {
+			value[i] = redactValue(nested)
+		}
+	}
+
+	return value
+}
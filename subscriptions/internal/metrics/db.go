@@ -0,0 +1,86 @@
+package metrics
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/parbhat-cpp/fuse/subscriptions/pkg/utils"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+type queryStartKey struct{}
+
+type queryStart struct {
+	name string
+	at   time.Time
+}
+
+// QueryTracer observes the latency of queries run on a pgx connection, labelled by their
+// sqlc query name.
+type QueryTracer struct{}
+
+func (QueryTracer) TraceQueryStart(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryStartData) context.Context {
+	return context.WithValue(ctx, queryStartKey{}, queryStart{name: utils.QueryName(data.SQL), at: time.Now()})
+}
+
+func (QueryTracer) TraceQueryEnd(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryEndData) {
+	start, ok := ctx.Value(queryStartKey{}).(queryStart)
+
+	if !ok {
+		return
+	}
+
+	DBQueryDuration.WithLabelValues(start.name, outcome(data.Err)).Observe(time.Since(start.at).Seconds())
+}
+
+// poolCollector reports the statistics of a pgx pool each time metrics are scraped.
+type poolCollector struct {
+	pool *pgxpool.Pool
+
+	acquired_conns    *prometheus.Desc
+	idle_conns        *prometheus.Desc
+	total_conns       *prometheus.Desc
+	max_conns         *prometheus.Desc
+	acquires          *prometheus.Desc
+	empty_acquires    *prometheus.Desc
+	canceled_acquires *prometheus.Desc
+	acquire_seconds   *prometheus.Desc
+}
+
+// RegisterPool adds the statistics of pool to the metrics.
+func RegisterPool(pool *pgxpool.Pool) {
+	desc := func(name string, help string) *prometheus.Desc {
+		return prometheus.NewDesc(prometheus.BuildFQName(namespace, "db_pool", name), help, nil, nil)
+	}
+
+	prometheus.MustRegister(&poolCollector{
+		pool:              pool,
+		acquired_conns:    desc("acquired_conns", "Connections currently in use."),
+		idle_conns:        desc("idle_conns", "Idle connections."),
+		total_conns:       desc("total_conns", "Open connections."),
+		max_conns:         desc("max_conns", "Maximum size of the pool."),
+		acquires:          desc("acquires_total", "Connections acquired from the pool."),
+		empty_acquires:    desc("empty_acquires_total", "Acquires that had to wait for a connection."),
+		canceled_acquires: desc("canceled_acquires_total", "Acquires canceled by their context."),
+		acquire_seconds:   desc("acquire_duration_seconds_total", "Total time spent acquiring connections."),
+	})
+}
+
+func (c *poolCollector) Describe(ch chan<- *prometheus.Desc) {
+	prometheus.DescribeByCollect(c, ch)
+}
+
+func (c *poolCollector) Collect(ch chan<- prometheus.Metric) {
+	stat := c.pool.Stat()
+
+	ch <- prometheus.MustNewConstMetric(c.acquired_conns, prometheus.GaugeValue, float64(stat.AcquiredConns()))
+	ch <- prometheus.MustNewConstMetric(c.idle_conns, prometheus.GaugeValue, float64(stat.IdleConns()))
+	ch <- prometheus.MustNewConstMetric(c.total_conns, prometheus.GaugeValue, float64(stat.TotalConns()))
+	ch <- prometheus.MustNewConstMetric(c.max_conns, prometheus.GaugeValue, float64(stat.MaxConns()))
+	ch <- prometheus.MustNewConstMetric(c.acquires, prometheus.CounterValue, float64(stat.AcquireCount()))
+	ch <- prometheus.MustNewConstMetric(c.empty_acquires, prometheus.CounterValue, float64(stat.EmptyAcquireCount()))
+	ch <- prometheus.MustNewConstMetric(c.canceled_acquires, prometheus.CounterValue, float64(stat.CanceledAcquireCount()))
+	ch <- prometheus.MustNewConstMetric(c.acquire_seconds, prometheus.CounterValue, stat.AcquireDuration().Seconds())
+}
@@ -0,0 +1,33 @@
+package metrics
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// HTTPMiddleware observes the latency of each request. Routes are labelled by their
+// template, e.g. /v1/invoices/:invoice_id/pdf, so ids do not create new series.
+func HTTPMiddleware(nextHandler echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		start := time.Now()
+
+		err := nextHandler(c)
+
+		// render the error now so the status it is sent with is known
+		if err != nil {
+			c.Error(err)
+		}
+
+		route := c.Path()
+
+		if route == "" {
+			route = "unmatched"
+		}
+
+		HTTPRequestDuration.WithLabelValues(c.Request().Method, route, strconv.Itoa(c.Response().Status)).Observe(time.Since(start).Seconds())
+
+		return err
+	}
+}
@@ -0,0 +1,96 @@
+// Package metrics holds the Prometheus metrics of the service. Metrics are registered on
+// the default registry, which Handler serves along with the Go runtime and process
+// metrics.
+package metrics
+
+import (
+	"github.com/labstack/echo/v4"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "subscriptions"
+
+// outcomes of an access request
+const (
+	AccessAllowed = "allowed"
+	AccessDenied  = "denied"
+	AccessError   = "error"
+)
+
+// outcomes of a refund worker step
+const (
+	RefundSubmitted = "submitted"
+	RefundProcessed = "processed"
+	RefundFailed    = "failed"
+	// the call to razorpay failed and is retried later
+	RefundRetried = "retried"
+)
+
+// outcomes of an outbox delivery
+const (
+	DeliveryDelivered = "delivered"
+	DeliveryRetried   = "retried"
+	DeliveryDead      = "dead"
+	// not attempted because the circuit breaker of the notification service is open
+	DeliveryCircuitOpen = "circuit_open"
+)
+
+var AccessDecisions = promauto.NewCounterVec(prometheus.CounterOpts{
+	Namespace: namespace,
+	Name:      "access_decisions_total",
+	Help:      "Access requests by access type, plan and outcome.",
+}, []string{"access_type", "plan", "outcome"})
+
+var PaymentVerifications = promauto.NewCounterVec(prometheus.CounterOpts{
+	Namespace: namespace,
+	Name:      "payment_verifications_total",
+	Help:      "Payment verifications by outcome and reason code.",
+}, []string{"outcome", "reason"})
+
+var Refunds = promauto.NewCounterVec(prometheus.CounterOpts{
+	Namespace: namespace,
+	Name:      "refunds_total",
+	Help:      "Refund worker steps by outcome.",
+}, []string{"outcome"})
+
+var OutboxDeliveries = promauto.NewCounterVec(prometheus.CounterOpts{
+	Namespace: namespace,
+	Name:      "outbox_deliveries_total",
+	Help:      "Deliveries of notifications and domain events by topic and outcome.",
+}, []string{"topic", "outcome"})
+
+var HTTPRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+	Namespace: namespace,
+	Name:      "http_request_duration_seconds",
+	Help:      "Latency of HTTP handlers by method, route and status.",
+	Buckets:   prometheus.DefBuckets,
+}, []string{"method", "route", "status"})
+
+var DBQueryDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+	Namespace: namespace,
+	Name:      "db_query_duration_seconds",
+	Help:      "Latency of database queries by sqlc query name and outcome.",
+	Buckets:   []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+}, []string{"query", "outcome"})
+
+var RazorpayRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+	Namespace: namespace,
+	Name:      "razorpay_request_duration_seconds",
+	Help:      "Latency of razorpay API calls by method, endpoint and status.",
+	Buckets:   prometheus.DefBuckets,
+}, []string{"method", "endpoint", "status"})
+
+// Handler serves the metrics in the Prometheus text format.
+func Handler() echo.HandlerFunc {
+	return echo.WrapHandler(promhttp.Handler())
+}
+
+// outcome is the outcome label of a call that failed or not.
+func outcome(err error) string {
+	if err != nil {
+		return "error"
+	}
+	return "ok"
+}
@@ -0,0 +1,53 @@
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// razorpayTransport observes the latency of requests made by the razorpay client.
+type razorpayTransport struct {
+	next http.RoundTripper
+}
+
+// RazorpayTransport wraps next, or http.DefaultTransport when it is nil, so calls to
+// razorpay are observed.
+func RazorpayTransport(next http.RoundTripper) http.RoundTripper {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+
+	return &razorpayTransport{next: next}
+}
+
+func (t *razorpayTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+
+	res, err := t.next.RoundTrip(req)
+
+	status := "error"
+
+	if err == nil {
+		status = strconv.Itoa(res.StatusCode)
+	}
+
+	RazorpayRequestDuration.WithLabelValues(req.Method, razorpayEndpoint(req.URL.Path), status).Observe(time.Since(start).Seconds())
+
+	return res, err
+}
+
+// razorpayEndpoint replaces the ids in a razorpay path, which all look like pay_Abc123,
+// so /v1/payments/pay_Abc123/refunds becomes /v1/payments/:id/refunds.
+func razorpayEndpoint(path string) string {
+	segments := strings.Split(path, "/")
+
+	for i, segment := range segments {
+		if strings.Contains(segment, "_") {
+			segments[i] = ":id"
+		}
+	}
+
+	return strings.Join(segments, "/")
+}
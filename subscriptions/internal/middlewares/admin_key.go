@@ -0,0 +1,23 @@
+package middlewares
+
+import (
+	"crypto/subtle"
+
+	"github.com/labstack/echo/v4"
+	"github.com/parbhat-cpp/fuse/subscriptions/internal/apperrors"
+	"github.com/parbhat-cpp/fuse/subscriptions/internal/config"
+)
+
+// AdminKeyMiddleware only lets requests carrying the configured ADMIN_API_KEY in the
+// X-Admin-Key header through. All requests are rejected when no key is configured.
+func AdminKeyMiddleware(nextHandler echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		admin_key := config.LoadEnv().ADMIN_API_KEY
+		request_key := c.Request().Header.Get("X-Admin-Key")
+
+		if admin_key == "" || subtle.ConstantTimeCompare([]byte(admin_key), []byte(request_key)) != 1 {
+			return apperrors.ErrUnauthorized
+		}
+		return nextHandler(c)
+	}
+}
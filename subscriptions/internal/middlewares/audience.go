@@ -0,0 +1,90 @@
+package middlewares
+
+import (
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+	"github.com/parbhat-cpp/fuse/subscriptions/internal/apperrors"
+	"github.com/parbhat-cpp/fuse/subscriptions/internal/identity"
+	"golang.org/x/time/rate"
+)
+
+// RequestLoggerMiddleware logs each request, tagged with the audience of the route it
+// matched. Failed requests are logged at warn level, or error level for 5xx responses.
+func RequestLoggerMiddleware(audience string) echo.MiddlewareFunc {
+	return middleware.RequestLoggerWithConfig(middleware.RequestLoggerConfig{
+		LogStatus:        true,
+		LogMethod:        true,
+		LogRoutePath:     true,
+		LogRemoteIP:      true,
+		LogLatency:       true,
+		LogContentLength: true,
+		LogResponseSize:  true,
+		LogError:         true,
+		// renders the error first so the status it is sent with is logged
+		HandleError: true,
+		LogValuesFunc: func(c echo.Context, values middleware.RequestLoggerValues) error {
+			level := slog.LevelInfo
+
+			if values.Status >= http.StatusInternalServerError {
+				level = slog.LevelError
+			} else if values.Error != nil {
+				level = slog.LevelWarn
+			}
+
+			attrs := []slog.Attr{
+				slog.String("audience", audience),
+				slog.String("method", values.Method),
+				slog.String("route", values.RoutePath),
+				slog.Int("status", values.Status),
+				slog.String("remote_ip", values.RemoteIP),
+				slog.Duration("latency", values.Latency),
+				slog.String("bytes_in", values.ContentLength),
+				slog.Int64("bytes_out", values.ResponseSize),
+			}
+
+			if values.Error != nil {
+				attrs = append(attrs, slog.String("error", values.Error.Error()))
+			}
+
+			slog.LogAttrs(c.Request().Context(), level, "Request", attrs...)
+
+			return nil
+		},
+	})
+}
+
+// RateLimitMiddleware allows each caller requests_per_second requests a second, with
+// bursts of as many. Verified users are told apart by their id, anyone else by IP, so
+// it must run after IdentityMiddleware. Rate limiting is off when requests_per_second
+// is not positive.
+func RateLimitMiddleware(requests_per_second int) echo.MiddlewareFunc {
+	if requests_per_second <= 0 {
+		return func(nextHandler echo.HandlerFunc) echo.HandlerFunc {
+			return nextHandler
+		}
+	}
+
+	return middleware.RateLimiterWithConfig(middleware.RateLimiterConfig{
+		Store: middleware.NewRateLimiterMemoryStoreWithConfig(middleware.RateLimiterMemoryStoreConfig{
+			Rate:      rate.Limit(requests_per_second),
+			Burst:     requests_per_second,
+			ExpiresIn: 3 * time.Minute,
+		}),
+		IdentifierExtractor: func(c echo.Context) (string, error) {
+			if principal, ok := identity.FromContext(c); ok {
+				return "user:" + principal.UserID.String(), nil
+			}
+			return "ip:" + c.RealIP(), nil
+		},
+		ErrorHandler: func(c echo.Context, err error) error {
+			return apperrors.New(apperrors.CodeForbidden, "unable to identify caller")
+		},
+		DenyHandler: func(c echo.Context, identifier string, err error) error {
+			return apperrors.New(apperrors.CodeRateLimited, "too many requests")
+		},
+	})
+}
@@ -0,0 +1,36 @@
+package middlewares
+
+import (
+	"fmt"
+	"net"
+
+	"github.com/labstack/echo/v4"
+)
+
+/**
+ * Returns how the client ip of a request is found. X-Forwarded-For is only read when
+ * the request came from one of the trusted proxy networks, otherwise anyone could set
+ * the ip recorded for them.
+ * @param cidrs: []string, networks of the trusted proxies
+ * @return echo.IPExtractor, error
+ */
+func ClientIPExtractor(cidrs []string) (echo.IPExtractor, error) {
+	if len(cidrs) == 0 {
+		return echo.ExtractIPDirect(), nil
+	}
+
+	// only the listed networks, not every private network echo trusts by default
+	options := []echo.TrustOption{echo.TrustLoopback(false), echo.TrustLinkLocal(false), echo.TrustPrivateNet(false)}
+
+	for _, cidr := range cidrs {
+		_, network, err := net.ParseCIDR(cidr)
+
+		if err != nil {
+			return nil, fmt.Errorf("Invalid trusted proxy network %q: %w", cidr, err)
+		}
+
+		options = append(options, echo.TrustIPRange(network))
+	}
+
+	return echo.ExtractIPFromXFFHeader(options...), nil
+}
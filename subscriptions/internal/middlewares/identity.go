@@ -0,0 +1,27 @@
+package middlewares
+
+import (
+	"github.com/labstack/echo/v4"
+	"github.com/parbhat-cpp/fuse/subscriptions/internal/apperrors"
+	"github.com/parbhat-cpp/fuse/subscriptions/internal/identity"
+	"github.com/parbhat-cpp/fuse/subscriptions/internal/logging"
+)
+
+// IdentityMiddleware verifies the user making the request and stores them as the
+// request's principal. Requests without valid credentials are rejected.
+func IdentityMiddleware(verifier *identity.Verifier) echo.MiddlewareFunc {
+	return func(nextHandler echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			principal, err := verifier.Verify(c.Request())
+
+			if err != nil {
+				return apperrors.ErrUnauthorized
+			}
+
+			identity.SetPrincipal(c, principal)
+			c.SetRequest(c.Request().WithContext(logging.With(c.Request().Context(), "user_id", principal.UserID)))
+
+			return nextHandler(c)
+		}
+	}
+}
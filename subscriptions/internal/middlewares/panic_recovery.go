@@ -1,19 +1,31 @@
 package middlewares
 
 import (
-	"log"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"runtime/debug"
 
 	"github.com/labstack/echo/v4"
+	"github.com/parbhat-cpp/fuse/subscriptions/internal/apperrors"
 )
 
+// PanicRecoveryMiddleware turns a panic in the handler into an internal error, so it is
+// rendered by HTTPErrorHandler like any other and its value never reaches the caller.
 func PanicRecoveryMiddleware(nextHandler echo.HandlerFunc) echo.HandlerFunc {
-	return func(c echo.Context) error {
-		if r := recover(); r != nil {
-			log.Printf("Panic recovered: %v", r)
-			return c.JSON(500, map[string]string{
-				"error": "Internal Server Error: " + r.(string),
-			})
-		}
+	return func(c echo.Context) (err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				// aborting the response is left to net/http
+				if r == http.ErrAbortHandler {
+					panic(r)
+				}
+
+				slog.ErrorContext(c.Request().Context(), "Panic recovered", "panic", r, "stack", string(debug.Stack()))
+				err = apperrors.ErrInternal.Wrap(fmt.Errorf("Panic: %v", r))
+			}
+		}()
+
 		return nextHandler(c)
 	}
 }
@@ -0,0 +1,17 @@
+package middlewares
+
+import (
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+	"github.com/parbhat-cpp/fuse/subscriptions/internal/logging"
+)
+
+// RequestIDMiddleware keeps the X-Request-Id the caller sent, or generates one, returns
+// it in the response and adds it to everything logged for the request.
+func RequestIDMiddleware() echo.MiddlewareFunc {
+	return middleware.RequestIDWithConfig(middleware.RequestIDConfig{
+		RequestIDHandler: func(c echo.Context, request_id string) {
+			c.SetRequest(c.Request().WithContext(logging.With(c.Request().Context(), "request_id", request_id)))
+		},
+	})
+}
@@ -0,0 +1,38 @@
+package middlewares
+
+import (
+	"crypto/subtle"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"github.com/parbhat-cpp/fuse/subscriptions/internal/apperrors"
+	"github.com/parbhat-cpp/fuse/subscriptions/internal/config"
+)
+
+// ServiceTokenMiddleware only lets requests from other services through. They must send
+// one of the configured SERVICE_TOKENS as a bearer token. Several tokens can be
+// configured at once so a token can be rotated without downtime: add the new token,
+// move the callers over, then remove the old one. All requests are rejected when no
+// token is configured.
+func ServiceTokenMiddleware(nextHandler echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		request_token, found := strings.CutPrefix(c.Request().Header.Get("Authorization"), "Bearer ")
+
+		if !found || request_token == "" || !isServiceToken(config.LoadEnv().SERVICE_TOKENS, request_token) {
+			return apperrors.ErrUnauthorized
+		}
+		return nextHandler(c)
+	}
+}
+
+// isServiceToken compares the request token with every configured token so the time
+// taken does not depend on which one matched.
+func isServiceToken(tokens []string, request_token string) bool {
+	matched := 0
+
+	for _, token := range tokens {
+		matched |= subtle.ConstantTimeCompare([]byte(token), []byte(request_token))
+	}
+
+	return matched == 1
+}
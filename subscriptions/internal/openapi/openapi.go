@@ -0,0 +1,204 @@
+package openapi
+
+import (
+	_ "embed"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers"
+	"github.com/labstack/echo/v4"
+	"github.com/parbhat-cpp/fuse/subscriptions/internal/apperrors"
+)
+
+//go:embed openapi.yaml
+var specYAML []byte
+
+// versioned api routes; /health and the document itself are not part of it
+var versionedPath = regexp.MustCompile(`^/v[0-9]+/`)
+
+// any uuid version; kin-openapi only knows the formats it is told about
+const uuidPattern = `^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`
+
+func init() {
+	openapi3.DefineStringFormat("uuid", uuidPattern)
+}
+
+/**
+ * Parses and validates the OpenAPI document of the service.
+ * @return *openapi3.T, error
+ */
+func Load() (*openapi3.T, error) {
+	loader := openapi3.NewLoader()
+
+	doc, err := loader.LoadFromData(specYAML)
+
+	if err != nil {
+		return nil, err
+	}
+
+	err = doc.Validate(loader.Context)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return doc, nil
+}
+
+// SpecYAMLHandler serves the OpenAPI document as written.
+func SpecYAMLHandler(c echo.Context) error {
+	return c.Blob(http.StatusOK, "application/yaml", specYAML)
+}
+
+// SpecJSONHandler serves the OpenAPI document as JSON.
+func SpecJSONHandler(doc *openapi3.T) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		return c.JSON(http.StatusOK, doc)
+	}
+}
+
+// ValidationMiddleware rejects requests whose parameters or body do not match the
+// operation in the document with a 400. Credentials are checked by the audience
+// middleware, not here.
+func ValidationMiddleware(doc *openapi3.T) echo.MiddlewareFunc {
+	options := &openapi3filter.Options{
+		AuthenticationFunc: openapi3filter.NoopAuthenticationFunc,
+	}
+
+	return func(nextHandler echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			method := c.Request().Method
+			path := pathTemplate(c.Path())
+			path_item := doc.Paths.Value(path)
+
+			// routes missing from the document are reported by CheckRoutes at startup
+			if path_item == nil || path_item.GetOperation(method) == nil {
+				return nextHandler(c)
+			}
+
+			path_params := map[string]string{}
+
+			for i, name := range c.ParamNames() {
+				path_params[name] = c.ParamValues()[i]
+			}
+
+			err := openapi3filter.ValidateRequest(c.Request().Context(), &openapi3filter.RequestValidationInput{
+				Request:    c.Request(),
+				PathParams: path_params,
+				Route: &routers.Route{
+					Spec:      doc,
+					Path:      path,
+					PathItem:  path_item,
+					Method:    method,
+					Operation: path_item.GetOperation(method),
+				},
+				Options: options,
+			})
+
+			if err != nil {
+				return apperrors.InvalidRequest("invalid request: " + describe(err))
+			}
+
+			return nextHandler(c)
+		}
+	}
+}
+
+/**
+ * Checks that every versioned route is documented and every documented operation has a route.
+ * @param doc: *openapi3.T
+ * @param routes: []*echo.Route
+ * @return error
+ */
+func CheckRoutes(doc *openapi3.T, routes []*echo.Route) error {
+	registered := map[string]bool{}
+
+	for _, route := range routes {
+		if route.Method == echo.RouteNotFound || !versionedPath.MatchString(route.Path) {
+			continue
+		}
+
+		registered[route.Method+" "+pathTemplate(route.Path)] = true
+	}
+
+	documented := map[string]bool{}
+
+	for path, path_item := range doc.Paths.Map() {
+		for method := range path_item.Operations() {
+			documented[method+" "+path] = true
+		}
+	}
+
+	problems := []string{}
+
+	for operation := range registered {
+		if !documented[operation] {
+			problems = append(problems, operation+" is not documented")
+		}
+	}
+
+	for operation := range documented {
+		if !registered[operation] {
+			problems = append(problems, operation+" is documented but has no route")
+		}
+	}
+
+	if len(problems) > 0 {
+		slices.Sort(problems)
+		return fmt.Errorf("Routes do not match the OpenAPI document: %s", strings.Join(problems, "; "))
+	}
+
+	return nil
+}
+
+// pathTemplate turns an echo path, /invoices/:invoice_id, into an OpenAPI path
+// template, /invoices/{invoice_id}.
+func pathTemplate(path string) string {
+	segments := strings.Split(path, "/")
+
+	for i, segment := range segments {
+		if name, found := strings.CutPrefix(segment, ":"); found {
+			segments[i] = "{" + name + "}"
+		}
+	}
+
+	return strings.Join(segments, "/")
+}
+
+// describe turns a validation error into a short message without the schema dumps
+// kin-openapi includes.
+func describe(err error) string {
+	var request_err *openapi3filter.RequestError
+
+	if !errors.As(err, &request_err) {
+		return err.Error()
+	}
+
+	where := "request body"
+
+	if request_err.Parameter != nil {
+		where = fmt.Sprintf("%s parameter %q", request_err.Parameter.In, request_err.Parameter.Name)
+	}
+
+	var schema_err *openapi3.SchemaError
+
+	if errors.As(request_err.Err, &schema_err) {
+		if pointer := schema_err.JSONPointer(); len(pointer) > 0 {
+			where += " field " + strings.Join(pointer, ".")
+		}
+
+		return where + ": " + schema_err.Reason
+	}
+
+	if request_err.Reason != "" {
+		return where + ": " + request_err.Reason
+	}
+
+	return where + ": " + request_err.Err.Error()
+}
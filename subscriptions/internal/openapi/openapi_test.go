@@ -0,0 +1,129 @@
+package openapi_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/parbhat-cpp/fuse/subscriptions/internal/handlers"
+	"github.com/parbhat-cpp/fuse/subscriptions/internal/openapi"
+)
+
+func TestLoadValidatesDocument(t *testing.T) {
+	doc, err := openapi.Load()
+
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	if doc.Paths.Len() == 0 {
+		t.Fatal("Load() returned a document without paths")
+	}
+}
+
+func TestRoutesMatchDocument(t *testing.T) {
+	doc, err := openapi.Load()
+
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		separate bool
+	}{
+		{name: "single listener"},
+		{name: "separate internal listener", separate: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := echo.New()
+			internal := e
+
+			if tt.separate {
+				internal = echo.New()
+			}
+
+			// only the route table is checked, so the handlers are never called
+			err := handlers.API{}.Register(e, internal, nil)
+
+			if err != nil {
+				t.Fatalf("Register() error = %v", err)
+			}
+
+			routes := e.Routes()
+
+			if tt.separate {
+				routes = append(routes, internal.Routes()...)
+			}
+
+			err = openapi.CheckRoutes(doc, routes)
+
+			if err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+func TestCheckRoutesReportsDrift(t *testing.T) {
+	doc, err := openapi.Load()
+
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	noop := func(echo.Context) error { return nil }
+
+	tests := []struct {
+		name  string
+		extra func(e *echo.Echo)
+		want  string
+	}{
+		{
+			name:  "undocumented route",
+			extra: func(e *echo.Echo) { e.GET("/v1/undocumented", noop) },
+			want:  "GET /v1/undocumented is not documented",
+		},
+		{
+			name:  "unversioned route is ignored",
+			extra: func(e *echo.Echo) { e.GET("/health", noop) },
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := echo.New()
+
+			err := handlers.API{}.Register(e, e, nil)
+
+			if err != nil {
+				t.Fatalf("Register() error = %v", err)
+			}
+
+			tt.extra(e)
+
+			err = openapi.CheckRoutes(doc, e.Routes())
+
+			if tt.want == "" {
+				if err != nil {
+					t.Fatalf("CheckRoutes() error = %v", err)
+				}
+				return
+			}
+
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("CheckRoutes() error = %v, want it to mention %q", err, tt.want)
+			}
+		})
+	}
+
+	t.Run("documented operation without a route", func(t *testing.T) {
+		err := openapi.CheckRoutes(doc, echo.New().Routes())
+
+		if err == nil || !strings.Contains(err.Error(), "is documented but has no route") {
+			t.Fatalf("CheckRoutes() error = %v, want missing routes reported", err)
+		}
+	})
+}
@@ -5,15 +5,19 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"log/slog"
 	"strings"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/parbhat-cpp/fuse/subscriptions/constants"
+	"github.com/parbhat-cpp/fuse/subscriptions/internal/apperrors"
 	"github.com/parbhat-cpp/fuse/subscriptions/internal/config"
 	"github.com/parbhat-cpp/fuse/subscriptions/internal/db/sqlc"
+	"github.com/parbhat-cpp/fuse/subscriptions/internal/metrics"
 	"github.com/parbhat-cpp/fuse/subscriptions/internal/types"
+	"github.com/parbhat-cpp/fuse/subscriptions/lib"
 	"github.com/parbhat-cpp/fuse/subscriptions/pkg/utils"
 )
 
@@ -21,7 +25,7 @@ type AccessService struct {
 	query *sqlc.Queries
 }
 
-var ErrAccessLimitReached = errors.New("access limit reached")
+var ErrAccessLimitReached = apperrors.New(apperrors.CodeQuotaExceeded, "access limit reached")
 
 func NewAccessService(query *sqlc.Queries) *AccessService {
 	return &AccessService{
@@ -44,11 +48,20 @@ type UsageResponse struct {
 
 /**
  * Checks if the user with the given ID has access to the requested access type based on their subscription plan.
+ * @param ctx: context.Context
  * @param user_id: uuid.UUID
  * @param access_request: constants.AccessType
  * @return AccessResponse, error
  */
-func (s *AccessService) HandleAccessRequest(user_id uuid.UUID, access_request constants.AccessType) (*AccessResponse, error) {
+func (s *AccessService) HandleAccessRequest(ctx context.Context, user_id uuid.UUID, access_request constants.AccessType) (*AccessResponse, error) {
+	res, err := s.handleAccessRequest(ctx, user_id, access_request)
+
+	recordAccessDecision(access_request, res, err)
+
+	return res, err
+}
+
+func (s *AccessService) handleAccessRequest(ctx context.Context, user_id uuid.UUID, access_request constants.AccessType) (*AccessResponse, error) {
 	/*
 	 * 1. when user is new, no rows in subscription and subscription_usage table
 	 * - create subscription_usage record with default values (free plan)
@@ -62,9 +75,18 @@ func (s *AccessService) HandleAccessRequest(user_id uuid.UUID, access_request co
 
 	var user_uuid pgtype.UUID = utils.ConvertGoogleUUIDToPgtypeUUID(user_id)
 
-	// returns latest user subscription
-	user_subscription, sub_err := s.query.GetSubscriptionByUserID(context.Background(), user_uuid)
-	user_usage, usage_err := s.query.GetSubscriptionUsageByID(context.Background(), user_uuid)
+	// returns the user's current subscription (trialing, active, past_due or canceled within its period)
+	user_subscription, sub_err := s.query.GetActiveSubscriptionByUserID(ctx, user_uuid)
+	user_usage, usage_err := s.query.GetSubscriptionUsageByID(ctx, user_uuid)
+
+	if sub_err == nil {
+		// quota of a paid plan is tracked on the usage row of that subscription
+		sub_usage, err := s.query.GetSubscriptionUsageBySubscriptionID(ctx, user_subscription.ID)
+
+		if err == nil {
+			user_usage, usage_err = sqlc.GetSubscriptionUsageByIDRow(sub_usage), nil
+		}
+	}
 
 	plan_expired := user_usage.ID.Valid && user_usage.ValidUntil.Valid && user_usage.ValidUntil.Time.Before(time.Now())
 
@@ -88,7 +110,7 @@ func (s *AccessService) HandleAccessRequest(user_id uuid.UUID, access_request co
 			limit = freePlan.FeaturesJson["room_schedule_limit"] - 1
 		}
 
-		new_sub_usage_row, err := s.query.CreateSubscriptionUsage(context.Background(), sqlc.CreateSubscriptionUsageParams{
+		new_sub_usage_row, err := s.query.CreateSubscriptionUsage(ctx, sqlc.CreateSubscriptionUsageParams{
 			UserID: user_uuid,
 			ValidFrom: pgtype.Timestamptz{
 				Time:  time.Now(),
@@ -118,7 +140,7 @@ func (s *AccessService) HandleAccessRequest(user_id uuid.UUID, access_request co
 
 		if access_request == constants.AccessTypeJoinRoom {
 			if usage.PublicRoomQuota >= basicPlan.FeaturesJson["public_room_join_limit"] {
-				return &AccessResponse{Plan: &basicPlan, IsAllowed: false, LimitLeft: 0, PlanExpired: false}, fmt.Errorf("%w: Public Room Joining Quota is exhausted", ErrAccessLimitReached)
+				return &AccessResponse{Plan: &basicPlan, IsAllowed: false, LimitLeft: 0, PlanExpired: false}, ErrAccessLimitReached.Withf("Public Room Joining Quota is exhausted")
 			} else {
 				usage.PublicRoomQuota = usage.PublicRoomQuota + 1
 				limit := basicPlan.FeaturesJson["public_room_join_limit"] - usage.PublicRoomQuota
@@ -128,7 +150,9 @@ func (s *AccessService) HandleAccessRequest(user_id uuid.UUID, access_request co
 					return &AccessResponse{Plan: &basicPlan, IsAllowed: false, LimitLeft: 0, PlanExpired: false}, fmt.Errorf("Cannot create json bytes %s", err)
 				}
 
-				s.query.UpdateSubscriptionUsage(context.Background(), sqlc.UpdateSubscriptionUsageParams{ID: user_usage.ID, UserID: user_uuid, Column3: string(usage_json_byte)})
+				s.query.UpdateSubscriptionUsage(ctx, sqlc.UpdateSubscriptionUsageParams{ID: user_usage.ID, UserID: user_uuid, Column3: string(usage_json_byte)})
+
+				s.warnQuota(ctx, user_uuid, user_usage.SubscriptionID, basicPlan, access_request, usage.PublicRoomQuota, basicPlan.FeaturesJson["public_room_join_limit"])
 
 				return &AccessResponse{Plan: &basicPlan, IsAllowed: true, LimitLeft: limit, PlanExpired: false}, nil
 			}
@@ -136,7 +160,7 @@ func (s *AccessService) HandleAccessRequest(user_id uuid.UUID, access_request co
 
 		if access_request == constants.AccessTypeSchedule {
 			if usage.RoomSchedulingQuota >= basicPlan.FeaturesJson["room_schedule_limit"] {
-				return &AccessResponse{Plan: &basicPlan, IsAllowed: false, LimitLeft: 0, PlanExpired: false}, fmt.Errorf("%w: Scheduling Room Quota is exhausted", ErrAccessLimitReached)
+				return &AccessResponse{Plan: &basicPlan, IsAllowed: false, LimitLeft: 0, PlanExpired: false}, ErrAccessLimitReached.Withf("Scheduling Room Quota is exhausted")
 			} else {
 				usage.RoomSchedulingQuota = usage.RoomSchedulingQuota + 1
 				limit := basicPlan.FeaturesJson["room_schedule_limit"] - usage.RoomSchedulingQuota
@@ -145,7 +169,9 @@ func (s *AccessService) HandleAccessRequest(user_id uuid.UUID, access_request co
 				if err != nil {
 					return &AccessResponse{Plan: &basicPlan, IsAllowed: false, LimitLeft: 0, PlanExpired: false}, fmt.Errorf("Cannot convert maps to bytes %s", err)
 				}
-				s.query.UpdateSubscriptionUsage(context.Background(), sqlc.UpdateSubscriptionUsageParams{ID: user_usage.ID, UserID: user_uuid, Column3: string(usage_json_byte)})
+				s.query.UpdateSubscriptionUsage(ctx, sqlc.UpdateSubscriptionUsageParams{ID: user_usage.ID, UserID: user_uuid, Column3: string(usage_json_byte)})
+
+				s.warnQuota(ctx, user_uuid, user_usage.SubscriptionID, basicPlan, access_request, usage.RoomSchedulingQuota, basicPlan.FeaturesJson["room_schedule_limit"])
 
 				return &AccessResponse{Plan: &basicPlan, IsAllowed: true, LimitLeft: limit, PlanExpired: false}, nil
 			}
@@ -158,7 +184,7 @@ func (s *AccessService) HandleAccessRequest(user_id uuid.UUID, access_request co
 
 		if access_request == constants.AccessTypeJoinRoom {
 			if usage.PublicRoomQuota >= proPlan.FeaturesJson["public_room_join_limit"] {
-				return &AccessResponse{Plan: &proPlan, IsAllowed: false, LimitLeft: 0, PlanExpired: false}, fmt.Errorf("%w: Public Room Joining Quota is exhausted", ErrAccessLimitReached)
+				return &AccessResponse{Plan: &proPlan, IsAllowed: false, LimitLeft: 0, PlanExpired: false}, ErrAccessLimitReached.Withf("Public Room Joining Quota is exhausted")
 			} else {
 				usage.PublicRoomQuota = usage.PublicRoomQuota + 1
 				limit := proPlan.FeaturesJson["public_room_join_limit"] - usage.PublicRoomQuota
@@ -167,7 +193,9 @@ func (s *AccessService) HandleAccessRequest(user_id uuid.UUID, access_request co
 				if err != nil {
 					return &AccessResponse{Plan: &proPlan, IsAllowed: false, LimitLeft: 0, PlanExpired: false}, fmt.Errorf("Cannot convert maps to byte %s", err)
 				}
-				s.query.UpdateSubscriptionUsage(context.Background(), sqlc.UpdateSubscriptionUsageParams{ID: user_usage.ID, UserID: user_uuid, Column3: string(usage_json_byte)})
+				s.query.UpdateSubscriptionUsage(ctx, sqlc.UpdateSubscriptionUsageParams{ID: user_usage.ID, UserID: user_uuid, Column3: string(usage_json_byte)})
+
+				s.warnQuota(ctx, user_uuid, user_usage.SubscriptionID, proPlan, access_request, usage.PublicRoomQuota, proPlan.FeaturesJson["public_room_join_limit"])
 
 				return &AccessResponse{Plan: &proPlan, IsAllowed: true, LimitLeft: limit, PlanExpired: false}, nil
 			}
@@ -175,7 +203,7 @@ func (s *AccessService) HandleAccessRequest(user_id uuid.UUID, access_request co
 
 		if access_request == constants.AccessTypeSchedule {
 			if usage.RoomSchedulingQuota >= proPlan.FeaturesJson["room_schedule_limit"] {
-				return &AccessResponse{Plan: &proPlan, IsAllowed: false, LimitLeft: 0, PlanExpired: false}, fmt.Errorf("%w: Scheduling Room Quota is exhausted", ErrAccessLimitReached)
+				return &AccessResponse{Plan: &proPlan, IsAllowed: false, LimitLeft: 0, PlanExpired: false}, ErrAccessLimitReached.Withf("Scheduling Room Quota is exhausted")
 			} else {
 				usage.RoomSchedulingQuota = usage.RoomSchedulingQuota + 1
 				limit := proPlan.FeaturesJson["room_schedule_limit"] - usage.RoomSchedulingQuota
@@ -185,7 +213,9 @@ func (s *AccessService) HandleAccessRequest(user_id uuid.UUID, access_request co
 					return &AccessResponse{Plan: &proPlan, IsAllowed: false, LimitLeft: 0, PlanExpired: false}, fmt.Errorf("Failed to convert usage map to bytes %s", err)
 				}
 
-				s.query.UpdateSubscriptionUsage(context.Background(), sqlc.UpdateSubscriptionUsageParams{ID: user_usage.ID, UserID: user_uuid, Column3: string(usage_json_byte)})
+				s.query.UpdateSubscriptionUsage(ctx, sqlc.UpdateSubscriptionUsageParams{ID: user_usage.ID, UserID: user_uuid, Column3: string(usage_json_byte)})
+
+				s.warnQuota(ctx, user_uuid, user_usage.SubscriptionID, proPlan, access_request, usage.RoomSchedulingQuota, proPlan.FeaturesJson["room_schedule_limit"])
 
 				return &AccessResponse{Plan: &proPlan, IsAllowed: true, LimitLeft: limit, PlanExpired: false}, nil
 			}
@@ -201,7 +231,7 @@ func (s *AccessService) HandleAccessRequest(user_id uuid.UUID, access_request co
 
 	if access_request == constants.AccessTypeJoinRoom {
 		if usage.PublicRoomQuota >= freePlan.FeaturesJson["public_room_join_limit"] {
-			return &AccessResponse{Plan: &freePlan, IsAllowed: false, LimitLeft: 0, PlanExpired: false}, fmt.Errorf("%w: Public Room Joining Quota is exhausted", ErrAccessLimitReached)
+			return &AccessResponse{Plan: &freePlan, IsAllowed: false, LimitLeft: 0, PlanExpired: false}, ErrAccessLimitReached.Withf("Public Room Joining Quota is exhausted")
 		} else {
 			usage.PublicRoomQuota = usage.PublicRoomQuota + 1
 			limit := freePlan.FeaturesJson["public_room_join_limit"] - usage.PublicRoomQuota
@@ -211,19 +241,21 @@ func (s *AccessService) HandleAccessRequest(user_id uuid.UUID, access_request co
 			if err != nil {
 				return &AccessResponse{Plan: &freePlan, IsAllowed: false, LimitLeft: 0, PlanExpired: false}, fmt.Errorf("Cannot convert maps to byte %s", err)
 			}
-			updated_row, err := s.query.UpdateSubscriptionUsage(context.Background(), sqlc.UpdateSubscriptionUsageParams{ID: user_usage.ID, UserID: user_uuid, Column3: string(usage_json_byte)})
+			updated_row, err := s.query.UpdateSubscriptionUsage(ctx, sqlc.UpdateSubscriptionUsageParams{ID: user_usage.ID, UserID: user_uuid, Column3: string(usage_json_byte)})
 
 			if err != nil {
 				return &AccessResponse{Plan: &freePlan, IsAllowed: false, LimitLeft: 0, PlanExpired: false}, fmt.Errorf("Failed to update subscription usage %s", err)
 			}
 
+			s.warnQuota(ctx, user_uuid, user_usage.SubscriptionID, freePlan, access_request, usage.PublicRoomQuota, freePlan.FeaturesJson["public_room_join_limit"])
+
 			return &AccessResponse{Plan: &freePlan, PlanUsage: updated_row, IsAllowed: true, LimitLeft: limit, PlanExpired: false}, nil
 		}
 	}
 
 	if access_request == constants.AccessTypeSchedule {
 		if usage.RoomSchedulingQuota >= freePlan.FeaturesJson["room_schedule_limit"] {
-			return &AccessResponse{Plan: &freePlan, IsAllowed: false, LimitLeft: 0, PlanExpired: false}, fmt.Errorf("%w: Scheduling Room Quota is exhausted", ErrAccessLimitReached)
+			return &AccessResponse{Plan: &freePlan, IsAllowed: false, LimitLeft: 0, PlanExpired: false}, ErrAccessLimitReached.Withf("Scheduling Room Quota is exhausted")
 		} else {
 			usage.RoomSchedulingQuota = usage.RoomSchedulingQuota + 1
 			limit := freePlan.FeaturesJson["room_schedule_limit"] - usage.RoomSchedulingQuota
@@ -233,15 +265,64 @@ func (s *AccessService) HandleAccessRequest(user_id uuid.UUID, access_request co
 				return &AccessResponse{Plan: &freePlan, IsAllowed: false, LimitLeft: 0, PlanExpired: false}, fmt.Errorf("Failed to convert usage map to bytes %s", err)
 			}
 
-			updated_row, err := s.query.UpdateSubscriptionUsage(context.Background(), sqlc.UpdateSubscriptionUsageParams{ID: user_usage.ID, UserID: user_uuid, Column3: string(usage_json_byte)})
+			updated_row, err := s.query.UpdateSubscriptionUsage(ctx, sqlc.UpdateSubscriptionUsageParams{ID: user_usage.ID, UserID: user_uuid, Column3: string(usage_json_byte)})
 
 			if err != nil {
 				return &AccessResponse{Plan: &freePlan, IsAllowed: false, LimitLeft: 0, PlanExpired: false}, fmt.Errorf("Failed to update subscription usage %s", err)
 			}
 
+			s.warnQuota(ctx, user_uuid, user_usage.SubscriptionID, freePlan, access_request, usage.RoomSchedulingQuota, freePlan.FeaturesJson["room_schedule_limit"])
+
 			return &AccessResponse{Plan: &freePlan, PlanUsage: updated_row, IsAllowed: true, LimitLeft: limit, PlanExpired: false}, nil
 		}
 	}
 
 	return &AccessResponse{Plan: &constants.Plan{}, IsAllowed: false, LimitLeft: 0, PlanExpired: false}, fmt.Errorf("Invalid access request type")
 }
+
+// warnQuota queues a quota warning on the use that reaches QUOTA_WARNING_PERCENT of
+// limit. Usage grows one at a time, so it is sent once per period. The usage update it
+// follows runs outside a transaction, so the warning is queued on its own as well.
+func (s *AccessService) warnQuota(ctx context.Context, user_id pgtype.UUID, subscription_id pgtype.UUID, plan constants.Plan, access_request constants.AccessType, used int, limit int) {
+	cfg := config.LoadEnv()
+
+	if limit <= 0 || cfg.QUOTA_WARNING_PERCENT <= 0 {
+		return
+	}
+
+	// the first use at or past the percentage, rounded up
+	threshold := (limit*cfg.QUOTA_WARNING_PERCENT + 99) / 100
+
+	if used != threshold {
+		return
+	}
+
+	err := enqueueNotification(ctx, s.query, user_id, subscription_id, lib.QuotaWarningEvent{
+		PlanType: plan.Name,
+		Feature:  string(access_request),
+		Used:     used,
+		Limit:    limit,
+	})
+
+	if err != nil {
+		slog.ErrorContext(ctx, "Unable to queue quota warning", "user_id", uuid.UUID(user_id.Bytes), "feature", access_request, "error", err)
+	}
+}
+
+func recordAccessDecision(access_request constants.AccessType, res *AccessResponse, err error) {
+	plan := "unknown"
+
+	if res != nil && res.Plan != nil && res.Plan.Name != "" {
+		plan = strings.ToLower(res.Plan.Name)
+	}
+
+	outcome := metrics.AccessAllowed
+
+	if errors.Is(err, ErrAccessLimitReached) || (err == nil && (res == nil || !res.IsAllowed)) {
+		outcome = metrics.AccessDenied
+	} else if err != nil {
+		outcome = metrics.AccessError
+	}
+
+	metrics.AccessDecisions.WithLabelValues(string(access_request), plan, outcome).Inc()
+}
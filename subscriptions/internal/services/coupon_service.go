@@ -0,0 +1,267 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/parbhat-cpp/fuse/subscriptions/constants"
+	"github.com/parbhat-cpp/fuse/subscriptions/internal/apperrors"
+	"github.com/parbhat-cpp/fuse/subscriptions/internal/db/sqlc"
+	"github.com/parbhat-cpp/fuse/subscriptions/pkg/utils"
+)
+
+type CouponService struct {
+	query *sqlc.Queries
+}
+
+var ErrInvalidCoupon = apperrors.New(apperrors.CodeInvalidRequest, "invalid coupon")
+var ErrCouponNotFound = apperrors.New(apperrors.CodeNotFound, "coupon not found")
+var ErrCouponExhausted = apperrors.New(apperrors.CodeConflict, "coupon redemption limit reached")
+
+func NewCouponService(query *sqlc.Queries) *CouponService {
+	return &CouponService{
+		query: query,
+	}
+}
+
+type CreateCouponRequest struct {
+	Code           string     `json:"code"`
+	DiscountType   string     `json:"discount_type"`
+	DiscountValue  int64      `json:"discount_value"` // percentage, or minor units of currency for fixed discounts
+	Currency       string     `json:"currency"`
+	ValidFrom      *time.Time `json:"valid_from"`
+	ExpiresAt      *time.Time `json:"expires_at"`
+	MaxRedemptions *int32     `json:"max_redemptions"`
+	PerUserLimit   *int32     `json:"per_user_limit"`
+	PlanTypes      []string   `json:"plan_types"`
+}
+
+type CouponRedemptionsResponse struct {
+	Coupon      sqlc.Coupon
+	Redemptions []sqlc.CouponRedemption
+}
+
+/**
+ * Creates a new promo code.
+ * @param ctx: context.Context
+ * @param req: CreateCouponRequest
+ * @return sqlc.Coupon, error
+ */
+func (s *CouponService) CreateCoupon(ctx context.Context, req CreateCouponRequest) (sqlc.Coupon, error) {
+	code := strings.ToUpper(strings.TrimSpace(req.Code))
+	discount_type := constants.CouponDiscountType(req.DiscountType)
+
+	if code == "" {
+		return sqlc.Coupon{}, ErrInvalidCoupon.Withf("code is required")
+	}
+
+	if discount_type != constants.CouponDiscountPercentage && discount_type != constants.CouponDiscountFixed {
+		return sqlc.Coupon{}, ErrInvalidCoupon.Withf("invalid discount_type")
+	}
+
+	if req.DiscountValue <= 0 || (discount_type == constants.CouponDiscountPercentage && req.DiscountValue > 100) {
+		return sqlc.Coupon{}, ErrInvalidCoupon.Withf("invalid discount_value")
+	}
+
+	currency := constants.DefaultCurrency
+
+	if req.Currency != "" {
+		parsed, err := constants.ParseCurrency(req.Currency)
+
+		if err != nil {
+			return sqlc.Coupon{}, ErrInvalidCoupon.Withf("%v", err)
+		}
+
+		currency = parsed
+	}
+
+	for _, plan_type := range req.PlanTypes {
+		if _, exists := constants.GetPlans()[plan_type]; !exists {
+			return sqlc.Coupon{}, ErrInvalidCoupon.Withf("unknown plan type %s", plan_type)
+		}
+	}
+
+	params := sqlc.CreateCouponParams{
+		Code:          code,
+		DiscountType:  string(discount_type),
+		DiscountValue: utils.ConvertInt64ToPgtypeNumeric(req.DiscountValue),
+		ValidFrom:     pgtype.Timestamptz{Time: time.Now(), Valid: true},
+		PerUserLimit:  1,
+		PlanTypes:     []string{},
+		Currency:      string(currency),
+	}
+
+	if req.ValidFrom != nil {
+		params.ValidFrom = pgtype.Timestamptz{Time: *req.ValidFrom, Valid: true}
+	}
+	if req.ExpiresAt != nil {
+		params.ExpiresAt = pgtype.Timestamptz{Time: *req.ExpiresAt, Valid: true}
+	}
+	if req.MaxRedemptions != nil {
+		params.MaxRedemptions = pgtype.Int4{Int32: *req.MaxRedemptions, Valid: true}
+	}
+	if req.PerUserLimit != nil {
+		params.PerUserLimit = *req.PerUserLimit
+	}
+	if req.PlanTypes != nil {
+		params.PlanTypes = req.PlanTypes
+	}
+
+	coupon, err := s.query.CreateCoupon(ctx, params)
+
+	if err != nil {
+		return sqlc.Coupon{}, fmt.Errorf("Unable to create coupon")
+	}
+
+	return coupon, nil
+}
+
+/**
+ * Returns every coupon along with its redemption count and total discount given.
+ * @param ctx: context.Context
+ * @return []sqlc.GetCouponReportRow, error
+ */
+func (s *CouponService) GetCouponReport(ctx context.Context) ([]sqlc.GetCouponReportRow, error) {
+	return s.query.GetCouponReport(ctx)
+}
+
+/**
+ * Returns the redemptions recorded for a coupon code.
+ * @param ctx: context.Context
+ * @param code: string
+ * @return CouponRedemptionsResponse, error
+ */
+func (s *CouponService) GetCouponRedemptions(ctx context.Context, code string) (CouponRedemptionsResponse, error) {
+	coupon, err := s.query.GetCouponByCode(ctx, strings.ToUpper(strings.TrimSpace(code)))
+
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return CouponRedemptionsResponse{}, ErrCouponNotFound
+		}
+		return CouponRedemptionsResponse{}, err
+	}
+
+	redemptions, err := s.query.GetCouponRedemptionsByCouponID(ctx, coupon.ID)
+
+	if err != nil {
+		return CouponRedemptionsResponse{}, err
+	}
+
+	return CouponRedemptionsResponse{Coupon: coupon, Redemptions: redemptions}, nil
+}
+
+// applyCoupon validates a promo code for the user and plan and returns the coupon with
+// the discount it gives on amount (in the currency's minor unit). Fixed discounts only
+// apply to orders in the coupon's currency. The discounted amount never goes below the
+// minimum order amount.
+func applyCoupon(ctx context.Context, query *sqlc.Queries, code string, user_id pgtype.UUID, plan_type string, currency constants.Currency, amount int64) (sqlc.Coupon, int64, error) {
+	coupon, err := query.GetCouponByCode(ctx, strings.ToUpper(strings.TrimSpace(code)))
+
+	if err != nil {
+		return sqlc.Coupon{}, 0, ErrInvalidCoupon.Withf("code does not exist")
+	}
+
+	now := time.Now()
+
+	if !coupon.IsActive || now.Before(coupon.ValidFrom.Time) || (coupon.ExpiresAt.Valid && !now.Before(coupon.ExpiresAt.Time)) {
+		return sqlc.Coupon{}, 0, ErrInvalidCoupon.Withf("code is not active")
+	}
+
+	if len(coupon.PlanTypes) > 0 && !slices.Contains(coupon.PlanTypes, plan_type) {
+		return sqlc.Coupon{}, 0, ErrInvalidCoupon.Withf("code is not valid for this plan")
+	}
+
+	if constants.CouponDiscountType(coupon.DiscountType) == constants.CouponDiscountFixed && constants.Currency(coupon.Currency) != currency {
+		return sqlc.Coupon{}, 0, ErrInvalidCoupon.Withf("code is only valid for %s payments", coupon.Currency)
+	}
+
+	if coupon.MaxRedemptions.Valid {
+		redemptions, err := query.CountCouponRedemptions(ctx, coupon.ID)
+
+		if err != nil {
+			return sqlc.Coupon{}, 0, err
+		}
+
+		if redemptions >= int64(coupon.MaxRedemptions.Int32) {
+			return sqlc.Coupon{}, 0, ErrInvalidCoupon.Withf("code has been fully redeemed")
+		}
+	}
+
+	user_redemptions, err := query.CountCouponRedemptionsByUserID(ctx, sqlc.CountCouponRedemptionsByUserIDParams{
+		CouponID: coupon.ID,
+		UserID:   user_id,
+	})
+
+	if err != nil {
+		return sqlc.Coupon{}, 0, err
+	}
+
+	if user_redemptions >= int64(coupon.PerUserLimit) {
+		return sqlc.Coupon{}, 0, ErrInvalidCoupon.Withf("code already used")
+	}
+
+	discount_value := utils.ConvertPgtypeNumericToInt64(coupon.DiscountValue)
+	var discount int64
+
+	if constants.CouponDiscountType(coupon.DiscountType) == constants.CouponDiscountPercentage {
+		discount = amount * discount_value / 100
+	} else {
+		discount = discount_value
+	}
+
+	if amount-discount < constants.MinimumOrderAmount {
+		discount = max(amount-constants.MinimumOrderAmount, 0)
+	}
+
+	return coupon, discount, nil
+}
+
+// redeemCoupon records the redemption of a coupon applied to an order. Several orders
+// can be open on the same coupon, so its caps are checked again with the coupon row
+// locked until the caller's transaction ends.
+func redeemCoupon(ctx context.Context, qtx *sqlc.Queries, order sqlc.PaymentOrder, user_id pgtype.UUID, subscription_id pgtype.UUID) error {
+	coupon, err := qtx.LockCouponByID(ctx, order.CouponID)
+
+	if err != nil {
+		return err
+	}
+
+	if coupon.MaxRedemptions.Valid {
+		redemptions, err := qtx.CountCouponRedemptions(ctx, coupon.ID)
+
+		if err != nil {
+			return err
+		}
+
+		if redemptions >= int64(coupon.MaxRedemptions.Int32) {
+			return ErrCouponExhausted.Withf("code has been fully redeemed")
+		}
+	}
+
+	user_redemptions, err := qtx.CountCouponRedemptionsByUserID(ctx, sqlc.CountCouponRedemptionsByUserIDParams{
+		CouponID: coupon.ID,
+		UserID:   user_id,
+	})
+
+	if err != nil {
+		return err
+	}
+
+	if user_redemptions >= int64(coupon.PerUserLimit) {
+		return ErrCouponExhausted.Withf("code already used")
+	}
+
+	return qtx.CreateCouponRedemption(ctx, sqlc.CreateCouponRedemptionParams{
+		CouponID:       coupon.ID,
+		UserID:         user_id,
+		SubscriptionID: subscription_id,
+		PaymentOrderID: order.ID,
+		DiscountAmount: order.DiscountAmount,
+	})
+}
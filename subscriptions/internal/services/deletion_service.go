@@ -4,7 +4,7 @@ import (
 	"context"
 	"database/sql"
 	"errors"
-	"log"
+	"log/slog"
 
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5/pgxpool"
@@ -24,8 +24,8 @@ func NewDeletionService(query *sqlc.Queries, pool *pgxpool.Pool) *DeletionServic
 	}
 }
 
-func (s *DeletionService) DeleteUserData(user_id uuid.UUID) error {
-	tx, err := s.pool.Begin(context.Background())
+func (s *DeletionService) DeleteUserData(ctx context.Context, user_id uuid.UUID) error {
+	tx, err := s.pool.Begin(ctx)
 	user_id_pg := utils.ConvertGoogleUUIDToPgtypeUUID(user_id)
 
 	if err != nil {
@@ -33,39 +33,106 @@ func (s *DeletionService) DeleteUserData(user_id uuid.UUID) error {
 	}
 
 	qtx := s.query.WithTx(tx)
-	defer tx.Rollback(context.Background())
+	defer tx.Rollback(ctx)
 
-	_, err = qtx.RemoveRefundByUserID(context.Background(), user_id_pg)
+	logger := slog.With("user_id", user_id)
+
+	_, err = qtx.RemoveRefundByUserID(ctx, user_id_pg)
 
 	if err != nil {
 		if !errors.Is(err, sql.ErrNoRows) {
-			log.Printf("Error deleting refunds for user %s: %v", user_id, err)
+			logger.ErrorContext(ctx, "Error deleting refunds", "error", err)
 			return err
 		}
 	}
 
-	_, err = qtx.RemoveSubscriptionByUserID(context.Background(), user_id_pg)
+	// trials are kept with only a hash of the user id, so the user cannot start another
+	err = qtx.AnonymiseTrialsByUserID(ctx, user_id_pg)
+
+	if err != nil {
+		logger.ErrorContext(ctx, "Error anonymising trials", "error", err)
+		return err
+	}
+
+	_, err = qtx.RemoveSubscriptionByUserID(ctx, user_id_pg)
 
 	if err != nil {
 		if !errors.Is(err, sql.ErrNoRows) {
-			log.Printf("Error deleting subscriptions for user %s: %v", user_id, err)
+			logger.ErrorContext(ctx, "Error deleting subscriptions", "error", err)
 			return err
 		}
 	}
 
-	_, err = qtx.RemoveSubscriptionUsageByUserID(context.Background(), user_id_pg)
+	err = qtx.UnlinkCouponRedemptionsByUserID(ctx, user_id_pg)
+
+	if err != nil {
+		logger.ErrorContext(ctx, "Error unlinking coupon redemptions", "error", err)
+		return err
+	}
+
+	err = qtx.UnlinkSubscriptionEventsByUserID(ctx, user_id_pg)
+
+	if err != nil {
+		logger.ErrorContext(ctx, "Error unlinking subscription events", "error", err)
+		return err
+	}
+
+	err = qtx.RemovePlanChangesByUserID(ctx, user_id_pg)
+
+	if err != nil {
+		logger.ErrorContext(ctx, "Error deleting plan changes", "error", err)
+		return err
+	}
+
+	// invoices and credit notes are kept for tax records, only the link to the user is removed
+	err = qtx.UnlinkInvoicesByUserID(ctx, user_id_pg)
+
+	if err != nil {
+		logger.ErrorContext(ctx, "Error unlinking invoices", "error", err)
+		return err
+	}
+
+	err = qtx.UnlinkCreditNotesByUserID(ctx, user_id_pg)
+
+	if err != nil {
+		logger.ErrorContext(ctx, "Error unlinking credit notes", "error", err)
+		return err
+	}
+
+	err = qtx.UnlinkPaymentAttemptsByUserID(ctx, user_id_pg)
+
+	if err != nil {
+		logger.ErrorContext(ctx, "Error unlinking payment attempts", "error", err)
+		return err
+	}
+
+	err = qtx.RemoveOutboxEventsByUserID(ctx, user_id_pg)
+
+	if err != nil {
+		logger.ErrorContext(ctx, "Error deleting outbox events", "error", err)
+		return err
+	}
+
+	err = qtx.RemovePaymentOrdersByUserID(ctx, user_id_pg)
+
+	if err != nil {
+		logger.ErrorContext(ctx, "Error deleting payment orders", "error", err)
+		return err
+	}
+
+	_, err = qtx.RemoveSubscriptionUsageByUserID(ctx, user_id_pg)
 
 	if err != nil {
 		if !errors.Is(err, sql.ErrNoRows) {
-			log.Printf("Error deleting subscription usage for user %s: %v", user_id, err)
+			logger.ErrorContext(ctx, "Error deleting subscription usage", "error", err)
 			return err
 		}
 	}
 
-	err = tx.Commit(context.Background())
+	err = tx.Commit(ctx)
 
 	if err != nil {
-		log.Printf("Error committing transaction for user %s: %v", user_id, err)
+		logger.ErrorContext(ctx, "Error committing transaction", "error", err)
 		return err
 	}
 
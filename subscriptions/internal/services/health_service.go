@@ -0,0 +1,123 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/parbhat-cpp/fuse/subscriptions/constants"
+	"github.com/parbhat-cpp/fuse/subscriptions/internal/config"
+)
+
+const (
+	HealthStatusOK   = "ok"
+	HealthStatusFail = "fail"
+)
+
+// each readiness check gets this long, so a hung database fails the probe instead of
+// timing it out
+const healthCheckTimeout = 2 * time.Second
+
+// HealthCheck is only the outcome of a check. Readiness may be served publicly, so why a
+// check failed is logged rather than returned.
+type HealthCheck struct {
+	Status string `json:"status"`
+}
+
+type HealthReport struct {
+	Status string                 `json:"status"`
+	Checks map[string]HealthCheck `json:"checks,omitempty"`
+}
+
+// Healthy reports whether every check passed.
+func (r HealthReport) Healthy() bool {
+	return r.Status == HealthStatusOK
+}
+
+type HealthService struct {
+	pool *pgxpool.Pool
+}
+
+func NewHealthService(pool *pgxpool.Pool) *HealthService {
+	return &HealthService{
+		pool: pool,
+	}
+}
+
+/**
+ * Reports that the process is up and serving requests. It checks nothing else, so a
+ * database outage does not get the service restarted.
+ * @return HealthReport
+ */
+func (s *HealthService) Liveness() HealthReport {
+	return HealthReport{Status: HealthStatusOK}
+}
+
+/**
+ * Reports whether the service can handle traffic: the database is reachable, it has
+ * been migrated to the schema this build expects and the config is valid.
+ * @param ctx: context.Context
+ * @return HealthReport
+ */
+func (s *HealthService) Readiness(ctx context.Context) HealthReport {
+	report := HealthReport{
+		Status: HealthStatusOK,
+		Checks: map[string]HealthCheck{},
+	}
+
+	checks := map[string]func(context.Context) error{
+		"database":   s.checkDatabase,
+		"migrations": s.checkMigrations,
+		"config":     checkConfig,
+	}
+
+	for name, check := range checks {
+		check_ctx, cancel := context.WithTimeout(ctx, healthCheckTimeout)
+		err := check(check_ctx)
+		cancel()
+
+		if err != nil {
+			slog.WarnContext(ctx, "Readiness check failed", "check", name, "error", err)
+			report.Status = HealthStatusFail
+			report.Checks[name] = HealthCheck{Status: HealthStatusFail}
+			continue
+		}
+
+		report.Checks[name] = HealthCheck{Status: HealthStatusOK}
+	}
+
+	return report
+}
+
+func (s *HealthService) checkDatabase(ctx context.Context) error {
+	return s.pool.Ping(ctx)
+}
+
+// checkMigrations reads the version golang-migrate records. The table is not part of
+// the sqlc schema, so it is queried directly.
+func (s *HealthService) checkMigrations(ctx context.Context) error {
+	var version int64
+	var dirty bool
+
+	err := s.pool.QueryRow(ctx, "SELECT version, dirty FROM schema_migrations LIMIT 1").Scan(&version, &dirty)
+
+	if err != nil {
+		return fmt.Errorf("Unable to read the schema version: %w", err)
+	}
+
+	if dirty {
+		return fmt.Errorf("Migration %d failed and left the schema dirty", version)
+	}
+
+	if version < constants.SchemaVersion {
+		return fmt.Errorf("Schema version is %d, expected at least %d", version, constants.SchemaVersion)
+	}
+
+	return nil
+}
+
+func checkConfig(_ context.Context) error {
+	return config.LoadEnv().Validate()
+}
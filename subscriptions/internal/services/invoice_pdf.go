@@ -0,0 +1,204 @@
+package services
+
+import (
+	"bytes"
+	"fmt"
+	"time"
+
+	"github.com/go-pdf/fpdf"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/parbhat-cpp/fuse/subscriptions/constants"
+	"github.com/parbhat-cpp/fuse/subscriptions/internal/config"
+	"github.com/parbhat-cpp/fuse/subscriptions/internal/db/sqlc"
+	"github.com/parbhat-cpp/fuse/subscriptions/pkg/utils"
+)
+
+// taxDocument holds what is printed on an invoice or a credit note.
+type taxDocument struct {
+	title          string
+	number_label   string
+	number         string
+	issued_at      time.Time
+	reference      [2]string // label and value, e.g. the invoice a credit note reverses
+	invoice        sqlc.Invoice
+	currency       string
+	tax_rate       pgtype.Numeric
+	taxable_amount pgtype.Numeric
+	cgst_amount    pgtype.Numeric
+	sgst_amount    pgtype.Numeric
+	igst_amount    pgtype.Numeric
+	total_amount   pgtype.Numeric
+}
+
+// renderInvoicePDF lays out an invoice on a single A4 page.
+func renderInvoicePDF(invoice sqlc.Invoice) ([]byte, error) {
+	title := "Invoice"
+
+	if invoice.PlaceOfSupply.Valid {
+		title = "Tax Invoice"
+	}
+
+	return renderTaxDocumentPDF(taxDocument{
+		title:          title,
+		number_label:   "Invoice No.",
+		number:         invoice.InvoiceNumber,
+		issued_at:      invoice.IssuedAt.Time,
+		invoice:        invoice,
+		currency:       invoice.Currency,
+		tax_rate:       invoice.TaxRate,
+		taxable_amount: invoice.TaxableAmount,
+		cgst_amount:    invoice.CgstAmount,
+		sgst_amount:    invoice.SgstAmount,
+		igst_amount:    invoice.IgstAmount,
+		total_amount:   invoice.TotalAmount,
+	})
+}
+
+// renderCreditNotePDF lays out a credit note against the invoice it reverses.
+func renderCreditNotePDF(credit_note sqlc.CreditNote, invoice sqlc.Invoice) ([]byte, error) {
+	return renderTaxDocumentPDF(taxDocument{
+		title:          "Credit Note",
+		number_label:   "Credit Note No.",
+		number:         credit_note.CreditNoteNumber,
+		issued_at:      credit_note.IssuedAt.Time,
+		reference:      [2]string{"Against invoice", invoice.InvoiceNumber + " dated " + invoice.IssuedAt.Time.In(invoiceTimezone).Format("02 Jan 2006")},
+		invoice:        invoice,
+		currency:       credit_note.Currency,
+		tax_rate:       credit_note.TaxRate,
+		taxable_amount: credit_note.TaxableAmount,
+		cgst_amount:    credit_note.CgstAmount,
+		sgst_amount:    credit_note.SgstAmount,
+		igst_amount:    credit_note.IgstAmount,
+		total_amount:   credit_note.TotalAmount,
+	})
+}
+
+func renderTaxDocumentPDF(doc taxDocument) ([]byte, error) {
+	cfg := config.LoadEnv()
+	invoice := doc.invoice
+
+	pdf := fpdf.New("P", "mm", "A4", "")
+	tr := pdf.UnicodeTranslatorFromDescriptor("")
+	pdf.SetTitle(doc.number, false)
+	pdf.SetCreator(cfg.SELLER_NAME, false)
+	pdf.AddPage()
+
+	is_taxed := invoice.PlaceOfSupply.Valid
+
+	pdf.SetFont("Helvetica", "B", 18)
+	pdf.CellFormat(0, 10, doc.title, "", 1, "L", false, 0, "")
+	pdf.Ln(2)
+
+	// seller
+	pdf.SetFont("Helvetica", "B", 11)
+	pdf.CellFormat(0, 6, tr(cfg.SELLER_NAME), "", 1, "L", false, 0, "")
+	pdf.SetFont("Helvetica", "", 10)
+
+	if cfg.SELLER_ADDRESS != "" {
+		pdf.MultiCell(90, 5, tr(cfg.SELLER_ADDRESS), "", "L", false)
+	}
+
+	if invoice.SellerGstin != "" {
+		pdf.CellFormat(0, 5, "GSTIN: "+invoice.SellerGstin, "", 1, "L", false, 0, "")
+	}
+
+	pdf.Ln(4)
+
+	// document details
+	details := [][2]string{
+		{doc.number_label, doc.number},
+		{"Date", doc.issued_at.In(invoiceTimezone).Format("02 Jan 2006")},
+	}
+
+	if doc.reference[0] != "" {
+		details = append(details, doc.reference)
+	}
+
+	if is_taxed {
+		details = append(details, [2]string{"Place of supply", fmt.Sprintf("%s (%s)", constants.GSTStates[invoice.PlaceOfSupply.String], invoice.PlaceOfSupply.String)})
+	} else {
+		details = append(details, [2]string{"Supply", "Export of services"})
+	}
+
+	for _, detail := range details {
+		pdf.SetFont("Helvetica", "B", 10)
+		pdf.CellFormat(35, 5, detail[0], "", 0, "L", false, 0, "")
+		pdf.SetFont("Helvetica", "", 10)
+		pdf.CellFormat(0, 5, tr(detail[1]), "", 1, "L", false, 0, "")
+	}
+
+	pdf.Ln(4)
+
+	// buyer
+	pdf.SetFont("Helvetica", "B", 10)
+	pdf.CellFormat(0, 5, "Bill to", "", 1, "L", false, 0, "")
+	pdf.SetFont("Helvetica", "", 10)
+
+	if invoice.BillingName.Valid && invoice.BillingName.String != "" {
+		pdf.CellFormat(0, 5, tr(invoice.BillingName.String), "", 1, "L", false, 0, "")
+	}
+
+	if invoice.Gstin.Valid && invoice.Gstin.String != "" {
+		pdf.CellFormat(0, 5, "GSTIN: "+invoice.Gstin.String, "", 1, "L", false, 0, "")
+	}
+
+	pdf.CellFormat(0, 5, "Customer ID: "+invoice.UserID.String(), "", 1, "L", false, 0, "")
+	pdf.Ln(6)
+
+	// line items
+	amount := func(value pgtype.Numeric) string {
+		minor := utils.ConvertPgtypeNumericToInt64(value)
+		return fmt.Sprintf("%s %d.%02d", doc.currency, minor/100, minor%100)
+	}
+
+	pdf.SetFont("Helvetica", "B", 10)
+	pdf.SetFillColor(235, 235, 235)
+	pdf.CellFormat(110, 7, "Description", "1", 0, "L", true, 0, "")
+	pdf.CellFormat(30, 7, "SAC", "1", 0, "C", true, 0, "")
+	pdf.CellFormat(50, 7, "Amount", "1", 1, "R", true, 0, "")
+
+	pdf.SetFont("Helvetica", "", 10)
+	pdf.CellFormat(110, 7, tr(invoice.PlanType+" plan subscription"), "1", 0, "L", false, 0, "")
+	pdf.CellFormat(30, 7, constants.GSTServiceAccountingCode, "1", 0, "C", false, 0, "")
+	pdf.CellFormat(50, 7, amount(doc.taxable_amount), "1", 1, "R", false, 0, "")
+
+	rate := utils.ConvertPgtypeNumericToInt64(doc.tax_rate)
+	totals := [][2]string{
+		{"Taxable value", amount(doc.taxable_amount)},
+	}
+
+	if is_taxed {
+		if utils.ConvertPgtypeNumericToInt64(doc.igst_amount) > 0 {
+			totals = append(totals, [2]string{fmt.Sprintf("IGST @ %d%%", rate), amount(doc.igst_amount)})
+		} else {
+			half_rate := fmt.Sprintf("%g%%", float64(rate)/2)
+			totals = append(totals,
+				[2]string{"CGST @ " + half_rate, amount(doc.cgst_amount)},
+				[2]string{"SGST @ " + half_rate, amount(doc.sgst_amount)},
+			)
+		}
+	}
+
+	for _, total := range totals {
+		pdf.CellFormat(140, 7, total[0], "1", 0, "R", false, 0, "")
+		pdf.CellFormat(50, 7, total[1], "1", 1, "R", false, 0, "")
+	}
+
+	pdf.SetFont("Helvetica", "B", 10)
+	pdf.CellFormat(140, 7, "Total", "1", 0, "R", false, 0, "")
+	pdf.CellFormat(50, 7, amount(doc.total_amount), "1", 1, "R", false, 0, "")
+
+	pdf.Ln(8)
+	pdf.SetFont("Helvetica", "", 8)
+	pdf.MultiCell(0, 4, "This is a computer generated document and does not require a signature.", "", "L", false)
+
+	var buf bytes.Buffer
+
+	err := pdf.Output(&buf)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
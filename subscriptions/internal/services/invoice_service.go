@@ -0,0 +1,282 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/parbhat-cpp/fuse/subscriptions/constants"
+	"github.com/parbhat-cpp/fuse/subscriptions/internal/apperrors"
+	"github.com/parbhat-cpp/fuse/subscriptions/internal/config"
+	"github.com/parbhat-cpp/fuse/subscriptions/internal/db/sqlc"
+	"github.com/parbhat-cpp/fuse/subscriptions/pkg/utils"
+)
+
+type InvoiceService struct {
+	query *sqlc.Queries
+}
+
+var ErrInvoiceNotFound = apperrors.New(apperrors.CodeNotFound, "invoice not found")
+var ErrCreditNoteNotFound = apperrors.New(apperrors.CodeNotFound, "credit note not found")
+var ErrInvalidBillingDetails = apperrors.New(apperrors.CodeInvalidRequest, "invalid billing details")
+
+// BillingDetails are the optional buyer details printed on the invoice. StateCode is the
+// GST state code of the buyer and decides between CGST + SGST and IGST.
+type BillingDetails struct {
+	Name      string
+	GSTIN     string
+	StateCode string
+}
+
+// invoices are numbered and dated in Indian time
+var invoiceTimezone = time.FixedZone("IST", 5*60*60+30*60)
+
+func NewInvoiceService(query *sqlc.Queries) *InvoiceService {
+	return &InvoiceService{
+		query: query,
+	}
+}
+
+/**
+ * Returns the invoices issued to a user, newest first.
+ * @param ctx: context.Context
+ * @param user_id: uuid.UUID
+ * @return []sqlc.Invoice, error
+ */
+func (s *InvoiceService) GetInvoices(ctx context.Context, user_id uuid.UUID) ([]sqlc.Invoice, error) {
+	return s.query.GetInvoicesByUserID(ctx, utils.ConvertGoogleUUIDToPgtypeUUID(user_id))
+}
+
+/**
+ * Renders one of the user's invoices as a PDF.
+ * @param ctx: context.Context
+ * @param user_id: uuid.UUID
+ * @param invoice_id: uuid.UUID
+ * @return sqlc.Invoice, []byte, error
+ */
+func (s *InvoiceService) GetInvoicePDF(ctx context.Context, user_id uuid.UUID, invoice_id uuid.UUID) (sqlc.Invoice, []byte, error) {
+	invoice, err := s.query.GetInvoiceByIDAndUserID(ctx, sqlc.GetInvoiceByIDAndUserIDParams{
+		ID:     utils.ConvertGoogleUUIDToPgtypeUUID(invoice_id),
+		UserID: utils.ConvertGoogleUUIDToPgtypeUUID(user_id),
+	})
+
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return sqlc.Invoice{}, nil, ErrInvoiceNotFound
+		}
+		return sqlc.Invoice{}, nil, err
+	}
+
+	pdf, err := renderInvoicePDF(invoice)
+
+	if err != nil {
+		return sqlc.Invoice{}, nil, fmt.Errorf("Unable to render invoice")
+	}
+
+	return invoice, pdf, nil
+}
+
+/**
+ * Returns the credit notes issued to a user for refunds, newest first.
+ * @param ctx: context.Context
+ * @param user_id: uuid.UUID
+ * @return []sqlc.CreditNote, error
+ */
+func (s *InvoiceService) GetCreditNotes(ctx context.Context, user_id uuid.UUID) ([]sqlc.CreditNote, error) {
+	return s.query.GetCreditNotesByUserID(ctx, utils.ConvertGoogleUUIDToPgtypeUUID(user_id))
+}
+
+/**
+ * Renders one of the user's credit notes as a PDF.
+ * @param ctx: context.Context
+ * @param user_id: uuid.UUID
+ * @param credit_note_id: uuid.UUID
+ * @return sqlc.CreditNote, []byte, error
+ */
+func (s *InvoiceService) GetCreditNotePDF(ctx context.Context, user_id uuid.UUID, credit_note_id uuid.UUID) (sqlc.CreditNote, []byte, error) {
+	credit_note, err := s.query.GetCreditNoteByIDAndUserID(ctx, sqlc.GetCreditNoteByIDAndUserIDParams{
+		ID:     utils.ConvertGoogleUUIDToPgtypeUUID(credit_note_id),
+		UserID: utils.ConvertGoogleUUIDToPgtypeUUID(user_id),
+	})
+
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return sqlc.CreditNote{}, nil, ErrCreditNoteNotFound
+		}
+		return sqlc.CreditNote{}, nil, err
+	}
+
+	invoice, err := s.query.GetInvoiceByID(ctx, credit_note.InvoiceID)
+
+	if err != nil {
+		return sqlc.CreditNote{}, nil, err
+	}
+
+	pdf, err := renderCreditNotePDF(credit_note, invoice)
+
+	if err != nil {
+		return sqlc.CreditNote{}, nil, fmt.Errorf("Unable to render credit note")
+	}
+
+	return credit_note, pdf, nil
+}
+
+// normalizeBillingDetails validates billing details. A GSTIN decides the buyer's state,
+// so a state code that disagrees with it is rejected.
+func normalizeBillingDetails(billing BillingDetails) (BillingDetails, error) {
+	billing.Name = strings.TrimSpace(billing.Name)
+	billing.GSTIN = strings.ToUpper(strings.TrimSpace(billing.GSTIN))
+	billing.StateCode = strings.TrimSpace(billing.StateCode)
+
+	if billing.GSTIN != "" {
+		if !utils.IsValidGSTIN(billing.GSTIN) {
+			return BillingDetails{}, ErrInvalidBillingDetails.Withf("invalid gstin")
+		}
+
+		if billing.StateCode != "" && billing.StateCode != billing.GSTIN[:2] {
+			return BillingDetails{}, ErrInvalidBillingDetails.Withf("state does not match gstin")
+		}
+
+		billing.StateCode = billing.GSTIN[:2]
+	}
+
+	if billing.StateCode != "" {
+		if _, exists := constants.GSTStates[billing.StateCode]; !exists {
+			return BillingDetails{}, ErrInvalidBillingDetails.Withf("unknown state code %s", billing.StateCode)
+		}
+	}
+
+	return billing, nil
+}
+
+// sellerStateCode returns the configured seller state, falling back to the state in the
+// seller's GSTIN.
+func sellerStateCode(cfg *config.Config) string {
+	if cfg.SELLER_STATE_CODE != "" {
+		return cfg.SELLER_STATE_CODE
+	}
+
+	if len(cfg.SELLER_GSTIN) >= 2 {
+		return cfg.SELLER_GSTIN[:2]
+	}
+
+	return ""
+}
+
+// placeOfSupply is the buyer's state, or the seller's state when the buyer did not give one.
+func placeOfSupply(cfg *config.Config, billing_state_code string) string {
+	if billing_state_code != "" {
+		return billing_state_code
+	}
+
+	return sellerStateCode(cfg)
+}
+
+// calculateGST returns the GST rate and breakup of a tax inclusive amount. Only INR
+// sales are taxed; sales in other currencies are exports and carry no GST.
+func calculateGST(cfg *config.Config, currency constants.Currency, amount int64, place_of_supply string) (int64, utils.GSTBreakup) {
+	if currency != constants.CurrencyINR {
+		return 0, utils.SplitInclusiveGST(amount, 0, false)
+	}
+
+	rate := int64(cfg.GST_RATE)
+
+	return rate, utils.SplitInclusiveGST(amount, rate, place_of_supply == sellerStateCode(cfg))
+}
+
+// financialYear returns the Indian financial year (April to March) of t, e.g. "26-27".
+func financialYear(t time.Time) string {
+	start := t.Year()
+
+	if t.Month() < time.April {
+		start = start - 1
+	}
+
+	return fmt.Sprintf("%02d-%02d", start%100, (start+1)%100)
+}
+
+// issueInvoice numbers and stores the invoice for a paid order. It must run in the
+// transaction that records the payment so invoice numbers stay consecutive.
+func issueInvoice(ctx context.Context, qtx *sqlc.Queries, order sqlc.PaymentOrder, subscription_id pgtype.UUID, issued_at time.Time) (sqlc.Invoice, error) {
+	cfg := config.LoadEnv()
+	issued_at = issued_at.In(invoiceTimezone)
+	fy := financialYear(issued_at)
+	currency := constants.Currency(order.Currency)
+	amount := utils.ConvertPgtypeNumericToInt64(order.Amount)
+
+	var place_of_supply pgtype.Text
+
+	if currency == constants.CurrencyINR {
+		place_of_supply = pgtype.Text{String: placeOfSupply(cfg, order.BillingStateCode.String), Valid: true}
+	}
+
+	rate, gst := calculateGST(cfg, currency, amount, place_of_supply.String)
+
+	sequence, err := qtx.NextInvoiceSequence(ctx, fy)
+
+	if err != nil {
+		return sqlc.Invoice{}, err
+	}
+
+	return qtx.CreateInvoice(ctx, sqlc.CreateInvoiceParams{
+		InvoiceNumber:   fmt.Sprintf("%s/%s/%06d", cfg.INVOICE_PREFIX, fy, sequence),
+		UserID:          order.UserID,
+		SubscriptionID:  subscription_id,
+		PaymentOrderID:  order.ID,
+		PlanType:        order.PlanType,
+		Currency:        order.Currency,
+		BillingName:     order.BillingName,
+		Gstin:           order.Gstin,
+		PlaceOfSupply:   place_of_supply,
+		SellerGstin:     cfg.SELLER_GSTIN,
+		SellerStateCode: sellerStateCode(cfg),
+		TaxRate:         utils.ConvertInt64ToPgtypeNumeric(rate),
+		TaxableAmount:   utils.ConvertInt64ToPgtypeNumeric(gst.TaxableAmount),
+		CgstAmount:      utils.ConvertInt64ToPgtypeNumeric(gst.CGST),
+		SgstAmount:      utils.ConvertInt64ToPgtypeNumeric(gst.SGST),
+		IgstAmount:      utils.ConvertInt64ToPgtypeNumeric(gst.IGST),
+		TotalAmount:     order.Amount,
+		IssuedAt:        pgtype.Timestamptz{Time: issued_at, Valid: true},
+	})
+}
+
+// issueCreditNote numbers and stores a credit note for a refund against an invoice. The
+// GST reversed is in proportion to the share of the invoice that was refunded.
+func issueCreditNote(ctx context.Context, qtx *sqlc.Queries, invoice sqlc.Invoice, refund_id pgtype.UUID, amount int64, issued_at time.Time) (sqlc.CreditNote, error) {
+	cfg := config.LoadEnv()
+	issued_at = issued_at.In(invoiceTimezone)
+	fy := financialYear(issued_at)
+
+	gst := utils.ReverseGST(utils.GSTBreakup{
+		TaxableAmount: utils.ConvertPgtypeNumericToInt64(invoice.TaxableAmount),
+		CGST:          utils.ConvertPgtypeNumericToInt64(invoice.CgstAmount),
+		SGST:          utils.ConvertPgtypeNumericToInt64(invoice.SgstAmount),
+		IGST:          utils.ConvertPgtypeNumericToInt64(invoice.IgstAmount),
+	}, utils.ConvertPgtypeNumericToInt64(invoice.TotalAmount), amount)
+
+	sequence, err := qtx.NextCreditNoteSequence(ctx, fy)
+
+	if err != nil {
+		return sqlc.CreditNote{}, err
+	}
+
+	return qtx.CreateCreditNote(ctx, sqlc.CreateCreditNoteParams{
+		CreditNoteNumber: fmt.Sprintf("%s/%s/%06d", cfg.CREDIT_NOTE_PREFIX, fy, sequence),
+		InvoiceID:        invoice.ID,
+		RefundID:         refund_id,
+		UserID:           invoice.UserID,
+		Currency:         invoice.Currency,
+		TaxRate:          invoice.TaxRate,
+		TaxableAmount:    utils.ConvertInt64ToPgtypeNumeric(gst.TaxableAmount),
+		CgstAmount:       utils.ConvertInt64ToPgtypeNumeric(gst.CGST),
+		SgstAmount:       utils.ConvertInt64ToPgtypeNumeric(gst.SGST),
+		IgstAmount:       utils.ConvertInt64ToPgtypeNumeric(gst.IGST),
+		TotalAmount:      utils.ConvertInt64ToPgtypeNumeric(amount),
+		IssuedAt:         pgtype.Timestamptz{Time: issued_at, Valid: true},
+	})
+}
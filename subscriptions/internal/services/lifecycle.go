@@ -0,0 +1,82 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/parbhat-cpp/fuse/subscriptions/constants"
+	"github.com/parbhat-cpp/fuse/subscriptions/internal/apperrors"
+	"github.com/parbhat-cpp/fuse/subscriptions/internal/db/sqlc"
+)
+
+var ErrInvalidTransition = apperrors.New(apperrors.CodeConflict, "invalid subscription status transition")
+
+// transitionSubscription moves a subscription to a new status and records the change
+// in subscription_events and the outbox. The row is locked for the rest of the transaction.
+func transitionSubscription(ctx context.Context, qtx *sqlc.Queries, subscription_id pgtype.UUID, user_id pgtype.UUID, to constants.SubscriptionStatus, reason string) error {
+	current, err := qtx.GetSubscriptionStatusForUpdate(ctx, subscription_id)
+
+	if err != nil {
+		return err
+	}
+
+	from := constants.SubscriptionStatus(current)
+
+	if !constants.CanTransitionSubscription(from, to) {
+		return ErrInvalidTransition.Withf("%s -> %s", from, to)
+	}
+
+	err = qtx.UpdateSubscriptionStatus(ctx, sqlc.UpdateSubscriptionStatusParams{
+		ID:     subscription_id,
+		Status: string(to),
+	})
+
+	if err != nil {
+		return err
+	}
+
+	err = qtx.CreateSubscriptionEvent(ctx, sqlc.CreateSubscriptionEventParams{
+		SubscriptionID: subscription_id,
+		UserID:         user_id,
+		FromStatus:     pgtype.Text{String: string(from), Valid: true},
+		ToStatus:       string(to),
+		Reason:         reason,
+	})
+
+	if err != nil {
+		return err
+	}
+
+	return enqueueOutboxEvent(ctx, qtx, constants.OutboxTopicSubscriptionStatusChanged, user_id, subscription_id, map[string]interface{}{
+		"subscription_id": subscription_id.String(),
+		"user_id":         user_id.String(),
+		"from_status":     from,
+		"to_status":       to,
+		"reason":          reason,
+		"occurred_at":     time.Now().Format(time.RFC3339),
+	})
+}
+
+// recordSubscriptionCreated records the initial status of a new subscription and
+// publishes it through the outbox.
+func recordSubscriptionCreated(ctx context.Context, qtx *sqlc.Queries, subscription_id pgtype.UUID, user_id pgtype.UUID, status constants.SubscriptionStatus, reason string) error {
+	err := qtx.CreateSubscriptionEvent(ctx, sqlc.CreateSubscriptionEventParams{
+		SubscriptionID: subscription_id,
+		UserID:         user_id,
+		ToStatus:       string(status),
+		Reason:         reason,
+	})
+
+	if err != nil {
+		return err
+	}
+
+	return enqueueOutboxEvent(ctx, qtx, constants.OutboxTopicSubscriptionCreated, user_id, subscription_id, map[string]interface{}{
+		"subscription_id": subscription_id.String(),
+		"user_id":         user_id.String(),
+		"status":          status,
+		"reason":          reason,
+		"occurred_at":     time.Now().Format(time.RFC3339),
+	})
+}
@@ -0,0 +1,207 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/parbhat-cpp/fuse/subscriptions/constants"
+	"github.com/parbhat-cpp/fuse/subscriptions/internal/apperrors"
+	"github.com/parbhat-cpp/fuse/subscriptions/internal/config"
+	"github.com/parbhat-cpp/fuse/subscriptions/internal/db/sqlc"
+	"github.com/parbhat-cpp/fuse/subscriptions/internal/metrics"
+	"github.com/parbhat-cpp/fuse/subscriptions/internal/tracing"
+	"github.com/parbhat-cpp/fuse/subscriptions/lib"
+	"github.com/parbhat-cpp/fuse/subscriptions/pkg/utils"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type OutboxService struct {
+	query    *sqlc.Queries
+	notifier lib.NotificationClient
+}
+
+var ErrOutboxEventNotFound = apperrors.New(apperrors.CodeNotFound, "outbox event not found")
+
+// claimed events are skipped by other dispatchers until the lease runs out
+const outboxLease = 5 * time.Minute
+
+const outboxBaseBackoff = 30 * time.Second
+const outboxMaxBackoff = time.Hour
+
+const defaultDeadOutboxEventLimit = 100
+const maxDeadOutboxEventLimit = 500
+
+func NewOutboxService(query *sqlc.Queries, notifier lib.NotificationClient) *OutboxService {
+	return &OutboxService{
+		query:    query,
+		notifier: notifier,
+	}
+}
+
+/**
+ * Delivers due outbox events. Failed deliveries are retried with exponential backoff
+ * and dead-lettered after OUTBOX_MAX_ATTEMPTS, or right away when the notification is
+ * rejected. Delivered events are pruned after OUTBOX_RETENTION_DAYS.
+ * @return error
+ */
+func (s *OutboxService) DispatchOutbox() error {
+	cfg := config.LoadEnv()
+	now := time.Now()
+
+	events, err := s.query.ClaimOutboxEvents(context.Background(), sqlc.ClaimOutboxEventsParams{
+		LeaseUntil: pgtype.Timestamptz{Time: now.Add(outboxLease), Valid: true},
+		BatchSize:  int32(cfg.OUTBOX_BATCH_SIZE),
+	})
+
+	if err != nil {
+		return err
+	}
+
+	for _, event := range events {
+		// each delivery is its own trace, continued by the service it is delivered to
+		ctx, span := tracing.Start(context.Background(), "outbox deliver", trace.WithAttributes(
+			attribute.String("outbox.topic", event.Topic),
+			attribute.String("outbox.event_id", uuid.UUID(event.ID.Bytes).String()),
+		))
+		delivery_err := s.deliver(ctx, cfg, event)
+		tracing.End(span, delivery_err)
+
+		// the rest of the batch is picked up again once the lease runs out
+		if errors.Is(delivery_err, lib.ErrCircuitOpen) {
+			metrics.OutboxDeliveries.WithLabelValues(event.Topic, metrics.DeliveryCircuitOpen).Inc()
+			break
+		}
+
+		if delivery_err == nil {
+			metrics.OutboxDeliveries.WithLabelValues(event.Topic, metrics.DeliveryDelivered).Inc()
+			err = s.query.MarkOutboxEventDelivered(ctx, event.ID)
+
+			if err != nil {
+				slog.ErrorContext(ctx, "Unable to mark outbox event as delivered", "event_id", uuid.UUID(event.ID.Bytes), "topic", event.Topic, "error", err)
+			}
+			continue
+		}
+
+		attempts := int(event.Attempts) + 1
+		status := constants.OutboxStatusPending
+		delivery_outcome := metrics.DeliveryRetried
+
+		// a rejected notification is rejected again on every retry
+		if attempts >= cfg.OUTBOX_MAX_ATTEMPTS || errors.Is(delivery_err, lib.ErrNotificationRejected) {
+			status = constants.OutboxStatusDead
+			delivery_outcome = metrics.DeliveryDead
+			slog.WarnContext(ctx, "Outbox event dead", "event_id", uuid.UUID(event.ID.Bytes), "topic", event.Topic, "attempts", attempts, "error", delivery_err)
+		}
+
+		metrics.OutboxDeliveries.WithLabelValues(event.Topic, delivery_outcome).Inc()
+
+		err = s.query.MarkOutboxEventFailed(ctx, sqlc.MarkOutboxEventFailedParams{
+			ID:            event.ID,
+			Status:        string(status),
+			NextAttemptAt: pgtype.Timestamptz{Time: time.Now().Add(utils.ExponentialBackoff(attempts, outboxBaseBackoff, outboxMaxBackoff)), Valid: true},
+			LastError:     pgtype.Text{String: delivery_err.Error(), Valid: true},
+		})
+
+		if err != nil {
+			slog.ErrorContext(ctx, "Unable to record failed delivery of outbox event", "event_id", uuid.UUID(event.ID.Bytes), "topic", event.Topic, "error", err)
+		}
+	}
+
+	if cfg.OUTBOX_RETENTION_DAYS > 0 {
+		_, err = s.query.PruneDeliveredOutboxEvents(context.Background(), pgtype.Timestamptz{Time: now.AddDate(0, 0, -cfg.OUTBOX_RETENTION_DAYS), Valid: true})
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+/**
+ * Returns dead-lettered outbox events, newest first.
+ * @param ctx: context.Context
+ * @param limit: int
+ * @return []sqlc.OutboxEvent, error
+ */
+func (s *OutboxService) GetDeadOutboxEvents(ctx context.Context, limit int) ([]sqlc.OutboxEvent, error) {
+	if limit <= 0 {
+		limit = defaultDeadOutboxEventLimit
+	}
+
+	if limit > maxDeadOutboxEventLimit {
+		limit = maxDeadOutboxEventLimit
+	}
+
+	return s.query.GetDeadOutboxEvents(ctx, int32(limit))
+}
+
+/**
+ * Puts a dead-lettered outbox event back in the queue with a fresh set of attempts.
+ * @param ctx: context.Context
+ * @param event_id: uuid.UUID
+ * @return error
+ */
+func (s *OutboxService) RetryOutboxEvent(ctx context.Context, event_id uuid.UUID) error {
+	retried, err := s.query.RetryDeadOutboxEvent(ctx, utils.ConvertGoogleUUIDToPgtypeUUID(event_id))
+
+	if err != nil {
+		return err
+	}
+
+	if retried == 0 {
+		return ErrOutboxEventNotFound
+	}
+
+	return nil
+}
+
+// deliver sends notifications through the notification client. Domain events are
+// published to EVENTS_URL, or dropped when no consumer is configured.
+func (s *OutboxService) deliver(ctx context.Context, cfg *config.Config, event sqlc.OutboxEvent) error {
+	if constants.OutboxTopic(event.Topic) == constants.OutboxTopicNotification {
+		var notification lib.Notification
+
+		err := json.Unmarshal(event.Payload, &notification)
+
+		if err != nil {
+			return err
+		}
+
+		return s.notifier.Send(ctx, notification)
+	}
+
+	if cfg.EVENTS_URL == "" {
+		return nil
+	}
+
+	return lib.PublishEvent(ctx, cfg.EVENTS_URL, event.Topic, event.Payload)
+}
+
+// enqueueOutboxEvent writes an event to the outbox. It must run in the transaction that
+// makes the change the event describes, so the event exists if and only if the change does.
+func enqueueOutboxEvent(ctx context.Context, qtx *sqlc.Queries, topic constants.OutboxTopic, user_id pgtype.UUID, aggregate_id pgtype.UUID, payload any) error {
+	payload_json, err := json.Marshal(payload)
+
+	if err != nil {
+		return err
+	}
+
+	return qtx.CreateOutboxEvent(ctx, sqlc.CreateOutboxEventParams{
+		Topic:       string(topic),
+		UserID:      user_id,
+		AggregateID: aggregate_id,
+		Payload:     payload_json,
+	})
+}
+
+// enqueueNotification writes a notification for the user to the outbox.
+func enqueueNotification(ctx context.Context, qtx *sqlc.Queries, user_id pgtype.UUID, aggregate_id pgtype.UUID, event lib.NotificationEvent) error {
+	return enqueueOutboxEvent(ctx, qtx, constants.OutboxTopicNotification, user_id, aggregate_id, lib.NewNotification(user_id.String(), event))
+}
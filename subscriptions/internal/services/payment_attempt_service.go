@@ -0,0 +1,83 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/parbhat-cpp/fuse/subscriptions/constants"
+	"github.com/parbhat-cpp/fuse/subscriptions/internal/apperrors"
+	"github.com/parbhat-cpp/fuse/subscriptions/internal/db/sqlc"
+	"github.com/parbhat-cpp/fuse/subscriptions/pkg/utils"
+)
+
+type PaymentAttemptService struct {
+	query *sqlc.Queries
+}
+
+var ErrInvalidPaymentAttemptFilter = apperrors.New(apperrors.CodeInvalidRequest, "invalid payment attempt filter")
+
+const defaultPaymentAttemptLimit = 100
+const maxPaymentAttemptLimit = 500
+
+// PaymentAttemptFilter narrows a payment attempt search. Empty fields are not filtered on.
+type PaymentAttemptFilter struct {
+	UserID            *uuid.UUID
+	Outcome           string
+	ReasonCode        string
+	RazorpayOrderID   string
+	RazorpayPaymentID string
+	ClientIP          string
+	CreatedAfter      *time.Time
+	CreatedBefore     *time.Time
+	Limit             int
+}
+
+func NewPaymentAttemptService(query *sqlc.Queries) *PaymentAttemptService {
+	return &PaymentAttemptService{
+		query: query,
+	}
+}
+
+/**
+ * Returns payment verification attempts matching the filter, newest first.
+ * @param ctx: context.Context
+ * @param filter: PaymentAttemptFilter
+ * @return []sqlc.PaymentAttempt, error
+ */
+func (s *PaymentAttemptService) SearchPaymentAttempts(ctx context.Context, filter PaymentAttemptFilter) ([]sqlc.PaymentAttempt, error) {
+	outcome := constants.PaymentAttemptOutcome(filter.Outcome)
+
+	if outcome != "" && outcome != constants.PaymentAttemptSucceeded && outcome != constants.PaymentAttemptFailed {
+		return nil, ErrInvalidPaymentAttemptFilter.Withf("outcome must be succeeded or failed")
+	}
+
+	if filter.Limit < 0 || filter.Limit > maxPaymentAttemptLimit {
+		return nil, ErrInvalidPaymentAttemptFilter.Withf("limit must be between 1 and %d", maxPaymentAttemptLimit)
+	}
+
+	params := sqlc.SearchPaymentAttemptsParams{
+		Outcome:           pgtype.Text{String: filter.Outcome, Valid: filter.Outcome != ""},
+		ReasonCode:        pgtype.Text{String: filter.ReasonCode, Valid: filter.ReasonCode != ""},
+		RazorpayOrderID:   pgtype.Text{String: filter.RazorpayOrderID, Valid: filter.RazorpayOrderID != ""},
+		RazorpayPaymentID: pgtype.Text{String: filter.RazorpayPaymentID, Valid: filter.RazorpayPaymentID != ""},
+		ClientIp:          pgtype.Text{String: filter.ClientIP, Valid: filter.ClientIP != ""},
+		RowLimit:          defaultPaymentAttemptLimit,
+	}
+
+	if filter.UserID != nil {
+		params.UserID = utils.ConvertGoogleUUIDToPgtypeUUID(*filter.UserID)
+	}
+	if filter.CreatedAfter != nil {
+		params.CreatedAfter = pgtype.Timestamptz{Time: *filter.CreatedAfter, Valid: true}
+	}
+	if filter.CreatedBefore != nil {
+		params.CreatedBefore = pgtype.Timestamptz{Time: *filter.CreatedBefore, Valid: true}
+	}
+	if filter.Limit > 0 {
+		params.RowLimit = int32(filter.Limit)
+	}
+
+	return s.query.SearchPaymentAttempts(ctx, params)
+}
@@ -2,16 +2,21 @@ package services
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"math/big"
+	"log/slog"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/parbhat-cpp/fuse/subscriptions/constants"
+	"github.com/parbhat-cpp/fuse/subscriptions/internal/apperrors"
 	"github.com/parbhat-cpp/fuse/subscriptions/internal/config"
 	"github.com/parbhat-cpp/fuse/subscriptions/internal/db/sqlc"
+	"github.com/parbhat-cpp/fuse/subscriptions/internal/logging"
+	"github.com/parbhat-cpp/fuse/subscriptions/internal/metrics"
+	"github.com/parbhat-cpp/fuse/subscriptions/internal/tracing"
 	"github.com/parbhat-cpp/fuse/subscriptions/internal/types"
 	"github.com/parbhat-cpp/fuse/subscriptions/lib"
 	"github.com/parbhat-cpp/fuse/subscriptions/pkg/utils"
@@ -22,6 +27,16 @@ type PaymentService struct {
 	pool  *pgxpool.Pool
 }
 
+var ErrInvalidPlanType = apperrors.New(apperrors.CodeInvalidRequest, "invalid plan type")
+var ErrCurrencyUnavailable = apperrors.New(apperrors.CodeInvalidRequest, "currency unavailable")
+var ErrInvalidPaymentSignature = apperrors.New(apperrors.CodeInvalidRequest, "invalid payment signature")
+var ErrUpgradeUnavailable = apperrors.New(apperrors.CodeConflict, "upgrade unavailable")
+var ErrPaymentProvider = apperrors.New(apperrors.CodeUpstream, "payment provider unavailable")
+var ErrPaymentAlreadyProcessed = apperrors.New(apperrors.CodeConflict, "payment already processed")
+
+// the payment is genuine but cannot be applied, so it is refunded
+var ErrPaymentRejected = apperrors.New(apperrors.CodePaymentFailed, "payment could not be applied")
+
 func NewPaymentService(query *sqlc.Queries, pool *pgxpool.Pool) *PaymentService {
 	return &PaymentService{
 		query: query,
@@ -29,34 +44,165 @@ func NewPaymentService(query *sqlc.Queries, pool *pgxpool.Pool) *PaymentService
 	}
 }
 
-func (s *PaymentService) InitializePayment(plan_type string) (map[string]interface{}, error) {
+/**
+ * Creates a razorpay order for the given plan. When the user already has an active
+ * subscription on a cheaper plan, the unused value of the current period is credited
+ * against the new plan's price and the order is marked as an upgrade. A purchase made
+ * during a free trial converts it, so it is handled as an upgrade without any credit.
+ * An optional promo code is applied on top of that. Upgrades are charged in the
+ * currency of the subscription being upgraded. Prices include GST, the returned tax
+ * breakup is what the invoice will show.
+ * @param ctx: context.Context
+ * @param user_id: uuid.UUID
+ * @param plan_type: string
+ * @param coupon_code: string
+ * @param currency: constants.Currency
+ * @param billing: BillingDetails
+ * @return map[string]interface{}, error
+ */
+func (s *PaymentService) InitializePayment(ctx context.Context, user_id uuid.UUID, plan_type string, coupon_code string, currency constants.Currency, billing BillingDetails) (map[string]interface{}, error) {
+	cfg := config.LoadEnv()
 	razorpay_client := config.GetRazorpayClient()
 
+	billing, err := normalizeBillingDetails(billing)
+
+	if err != nil {
+		return map[string]interface{}{}, err
+	}
+
 	plan_data, exists := constants.GetPlans()[plan_type]
 
 	if !exists {
-		return map[string]interface{}{}, fmt.Errorf("Invalid plan type")
+		return map[string]interface{}{}, ErrInvalidPlanType
+	}
+
+	var user_uuid pgtype.UUID = utils.ConvertGoogleUUIDToPgtypeUUID(user_id)
+	var amount int64 = 0 // amount in the currency's minor unit
+	var proration_credit int64 = 0
+	var upgrade_from pgtype.UUID
+	var coupon_id pgtype.UUID
+	var discount_amount int64 = 0
+
+	active_sub, err := s.query.GetActiveSubscriptionByUserID(ctx, user_uuid)
+
+	if err == nil {
+		active_plan, plan_err := constants.GetPlanByID(uuid.UUID(active_sub.PlanID.Bytes))
+
+		is_trial := constants.SubscriptionStatus(active_sub.Status) == constants.SubscriptionStatusTrialing
+
+		if plan_err == nil && (is_trial || plan_data.Prices[constants.CurrencyINR] > active_plan.Prices[constants.CurrencyINR]) {
+			queued, err := s.query.CountQueuedSubscriptionsByUserID(ctx, user_uuid)
+
+			if err != nil {
+				return map[string]interface{}{}, fmt.Errorf("Unable to check queued subscriptions")
+			}
+
+			if queued > 0 {
+				return map[string]interface{}{}, ErrUpgradeUnavailable.Withf("a renewal is queued")
+			}
+
+			if !is_trial {
+				// credited from what was paid, so a discounted period is not credited at list price
+				currency = constants.Currency(active_sub.Currency)
+				proration_credit = utils.ProratedCredit(paidAmount(active_sub.Amount, active_sub.PlanID, active_sub.Currency), active_sub.ValidFrom.Time, active_sub.ValidUntil.Time, time.Now())
+			}
+
+			upgrade_from = active_sub.ID
+		}
 	}
 
+	amount, err = plan_data.Amount(currency)
+
+	if err != nil {
+		return map[string]interface{}{}, ErrCurrencyUnavailable.Withf("%v", err)
+	}
+
+	amount = amount - proration_credit
+
+	if coupon_code != "" {
+		coupon, discount, err := applyCoupon(ctx, s.query, coupon_code, user_uuid, plan_type, currency, amount)
+
+		if err != nil {
+			return map[string]interface{}{}, err
+		}
+
+		coupon_id = coupon.ID
+		discount_amount = discount
+		amount = amount - discount_amount
+	}
+
+	_, tax := calculateGST(cfg, currency, amount, placeOfSupply(cfg, billing.StateCode))
+
 	order_data := map[string]interface{}{
-		"amount":   plan_data.Price * 100, // amount in paise
-		"currency": "INR",
+		"amount":   amount,
+		"currency": string(currency),
 		"receipt":  "order_" + fmt.Sprintf("%x", uuid.New().String()[:8]),
 	}
 
-	body, err := razorpay_client.Order.Create(order_data, nil)
+	body, err := tracing.Razorpay(ctx, "orders.create", func() (map[string]interface{}, error) {
+		return razorpay_client.Order.Create(order_data, nil)
+	})
 
 	if err != nil {
-		return map[string]interface{}{}, fmt.Errorf("Unable to create an order")
+		return map[string]interface{}{}, ErrPaymentProvider.Wrap(err)
 	}
 
+	razorpay_order_id, _ := body["id"].(string)
+	ctx = logging.With(ctx, "plan_type", plan_type, "razorpay_order_id", razorpay_order_id)
+
+	_, err = s.query.CreatePaymentOrder(ctx, sqlc.CreatePaymentOrderParams{
+		UserID:                    user_uuid,
+		PlanID:                    utils.ConvertGoogleUUIDToPgtypeUUID(plan_data.ID),
+		PlanType:                  plan_type,
+		RazorpayOrderID:           razorpay_order_id,
+		Amount:                    utils.ConvertInt64ToPgtypeNumeric(amount),
+		ProrationCredit:           utils.ConvertInt64ToPgtypeNumeric(proration_credit),
+		UpgradeFromSubscriptionID: upgrade_from,
+		CouponID:                  coupon_id,
+		DiscountAmount:            utils.ConvertInt64ToPgtypeNumeric(discount_amount),
+		Currency:                  string(currency),
+		BillingName:               pgtype.Text{String: billing.Name, Valid: billing.Name != ""},
+		Gstin:                     pgtype.Text{String: billing.GSTIN, Valid: billing.GSTIN != ""},
+		BillingStateCode:          pgtype.Text{String: billing.StateCode, Valid: billing.StateCode != ""},
+	})
+
+	if err != nil {
+		slog.ErrorContext(ctx, "Unable to save payment order", "error", err)
+		return map[string]interface{}{}, fmt.Errorf("Unable to save the order")
+	}
+
+	slog.InfoContext(ctx, "Payment order created", "amount", amount, "currency", currency)
+
 	return map[string]interface{}{
-		"order": body,
-		"plan":  plan_data,
+		"order":            body,
+		"plan":             plan_data,
+		"amount":           amount,
+		"currency":         currency,
+		"proration_credit": proration_credit,
+		"is_upgrade":       upgrade_from.Valid,
+		"discount_amount":  discount_amount,
+		"tax":              tax,
 	}, nil
 }
 
-func (s *PaymentService) VerifyPayment(user_id uuid.UUID, plan_type string, order_id string, razorpay_order_id string, razorpay_payment_id string, razorpay_signature string) (interface{}, error) {
+/**
+ * Verifies a razorpay payment and creates the subscription it paid for. Payments that
+ * pass the signature check but cannot be applied are refunded. Every call is recorded
+ * in payment_attempts with its outcome.
+ * @param ctx: context.Context
+ * @param user_id: uuid.UUID
+ * @param plan_type: string
+ * @param order_id: string
+ * @param razorpay_order_id: string
+ * @param razorpay_payment_id: string
+ * @param razorpay_signature: string
+ * @param client_ip: string
+ * @return interface{}, error
+ */
+func (s *PaymentService) VerifyPayment(ctx context.Context, user_id uuid.UUID, plan_type string, order_id string, razorpay_order_id string, razorpay_payment_id string, razorpay_signature string, client_ip string) (res interface{}, err error) {
+	// a verified payment is applied or refunded even if the caller goes away
+	ctx = context.WithoutCancel(ctx)
+	ctx = logging.With(ctx, "plan_type", plan_type, "order_id", order_id, "razorpay_order_id", razorpay_order_id, "razorpay_payment_id", razorpay_payment_id)
 	cfg := config.LoadEnv()
 
 	plan := constants.GetPlans()[plan_type]
@@ -68,8 +214,18 @@ func (s *PaymentService) VerifyPayment(user_id uuid.UUID, plan_type string, orde
 	var refund_flag bool = false
 	var payment_verified bool = false
 	var sub_id pgtype.UUID
+	// taken from the order once it is read; until then nothing is refunded
+	var refund_amount int64 = 0
+	var refund_currency constants.Currency
+	var refunded bool = false
+	var reason constants.PaymentAttemptReason = constants.PaymentAttemptReasonInternalError
+
+	// registered first so it runs last and sees the outcome of the refund
+	defer func() {
+		s.recordPaymentAttempt(ctx, user_uuid, plan_type, order_id, razorpay_order_id, razorpay_payment_id, client_ip, reason, refunded, err)
+	}()
 
-	tx, err := s.pool.Begin(context.Background())
+	tx, err := s.pool.Begin(ctx)
 
 	if err != nil {
 		refund_flag = true
@@ -78,53 +234,126 @@ func (s *PaymentService) VerifyPayment(user_id uuid.UUID, plan_type string, orde
 
 	qtx := s.query.WithTx(tx)
 	defer func() {
-		if payment_verified && refund_flag {
-			_, err := s.refund(sub_id, user_uuid, razorpay_payment_id, int(plan.Price*100))
-			if err != nil {
-				fmt.Println("Refund failed: ", err)
-			} else {
-				fmt.Println("Refund successful for payment id: ", razorpay_payment_id)
-			}
+		// queued outside the transaction, which has been rolled back by now
+		if !payment_verified || !refund_flag {
+			return
+		}
+
+		if refund_amount <= 0 {
+			// without the order the amount paid is unknown, so it is left for a manual refund
+			slog.ErrorContext(ctx, "Unable to queue refund without the order")
+			return
+		}
+
+		_, err := queueRefund(ctx, s.query, pgtype.UUID{}, user_uuid, razorpay_payment_id, refund_amount, refund_amount, refund_currency, constants.RefundReasonPaymentNotApplied)
+		if err != nil {
+			slog.ErrorContext(ctx, "Unable to queue refund", "error", err)
+		} else {
+			refunded = true
 		}
 	}()
-	defer tx.Rollback(context.Background())
+	defer tx.Rollback(ctx)
 
-	payment_exists, _ := qtx.GetSubscriptionByPaymentID(context.Background(), razorpay_payment_id)
+	payment_exists, _ := qtx.GetSubscriptionByPaymentID(ctx, razorpay_payment_id)
 
 	if payment_exists.ID.Valid {
-		return nil, fmt.Errorf("Subscription already exists for this payment")
+		reason = constants.PaymentAttemptReasonDuplicatePayment
+		return nil, ErrPaymentAlreadyProcessed.Withf("subscription already exists for this payment")
 	}
 
 	err = utils.PaymentVerify(razorpay_signature, razorpay_order_id, razorpay_payment_id, cfg.RAZORPAY_API_SECRET)
 
 	if err != nil {
 		refund_flag = true
-		return nil, err
+		reason = constants.PaymentAttemptReasonInvalidSignature
+		return nil, ErrInvalidPaymentSignature.Wrap(err)
 	}
 	payment_verified = true
 
-	_, err = qtx.GetSubscriptionByUserIDOrderID(context.Background(), sqlc.GetSubscriptionByUserIDOrderIDParams{UserID: user_uuid, OrderID: order_id})
+	_, err = qtx.GetSubscriptionByUserIDOrderID(ctx, sqlc.GetSubscriptionByUserIDOrderIDParams{UserID: user_uuid, OrderID: order_id})
 
 	if err == nil {
-		return nil, fmt.Errorf("Payment already processed for this order")
+		reason = constants.PaymentAttemptReasonDuplicateOrder
+		return nil, ErrPaymentAlreadyProcessed.Withf("order already fulfilled")
 	}
 
-	sub, err := qtx.GetSubscriptionByUserID(context.Background(), user_uuid)
+	order, err := qtx.GetPaymentOrderByRazorpayOrderID(ctx, razorpay_order_id)
 
-	if err == nil {
-		if sub.ValidFrom.Valid && sub.ValidUntil.Valid && sub.ValidUntil.Time.Before(time.Now()) {
-			new_sub_valid_from.Time = time.Now()
-			new_sub_valid_to.Time = sub.ValidUntil.Time.AddDate(0, 0, 30)
-		} else {
+	if err != nil {
+		refund_flag = true
+		reason = constants.PaymentAttemptReasonOrderNotFound
+		return nil, ErrPaymentRejected.Withf("order not found")
+	}
+	refund_amount = utils.ConvertPgtypeNumericToInt64(order.Amount)
+	refund_currency = constants.Currency(order.Currency)
+
+	if order.UserID != user_uuid || order.PlanID != plan_uuid {
+		refund_flag = true
+		reason = constants.PaymentAttemptReasonOrderMismatch
+		return nil, ErrPaymentRejected.Withf("order does not match the requested plan")
+	}
+
+	if order.UpgradeFromSubscriptionID.Valid {
+		// upgrade or trial conversion: the new plan starts right away and the current subscription ends now
+		current_sub, err := qtx.GetSubscriptionByID(ctx, order.UpgradeFromSubscriptionID)
+		current_status := constants.SubscriptionStatus(current_sub.Status)
+		now := time.Now()
+
+		// the status is synced once a minute, so the period is checked as well
+		live := (current_status == constants.SubscriptionStatusActive || current_status == constants.SubscriptionStatusTrialing) &&
+			!current_sub.ValidFrom.Time.After(now) && current_sub.ValidUntil.Time.After(now)
+
+		if err != nil || !live {
+			refund_flag = true
+			reason = constants.PaymentAttemptReasonUpgradeNotActive
+			return nil, ErrPaymentRejected.Withf("subscription to upgrade is no longer active")
+		}
+
+		event_reason := "upgraded"
+
+		if current_status == constants.SubscriptionStatusTrialing {
+			event_reason = "trial converted"
+			err = qtx.MarkTrialConvertedBySubscriptionID(ctx, current_sub.ID)
+
+			if err != nil {
+				refund_flag = true
+				return nil, fmt.Errorf("Unable to convert trial")
+			}
+		}
+
+		new_sub_valid_from.Time = time.Now()
+		new_sub_valid_to.Time = new_sub_valid_from.Time.AddDate(0, 0, 30)
+
+		_, err = qtx.UpdateSubscriptionValidUntil(ctx, sqlc.UpdateSubscriptionValidUntilParams{ID: current_sub.ID, ValidUntil: new_sub_valid_from})
+
+		if err == nil {
+			err = transitionSubscription(ctx, qtx, current_sub.ID, user_uuid, constants.SubscriptionStatusExpired, event_reason)
+		}
+
+		if err != nil {
+			refund_flag = true
+			return nil, fmt.Errorf("Unable to end current subscription")
+		}
+	} else {
+		// a purchase made while a subscription is still live is queued after it
+		sub, err := qtx.GetLatestLiveSubscriptionByUserID(ctx, user_uuid)
+
+		if err == nil {
 			new_sub_valid_from.Time = sub.ValidUntil.Time.AddDate(0, 0, 1)
 			new_sub_valid_to.Time = new_sub_valid_from.Time.AddDate(0, 0, 30)
+			new_sub_valid_from.Valid = true
+			new_sub_valid_to.Valid = true
+			sub_id = sub.ID
 		}
-		new_sub_valid_from.Valid = true
-		new_sub_valid_to.Valid = true
-		sub_id = sub.ID
 	}
 
-	sub_row, err := qtx.CreateSubscription(context.Background(), sqlc.CreateSubscriptionParams{
+	new_sub_status := constants.SubscriptionStatusActive
+
+	if new_sub_valid_from.Time.After(time.Now()) {
+		new_sub_status = constants.SubscriptionStatusPending
+	}
+
+	sub_row, err := qtx.CreateSubscription(ctx, sqlc.CreateSubscriptionParams{
 		UserID:            user_uuid,
 		PlanID:            plan_uuid,
 		PlanType:          plan.Name,
@@ -135,8 +364,17 @@ func (s *PaymentService) VerifyPayment(user_id uuid.UUID, plan_type string, orde
 		RazorpayPaymentID: razorpay_payment_id,
 		RazorpayOrderID:   razorpay_order_id,
 		RazorpaySignature: razorpay_signature,
+		Amount:            order.Amount,
+		ProrationCredit:   order.ProrationCredit,
+		UpgradedFrom:      order.UpgradeFromSubscriptionID,
+		Status:            string(new_sub_status),
+		Currency:          order.Currency,
 	})
 
+	if err == nil {
+		err = recordSubscriptionCreated(ctx, qtx, sub_row.ID, user_uuid, new_sub_status, "payment verified")
+	}
+
 	if err != nil {
 		refund_flag = true
 		return nil, fmt.Errorf("Unable to create subscription")
@@ -144,71 +382,132 @@ func (s *PaymentService) VerifyPayment(user_id uuid.UUID, plan_type string, orde
 
 	sub_id = sub_row.ID
 
-	empty_usage := types.Usage{PublicRoomQuota: 0, RoomSchedulingQuota: 0}
-	empty_usage_json, _ := utils.ConvertMapTypeToBytes(empty_usage)
+	var moved_usage int64 = 0
+
+	if order.UpgradeFromSubscriptionID.Valid {
+		// carry the usage of the current period over to the upgraded subscription
+		moved_usage, err = qtx.MoveSubscriptionUsageToSubscription(ctx, sqlc.MoveSubscriptionUsageToSubscriptionParams{
+			NewSubscriptionID: sub_id,
+			ValidUntil:        new_sub_valid_to,
+			SubscriptionID:    order.UpgradeFromSubscriptionID,
+		})
 
-	_, err = qtx.CreateSubscriptionUsage(context.Background(), sqlc.CreateSubscriptionUsageParams{
-		UserID:         user_uuid,
-		ValidFrom:      new_sub_valid_from,
-		ValidUntil:     new_sub_valid_to,
-		Column4:        string(empty_usage_json),
-		SubscriptionID: sub_id,
+		if err != nil {
+			refund_flag = true
+			return nil, fmt.Errorf("Unable to move subscription usage")
+		}
+	}
+
+	if moved_usage == 0 {
+		empty_usage := types.Usage{PublicRoomQuota: 0, RoomSchedulingQuota: 0}
+		empty_usage_json, _ := utils.ConvertMapTypeToBytes(empty_usage)
+
+		_, err = qtx.CreateSubscriptionUsage(ctx, sqlc.CreateSubscriptionUsageParams{
+			UserID:         user_uuid,
+			ValidFrom:      new_sub_valid_from,
+			ValidUntil:     new_sub_valid_to,
+			Column4:        string(empty_usage_json),
+			SubscriptionID: sub_id,
+		})
+
+		if err != nil {
+			refund_flag = true
+			return nil, fmt.Errorf("Unable to create subscription usage")
+		}
+	}
+
+	// the coupon may have reached a cap since the order was created; the payment is
+	// refunded rather than honouring the discount past it
+	if order.CouponID.Valid {
+		err = redeemCoupon(ctx, qtx, order, user_uuid, sub_id)
+
+		if errors.Is(err, ErrCouponExhausted) {
+			refund_flag = true
+			reason = constants.PaymentAttemptReasonCouponExhausted
+			return nil, err
+		}
+
+		if err != nil {
+			refund_flag = true
+			return nil, fmt.Errorf("Unable to record coupon redemption")
+		}
+	}
+
+	invoice, err := issueInvoice(ctx, qtx, order, sub_id, time.Now())
+
+	if err != nil {
+		refund_flag = true
+		return nil, fmt.Errorf("Unable to issue invoice")
+	}
+
+	err = enqueueNotification(ctx, qtx, user_uuid, sub_id, lib.NewSubscriptionEvent{
+		PlanType:      sub_row.PlanType,
+		ValidFrom:     sub_row.ValidFrom.Time,
+		ValidUntil:    sub_row.ValidUntil.Time,
+		PlanInfo:      constants.GetPlans()[sub_row.PlanType],
+		Amount:        utils.ConvertPgtypeNumericToInt64(sub_row.Amount),
+		Currency:      sub_row.Currency,
+		InvoiceID:     invoice.ID.String(),
+		InvoiceNumber: invoice.InvoiceNumber,
 	})
 
 	if err != nil {
 		refund_flag = true
-		return nil, fmt.Errorf("Unable to create subscription usage")
+		return nil, fmt.Errorf("Unable to queue notification")
 	}
 
-	err = tx.Commit(context.Background())
+	err = qtx.UpdatePaymentOrderStatus(ctx, sqlc.UpdatePaymentOrderStatusParams{ID: order.ID, Status: string(constants.PaymentOrderStatusPaid)})
+
+	if err != nil {
+		refund_flag = true
+		return nil, fmt.Errorf("Unable to update order status")
+	}
+
+	err = tx.Commit(ctx)
 
 	if err != nil {
 		refund_flag = true
 		return nil, fmt.Errorf("Failed to commit transaction")
 	}
 
-	lib.SendNotification(
-		user_uuid.String(),
-		"Upgraded to "+sub_row.PlanType+" Successfully",
-		"",
-		map[string]interface{}{
-			"plan_type":   sub_row.PlanType,
-			"valid_until": sub_row.ValidUntil.Time.Format(time.RFC3339),
-			"valid_from":  sub_row.ValidFrom.Time.Format(time.RFC3339),
-			"plan_info":   constants.GetPlans()[sub_row.PlanType],
-		},
-		[]string{"in-app", "email"},
-		"NEW_SUBSCRIPTION",
-	)
+	reason = constants.PaymentAttemptReasonVerified
 
 	return sub_row, nil
 }
 
-func (s *PaymentService) refund(subscription_id pgtype.UUID, user_id pgtype.UUID, razorpay_payment_id string, amount int) (map[string]interface{}, error) {
-	client := config.GetRazorpayClient()
+// recordPaymentAttempt stores and logs the outcome of a VerifyPayment call. Failing to
+// record it must not change the result of the verification, so errors are only logged.
+func (s *PaymentService) recordPaymentAttempt(ctx context.Context, user_id pgtype.UUID, plan_type string, order_id string, razorpay_order_id string, razorpay_payment_id string, client_ip string, reason constants.PaymentAttemptReason, refunded bool, verify_err error) {
+	outcome := constants.PaymentAttemptSucceeded
+	var error_message pgtype.Text
 
-	_, err := s.query.GetRefundByPaymentID(context.Background(), razorpay_payment_id)
-
-	if err == nil {
-		return nil, fmt.Errorf("Refund already processed for this payment")
+	if verify_err != nil {
+		outcome = constants.PaymentAttemptFailed
+		error_message = pgtype.Text{String: verify_err.Error(), Valid: true}
 	}
 
-	data := map[string]interface{}{
-		"speed": "normal",
-		"notes": map[string]interface{}{},
-	}
-	body, err := client.Payment.Refund(razorpay_payment_id, amount, data, nil)
-
-	if err != nil {
-		return nil, err
+	if verify_err != nil {
+		slog.WarnContext(ctx, "Payment verification failed", "reason", reason, "refunded", refunded, "error", verify_err)
+	} else {
+		slog.InfoContext(ctx, "Payment verified")
 	}
 
-	s.query.CreateNewRefund(context.Background(), sqlc.CreateNewRefundParams{
-		SubscriptionID:    subscription_id,
-		RazorpayPaymentID: razorpay_payment_id,
-		Amount:            pgtype.Numeric{Int: big.NewInt(int64(amount)), Valid: true},
+	err := s.query.CreatePaymentAttempt(ctx, sqlc.CreatePaymentAttemptParams{
 		UserID:            user_id,
+		PlanType:          plan_type,
+		OrderID:           order_id,
+		RazorpayOrderID:   razorpay_order_id,
+		RazorpayPaymentID: razorpay_payment_id,
+		Outcome:           string(outcome),
+		ReasonCode:        string(reason),
+		ErrorMessage:      error_message,
+		Refunded:          refunded,
+		ClientIp:          pgtype.Text{String: client_ip, Valid: client_ip != ""},
 	})
 
-	return body, nil
+	if err != nil {
+		slog.ErrorContext(ctx, "Unable to record payment attempt", "error", err)
+	}
+
+	metrics.PaymentVerifications.WithLabelValues(string(outcome), string(reason)).Inc()
 }
@@ -0,0 +1,603 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/parbhat-cpp/fuse/subscriptions/constants"
+	"github.com/parbhat-cpp/fuse/subscriptions/internal/config"
+	"github.com/parbhat-cpp/fuse/subscriptions/internal/db/sqlc"
+	"github.com/parbhat-cpp/fuse/subscriptions/internal/tracing"
+	"github.com/parbhat-cpp/fuse/subscriptions/pkg/utils"
+	razorpay "github.com/razorpay/razorpay-go"
+)
+
+type ReconciliationService struct {
+	query  *sqlc.Queries
+	refund *RefundService
+}
+
+// razorpay returns at most 100 entities per page
+const razorpayPageSize = 100
+
+type Discrepancy struct {
+	Kind              constants.DiscrepancyKind `json:"kind"`
+	RazorpayPaymentID string                    `json:"razorpay_payment_id"`
+	RazorpayOrderID   string                    `json:"razorpay_order_id,omitempty"`
+	SubscriptionID    string                    `json:"subscription_id,omitempty"`
+	UserID            string                    `json:"user_id,omitempty"`
+	ProviderAmount    int64                     `json:"provider_amount"`
+	LocalAmount       int64                     `json:"local_amount"`
+	Currency          string                    `json:"currency,omitempty"`
+	Detail            string                    `json:"detail"`
+	Repaired          bool                      `json:"repaired"`
+	RepairError       string                    `json:"repair_error,omitempty"`
+}
+
+type ReconciliationReport struct {
+	From                 time.Time     `json:"from"`
+	To                   time.Time     `json:"to"`
+	PaymentsChecked      int           `json:"payments_checked"`
+	SubscriptionsChecked int           `json:"subscriptions_checked"`
+	RefundsChecked       int           `json:"refunds_checked"`
+	Discrepancies        []Discrepancy `json:"discrepancies"`
+}
+
+// Unresolved counts the discrepancies that were not repaired.
+func (r ReconciliationReport) Unresolved() int {
+	unresolved := 0
+
+	for _, d := range r.Discrepancies {
+		if !d.Repaired {
+			unresolved++
+		}
+	}
+
+	return unresolved
+}
+
+type providerPayment struct {
+	id              string
+	order_id        string
+	status          string
+	currency        string
+	amount          int64
+	amount_refunded int64
+}
+
+type providerRefund struct {
+	id         string
+	payment_id string
+	status     string
+	amount     int64
+}
+
+func NewReconciliationService(query *sqlc.Queries, refund *RefundService) *ReconciliationService {
+	return &ReconciliationService{
+		query:  query,
+		refund: refund,
+	}
+}
+
+/**
+ * Compares razorpay payments and refunds created in [from, to) with the subscriptions
+ * and refunds tables. With repair set, refunds made on razorpay but missing locally are
+ * recorded; every other discrepancy is only reported.
+ * @param ctx: context.Context
+ * @param from: time.Time
+ * @param to: time.Time
+ * @param repair: bool
+ * @return ReconciliationReport, error
+ */
+func (s *ReconciliationService) Reconcile(ctx context.Context, from time.Time, to time.Time, repair bool) (ReconciliationReport, error) {
+	if !from.Before(to) {
+		return ReconciliationReport{}, fmt.Errorf("Reconciliation range is empty")
+	}
+
+	client := config.GetRazorpayClient()
+	report := ReconciliationReport{From: from, To: to, Discrepancies: []Discrepancy{}}
+
+	payments, err := listRazorpayPayments(ctx, client, from, to)
+
+	if err != nil {
+		return ReconciliationReport{}, fmt.Errorf("Unable to list razorpay payments: %w", err)
+	}
+
+	seen_payments := map[string]providerPayment{}
+
+	for _, payment := range payments {
+		seen_payments[payment.id] = payment
+
+		// created, authorized and failed payments took no money
+		if payment.status != "captured" && payment.status != "refunded" {
+			continue
+		}
+
+		report.PaymentsChecked++
+
+		sub, err := s.query.GetSubscriptionByPaymentID(ctx, payment.id)
+
+		if errors.Is(err, pgx.ErrNoRows) {
+			if payment.amount_refunded < payment.amount {
+				report.Discrepancies = append(report.Discrepancies, Discrepancy{
+					Kind:              constants.DiscrepancyCapturedWithoutSubscription,
+					RazorpayPaymentID: payment.id,
+					RazorpayOrderID:   payment.order_id,
+					ProviderAmount:    payment.amount - payment.amount_refunded,
+					Currency:          payment.currency,
+					Detail:            "captured payment has no subscription and was not fully refunded",
+				})
+			}
+			continue
+		}
+
+		if err != nil {
+			return ReconciliationReport{}, err
+		}
+
+		local_amount := utils.ConvertPgtypeNumericToInt64(sub.Amount)
+
+		if local_amount != payment.amount || sub.Currency != payment.currency {
+			report.Discrepancies = append(report.Discrepancies, Discrepancy{
+				Kind:              constants.DiscrepancyAmountMismatch,
+				RazorpayPaymentID: payment.id,
+				RazorpayOrderID:   payment.order_id,
+				SubscriptionID:    sub.ID.String(),
+				UserID:            sub.UserID.String(),
+				ProviderAmount:    payment.amount,
+				LocalAmount:       local_amount,
+				Currency:          payment.currency,
+				Detail:            fmt.Sprintf("razorpay charged %s, subscription records %s", payment.currency, sub.Currency),
+			})
+		}
+	}
+
+	range_from := pgtype.Timestamptz{Time: from, Valid: true}
+	range_to := pgtype.Timestamptz{Time: to, Valid: true}
+
+	subs, err := s.query.GetPaidSubscriptionsCreatedBetween(ctx, sqlc.GetPaidSubscriptionsCreatedBetweenParams{CreatedFrom: range_from, CreatedTo: range_to})
+
+	if err != nil {
+		return ReconciliationReport{}, err
+	}
+
+	for _, sub := range subs {
+		report.SubscriptionsChecked++
+
+		payment, exists := seen_payments[sub.RazorpayPaymentID]
+		var fetch_err error
+
+		// the payment may have been created just before the range
+		if !exists {
+			payment, fetch_err = fetchRazorpayPayment(ctx, client, sub.RazorpayPaymentID)
+		}
+
+		if fetch_err != nil || (payment.status != "captured" && payment.status != "refunded") {
+			detail := "payment status is " + payment.status
+
+			if fetch_err != nil {
+				detail = "payment not found on razorpay: " + fetch_err.Error()
+			}
+
+			report.Discrepancies = append(report.Discrepancies, Discrepancy{
+				Kind:              constants.DiscrepancySubscriptionWithoutPayment,
+				RazorpayPaymentID: sub.RazorpayPaymentID,
+				RazorpayOrderID:   sub.RazorpayOrderID,
+				SubscriptionID:    sub.ID.String(),
+				UserID:            sub.UserID.String(),
+				LocalAmount:       utils.ConvertPgtypeNumericToInt64(sub.Amount),
+				Currency:          sub.Currency,
+				Detail:            detail,
+			})
+		}
+	}
+
+	refunds, err := listRazorpayRefunds(ctx, client, from, to)
+
+	if err != nil {
+		return ReconciliationReport{}, fmt.Errorf("Unable to list razorpay refunds: %w", err)
+	}
+
+	refunds_by_payment := map[string]bool{}
+
+	for _, refund := range refunds {
+		refunds_by_payment[refund.payment_id] = true
+	}
+
+	for payment_id := range refunds_by_payment {
+		report.RefundsChecked++
+
+		// earlier refunds of the payment may fall outside the range
+		provider_refunds, err := fetchRazorpayRefunds(ctx, client, payment_id)
+
+		if err != nil {
+			return ReconciliationReport{}, fmt.Errorf("Unable to fetch razorpay refunds: %w", err)
+		}
+
+		discrepancies, err := s.compareRefunds(ctx, client, payment_id, provider_refunds, repair)
+
+		if err != nil {
+			return ReconciliationReport{}, err
+		}
+
+		report.Discrepancies = append(report.Discrepancies, discrepancies...)
+	}
+
+	local_refunds, err := s.query.GetRefundsCreatedBetween(ctx, sqlc.GetRefundsCreatedBetweenParams{CreatedFrom: range_from, CreatedTo: range_to})
+
+	if err != nil {
+		return ReconciliationReport{}, err
+	}
+
+	for _, local_refund := range local_refunds {
+		if refunds_by_payment[local_refund.RazorpayPaymentID] {
+			continue
+		}
+
+		report.RefundsChecked++
+
+		provider_refunds, err := fetchRazorpayRefunds(ctx, client, local_refund.RazorpayPaymentID)
+
+		if err != nil {
+			return ReconciliationReport{}, fmt.Errorf("Unable to fetch razorpay refunds: %w", err)
+		}
+
+		discrepancies, err := s.compareRefunds(ctx, client, local_refund.RazorpayPaymentID, provider_refunds, repair)
+
+		if err != nil {
+			return ReconciliationReport{}, err
+		}
+
+		report.Discrepancies = append(report.Discrepancies, discrepancies...)
+	}
+
+	return report, nil
+}
+
+/**
+ * Reconciles the last RECONCILE_WINDOW_DAYS and logs the discrepancies found.
+ * Refunds missing locally are repaired when RECONCILE_AUTO_REPAIR is set.
+ * @return error
+ */
+func (s *ReconciliationService) ReconcileRecent() error {
+	ctx := context.Background()
+	cfg := config.LoadEnv()
+	to := time.Now()
+	from := to.AddDate(0, 0, -cfg.RECONCILE_WINDOW_DAYS)
+
+	report, err := s.Reconcile(ctx, from, to, cfg.RECONCILE_AUTO_REPAIR)
+
+	if err != nil {
+		return err
+	}
+
+	for _, d := range report.Discrepancies {
+		slog.WarnContext(ctx,
+			"Reconciliation discrepancy",
+			"kind", d.Kind, "razorpay_payment_id", d.RazorpayPaymentID, "user_id", d.UserID, "repaired", d.Repaired, "detail", d.Detail,
+		)
+	}
+
+	slog.InfoContext(ctx,
+		"Reconciled payments",
+		"payments", report.PaymentsChecked, "subscriptions", report.SubscriptionsChecked, "refunds", report.RefundsChecked,
+		"discrepancies", len(report.Discrepancies), "unresolved", report.Unresolved(),
+	)
+
+	return nil
+}
+
+// compareRefunds compares the razorpay refunds of a payment with its local refund rows.
+// A refund processed on razorpay but missing locally is the one case that is safe to
+// repair: each is recorded with its own id and amount, along with its credit note, as the
+// refund worker would have. Ones still pending on razorpay are only reported, and refunds
+// still pending or submitted locally are left to the refund worker.
+func (s *ReconciliationService) compareRefunds(ctx context.Context, client *razorpay.Client, payment_id string, provider_refunds []providerRefund, repair bool) ([]Discrepancy, error) {
+	local_refunds, err := s.query.GetRefundsByPaymentID(ctx, payment_id)
+
+	if err != nil {
+		return nil, err
+	}
+
+	// a payment can be refunded in parts; the processed ones are compared as a total
+	var local_amount int64 = 0
+	var local_refund sqlc.GetRefundsByPaymentIDRow
+	local_exists := len(local_refunds) > 0
+	local_status := constants.RefundStatusFailed
+	local_ids := map[string]bool{}
+
+	for _, refund := range local_refunds {
+		status := constants.RefundStatus(refund.Status)
+
+		if status == constants.RefundStatusPending || status == constants.RefundStatusSubmitted {
+			return nil, nil
+		}
+
+		if status == constants.RefundStatusProcessed {
+			local_amount += utils.ConvertPgtypeNumericToInt64(refund.Amount)
+			local_status = constants.RefundStatusProcessed
+		}
+
+		if refund.RazorpayRefundID.Valid {
+			local_ids[refund.RazorpayRefundID.String] = true
+		}
+
+		if !local_refund.ID.Valid {
+			local_refund = refund
+		}
+	}
+
+	discrepancies := []Discrepancy{}
+	var processed int64 = 0
+	failed := 0
+
+	for _, refund := range provider_refunds {
+		switch refund.status {
+		case "processed", "pending":
+			if local_ids[refund.id] {
+				processed += refund.amount
+				continue
+			}
+
+			discrepancy := Discrepancy{
+				Kind:              constants.DiscrepancyRefundNotRecorded,
+				RazorpayPaymentID: payment_id,
+				ProviderAmount:    refund.amount,
+				Detail:            fmt.Sprintf("refund %s on razorpay has no local record", refund.id),
+			}
+
+			if refund.status == "pending" {
+				// not settled yet, so no credit note is issued for it
+				discrepancy.Detail = fmt.Sprintf("refund %s pending on razorpay has no local record", refund.id)
+			} else if repair {
+				err = s.repairMissingRefund(ctx, client, &discrepancy, refund.id)
+
+				if err != nil {
+					discrepancy.RepairError = err.Error()
+				} else {
+					discrepancy.Repaired = true
+				}
+			}
+
+			discrepancies = append(discrepancies, discrepancy)
+		case "failed":
+			failed++
+		}
+	}
+
+	if !local_exists {
+		return discrepancies, nil
+	}
+
+	// the refunds missing locally are reported above; the rest are compared as a total
+	switch {
+	case local_status == constants.RefundStatusFailed:
+		if processed > 0 {
+			discrepancies = append(discrepancies, Discrepancy{
+				Kind:              constants.DiscrepancyRefundAmountMismatch,
+				RazorpayPaymentID: payment_id,
+				SubscriptionID:    local_refund.SubscriptionID.String(),
+				ProviderAmount:    processed,
+				LocalAmount:       local_amount,
+				Currency:          local_refund.Currency,
+				Detail:            "razorpay refunded a payment whose local refunds failed",
+			})
+		}
+
+	case processed == 0 && failed > 0:
+		discrepancies = append(discrepancies, Discrepancy{
+			Kind:              constants.DiscrepancyRefundFailed,
+			RazorpayPaymentID: payment_id,
+			SubscriptionID:    local_refund.SubscriptionID.String(),
+			LocalAmount:       local_amount,
+			Currency:          local_refund.Currency,
+			Detail:            "razorpay failed the refunds recorded locally",
+		})
+
+	case processed == 0:
+		discrepancies = append(discrepancies, Discrepancy{
+			Kind:              constants.DiscrepancyRefundMissingOnProvider,
+			RazorpayPaymentID: payment_id,
+			SubscriptionID:    local_refund.SubscriptionID.String(),
+			LocalAmount:       local_amount,
+			Currency:          local_refund.Currency,
+			Detail:            "refunds recorded locally do not exist on razorpay",
+		})
+
+	case processed != local_amount:
+		discrepancies = append(discrepancies, Discrepancy{
+			Kind:              constants.DiscrepancyRefundAmountMismatch,
+			RazorpayPaymentID: payment_id,
+			SubscriptionID:    local_refund.SubscriptionID.String(),
+			ProviderAmount:    processed,
+			LocalAmount:       local_amount,
+			Currency:          local_refund.Currency,
+			Detail:            "refunded amount on razorpay differs from the local records",
+		})
+	}
+
+	return discrepancies, nil
+}
+
+// repairMissingRefund records a razorpay refund against the payment's subscription, or
+// against the payment order's user when the payment never got a subscription.
+func (s *ReconciliationService) repairMissingRefund(ctx context.Context, client *razorpay.Client, discrepancy *Discrepancy, razorpay_refund_id string) error {
+	payment, err := fetchRazorpayPayment(ctx, client, discrepancy.RazorpayPaymentID)
+
+	if err != nil {
+		return err
+	}
+
+	discrepancy.RazorpayOrderID = payment.order_id
+	discrepancy.Currency = payment.currency
+
+	var subscription_id pgtype.UUID
+	var user_id pgtype.UUID
+
+	sub, err := s.query.GetSubscriptionByPaymentID(ctx, payment.id)
+
+	if err == nil {
+		subscription_id = sub.ID
+		user_id = sub.UserID
+	} else if errors.Is(err, pgx.ErrNoRows) {
+		order, err := s.query.GetPaymentOrderByRazorpayOrderID(ctx, payment.order_id)
+
+		if err != nil {
+			return fmt.Errorf("Unable to find the payment's order: %w", err)
+		}
+
+		user_id = order.UserID
+	} else {
+		return err
+	}
+
+	discrepancy.SubscriptionID = subscription_id.String()
+	discrepancy.UserID = user_id.String()
+
+	return s.refund.recordProviderRefund(ctx, subscription_id, user_id, payment.id, razorpay_refund_id, discrepancy.ProviderAmount, constants.Currency(payment.currency))
+}
+
+// listRazorpayPayments pages through the payments created in [from, to).
+func listRazorpayPayments(ctx context.Context, client *razorpay.Client, from time.Time, to time.Time) ([]providerPayment, error) {
+	payments := []providerPayment{}
+
+	err := pageRazorpay(from, to, func(params map[string]interface{}) ([]interface{}, error) {
+		body, err := tracing.Razorpay(ctx, "payments.all", func() (map[string]interface{}, error) {
+			return client.Payment.All(params, nil)
+		})
+
+		if err != nil {
+			return nil, err
+		}
+
+		items, _ := body["items"].([]interface{})
+
+		for _, item := range items {
+			if entity, ok := item.(map[string]interface{}); ok {
+				payments = append(payments, parseRazorpayPayment(entity))
+			}
+		}
+
+		return items, nil
+	})
+
+	return payments, err
+}
+
+// listRazorpayRefunds pages through the refunds created in [from, to).
+func listRazorpayRefunds(ctx context.Context, client *razorpay.Client, from time.Time, to time.Time) ([]providerRefund, error) {
+	refunds := []providerRefund{}
+
+	err := pageRazorpay(from, to, func(params map[string]interface{}) ([]interface{}, error) {
+		body, err := tracing.Razorpay(ctx, "refunds.all", func() (map[string]interface{}, error) {
+			return client.Refund.All(params, nil)
+		})
+
+		if err != nil {
+			return nil, err
+		}
+
+		items, _ := body["items"].([]interface{})
+
+		for _, item := range items {
+			if entity, ok := item.(map[string]interface{}); ok {
+				refunds = append(refunds, parseRazorpayRefund(entity))
+			}
+		}
+
+		return items, nil
+	})
+
+	return refunds, err
+}
+
+// pageRazorpay calls fetch with count and skip until a short page comes back. Razorpay
+// takes from and to as unix timestamps; to is inclusive, so one second is taken off.
+func pageRazorpay(from time.Time, to time.Time, fetch func(params map[string]interface{}) ([]interface{}, error)) error {
+	for skip := 0; ; skip += razorpayPageSize {
+		items, err := fetch(map[string]interface{}{
+			"from":  from.Unix(),
+			"to":    to.Unix() - 1,
+			"count": razorpayPageSize,
+			"skip":  skip,
+		})
+
+		if err != nil {
+			return err
+		}
+
+		if len(items) < razorpayPageSize {
+			return nil
+		}
+	}
+}
+
+func fetchRazorpayPayment(ctx context.Context, client *razorpay.Client, payment_id string) (providerPayment, error) {
+	body, err := tracing.Razorpay(ctx, "payments.fetch", func() (map[string]interface{}, error) {
+		return client.Payment.Fetch(payment_id, nil, nil)
+	})
+
+	if err != nil {
+		return providerPayment{}, err
+	}
+
+	return parseRazorpayPayment(body), nil
+}
+
+func fetchRazorpayRefunds(ctx context.Context, client *razorpay.Client, payment_id string) ([]providerRefund, error) {
+	body, err := tracing.Razorpay(ctx, "payments.refunds", func() (map[string]interface{}, error) {
+		return client.Payment.FetchMultipleRefund(payment_id, map[string]interface{}{"count": razorpayPageSize}, nil)
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	refunds := []providerRefund{}
+	items, _ := body["items"].([]interface{})
+
+	for _, item := range items {
+		if entity, ok := item.(map[string]interface{}); ok {
+			refunds = append(refunds, parseRazorpayRefund(entity))
+		}
+	}
+
+	return refunds, nil
+}
+
+func parseRazorpayPayment(entity map[string]interface{}) providerPayment {
+	return providerPayment{
+		id:              razorpayString(entity, "id"),
+		order_id:        razorpayString(entity, "order_id"),
+		status:          razorpayString(entity, "status"),
+		currency:        razorpayString(entity, "currency"),
+		amount:          razorpayInt64(entity, "amount"),
+		amount_refunded: razorpayInt64(entity, "amount_refunded"),
+	}
+}
+
+func parseRazorpayRefund(entity map[string]interface{}) providerRefund {
+	return providerRefund{
+		id:         razorpayString(entity, "id"),
+		payment_id: razorpayString(entity, "payment_id"),
+		status:     razorpayString(entity, "status"),
+		amount:     razorpayInt64(entity, "amount"),
+	}
+}
+
+func razorpayString(entity map[string]interface{}, key string) string {
+	value, _ := entity[key].(string)
+	return value
+}
+
+// razorpayInt64 reads a number; razorpay amounts are integers in the minor unit but are
+// decoded as float64.
+func razorpayInt64(entity map[string]interface{}, key string) int64 {
+	value, _ := entity[key].(float64)
+	return int64(value)
+}
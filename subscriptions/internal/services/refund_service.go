@@ -0,0 +1,519 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/parbhat-cpp/fuse/subscriptions/constants"
+	"github.com/parbhat-cpp/fuse/subscriptions/internal/apperrors"
+	"github.com/parbhat-cpp/fuse/subscriptions/internal/config"
+	"github.com/parbhat-cpp/fuse/subscriptions/internal/db/sqlc"
+	"github.com/parbhat-cpp/fuse/subscriptions/internal/metrics"
+	"github.com/parbhat-cpp/fuse/subscriptions/internal/tracing"
+	"github.com/parbhat-cpp/fuse/subscriptions/pkg/utils"
+	razorpay "github.com/razorpay/razorpay-go"
+	rzperrors "github.com/razorpay/razorpay-go/errors"
+)
+
+type RefundService struct {
+	query *sqlc.Queries
+	pool  *pgxpool.Pool
+}
+
+var ErrRefundExists = apperrors.New(apperrors.CodeConflict, "refund already recorded for this payment, subscription and reason")
+var ErrPaymentFullyRefunded = apperrors.New(apperrors.CodeConflict, "payment has been refunded in full")
+var ErrRefundNotFound = apperrors.New(apperrors.CodeNotFound, "refund not found")
+var ErrInvalidRefundFilter = apperrors.New(apperrors.CodeInvalidRequest, "invalid refund filter")
+var ErrInvalidWebhookSignature = apperrors.New(apperrors.CodeInvalidRequest, "invalid webhook signature")
+
+// claimed refunds are skipped by other workers until the lease runs out
+const refundLease = 5 * time.Minute
+const refundBatchSize = 20
+
+const refundBaseBackoff = time.Minute
+const refundMaxBackoff = 6 * time.Hour
+
+const defaultRefundListLimit = 100
+const maxRefundListLimit = 500
+
+// providerRefundStatus is the part of a razorpay refund entity the worker acts on.
+type providerRefundStatus struct {
+	id        string
+	status    string
+	refund_id string // our refund id, sent to razorpay in the notes
+}
+
+func NewRefundService(query *sqlc.Queries, pool *pgxpool.Pool) *RefundService {
+	return &RefundService{
+		query: query,
+		pool:  pool,
+	}
+}
+
+/**
+ * Submits pending refunds to razorpay and polls submitted ones in case a webhook was
+ * missed. Failed submissions are retried with exponential backoff until
+ * REFUND_MAX_ATTEMPTS; refunds razorpay rejects outright fail straight away.
+ * @return error
+ */
+func (s *RefundService) ProcessRefunds() error {
+	ctx := context.Background()
+	client := config.GetRazorpayClient()
+
+	refunds, err := s.query.ClaimDueRefunds(ctx, sqlc.ClaimDueRefundsParams{
+		LeaseUntil: pgtype.Timestamptz{Time: time.Now().Add(refundLease), Valid: true},
+		BatchSize:  refundBatchSize,
+	})
+
+	if err != nil {
+		return err
+	}
+
+	for _, refund := range refunds {
+		if constants.RefundStatus(refund.Status) == constants.RefundStatusSubmitted {
+			err = s.pollRefund(ctx, client, refund)
+		} else {
+			err = s.submitRefund(ctx, client, refund)
+		}
+
+		if err != nil {
+			slog.ErrorContext(ctx, "Unable to process refund", "refund_id", uuid.UUID(refund.ID.Bytes), "razorpay_payment_id", refund.RazorpayPaymentID, "error", err)
+		}
+	}
+
+	return nil
+}
+
+/**
+ * Applies a razorpay refund.processed or refund.failed webhook. Other events and
+ * refunds this service did not make are acknowledged and ignored.
+ * @param ctx: context.Context
+ * @param body: []byte
+ * @param signature: string
+ * @return error
+ */
+func (s *RefundService) HandleRazorpayWebhook(ctx context.Context, body []byte, signature string) error {
+	cfg := config.LoadEnv()
+
+	err := utils.WebhookVerify(body, signature, cfg.RAZORPAY_WEBHOOK_SECRET)
+
+	if err != nil {
+		return ErrInvalidWebhookSignature.Wrap(err)
+	}
+
+	var webhook struct {
+		Event   string `json:"event"`
+		Payload struct {
+			Refund struct {
+				Entity map[string]interface{} `json:"entity"`
+			} `json:"refund"`
+		} `json:"payload"`
+	}
+
+	err = json.Unmarshal(body, &webhook)
+
+	if err != nil {
+		return err
+	}
+
+	if webhook.Event != "refund.processed" && webhook.Event != "refund.failed" {
+		return nil
+	}
+
+	provider := parseRazorpayRefundStatus(webhook.Payload.Refund.Entity)
+
+	refund, err := s.query.GetRefundByRazorpayRefundID(ctx, pgtype.Text{String: provider.id, Valid: true})
+
+	// the webhook can arrive before the worker has stored the razorpay refund id
+	if errors.Is(err, pgx.ErrNoRows) && provider.refund_id != "" {
+		refund_id, parse_err := uuid.Parse(provider.refund_id)
+
+		if parse_err == nil {
+			refund, err = s.query.GetRefundByID(ctx, utils.ConvertGoogleUUIDToPgtypeUUID(refund_id))
+		}
+	}
+
+	if errors.Is(err, pgx.ErrNoRows) {
+		slog.WarnContext(ctx, "Ignoring webhook for unknown refund", "event", webhook.Event, "razorpay_refund_id", provider.id)
+		return nil
+	}
+
+	if err != nil {
+		return err
+	}
+
+	return s.applyProviderStatus(ctx, refund, provider)
+}
+
+/**
+ * Returns refunds in a status, most recently updated first. Status defaults to failed.
+ * @param ctx: context.Context
+ * @param status: string
+ * @param limit: int
+ * @return []sqlc.Refund, error
+ */
+func (s *RefundService) GetRefunds(ctx context.Context, status string, limit int) ([]sqlc.Refund, error) {
+	if status == "" {
+		status = string(constants.RefundStatusFailed)
+	}
+
+	switch constants.RefundStatus(status) {
+	case constants.RefundStatusPending, constants.RefundStatusSubmitted, constants.RefundStatusProcessed, constants.RefundStatusFailed:
+	default:
+		return nil, ErrInvalidRefundFilter.Withf("unknown status %s", status)
+	}
+
+	if limit < 0 || limit > maxRefundListLimit {
+		return nil, ErrInvalidRefundFilter.Withf("limit must be between 1 and %d", maxRefundListLimit)
+	}
+
+	if limit == 0 {
+		limit = defaultRefundListLimit
+	}
+
+	return s.query.GetRefundsByStatus(ctx, sqlc.GetRefundsByStatusParams{
+		Status: status,
+		Limit:  int32(limit),
+	})
+}
+
+/**
+ * Puts a failed refund back in the queue with a fresh set of attempts.
+ * @param ctx: context.Context
+ * @param refund_id: uuid.UUID
+ * @return error
+ */
+func (s *RefundService) RetryRefund(ctx context.Context, refund_id uuid.UUID) error {
+	retried, err := s.query.RetryFailedRefund(ctx, utils.ConvertGoogleUUIDToPgtypeUUID(refund_id))
+
+	if err != nil {
+		return err
+	}
+
+	if retried == 0 {
+		return ErrRefundNotFound
+	}
+
+	return nil
+}
+
+// submitRefund creates the refund on razorpay. The refund id travels in the notes so a
+// refund that reached razorpay on an attempt that then failed is found, not repeated.
+func (s *RefundService) submitRefund(ctx context.Context, client *razorpay.Client, refund sqlc.Refund) error {
+	cfg := config.LoadEnv()
+	refund_id := uuid.UUID(refund.ID.Bytes).String()
+
+	provider, found, err := findRazorpayRefund(ctx, client, refund.RazorpayPaymentID, refund_id)
+
+	if err == nil && !found {
+		var body map[string]interface{}
+
+		body, err = tracing.Razorpay(ctx, "payments.refund", func() (map[string]interface{}, error) {
+			return client.Payment.Refund(refund.RazorpayPaymentID, int(utils.ConvertPgtypeNumericToInt64(refund.Amount)), map[string]interface{}{
+				"speed":   "normal",
+				"receipt": refund_id,
+				"notes":   map[string]interface{}{"refund_id": refund_id},
+			}, nil)
+		})
+
+		if err == nil {
+			provider = parseRazorpayRefundStatus(body)
+		}
+	}
+
+	if err != nil {
+		attempts := int(refund.Attempts) + 1
+		status := constants.RefundStatusPending
+
+		var bad_request *rzperrors.BadRequestError
+
+		if errors.As(err, &bad_request) || attempts >= cfg.REFUND_MAX_ATTEMPTS {
+			status = constants.RefundStatusFailed
+			slog.WarnContext(ctx, "Refund failed", "refund_id", refund_id, "user_id", uuid.UUID(refund.UserID.Bytes), "razorpay_payment_id", refund.RazorpayPaymentID, "attempts", attempts, "error", err)
+			metrics.Refunds.WithLabelValues(metrics.RefundFailed).Inc()
+		} else {
+			metrics.Refunds.WithLabelValues(metrics.RefundRetried).Inc()
+		}
+
+		return s.query.MarkRefundAttemptFailed(ctx, sqlc.MarkRefundAttemptFailedParams{
+			ID:            refund.ID,
+			Status:        string(status),
+			NextAttemptAt: pgtype.Timestamptz{Time: time.Now().Add(utils.ExponentialBackoff(attempts, refundBaseBackoff, refundMaxBackoff)), Valid: true},
+			LastError:     pgtype.Text{String: err.Error(), Valid: true},
+		})
+	}
+
+	return s.applyProviderStatus(ctx, refund, provider)
+}
+
+// pollRefund fetches the status of a submitted refund.
+func (s *RefundService) pollRefund(ctx context.Context, client *razorpay.Client, refund sqlc.Refund) error {
+	body, err := tracing.Razorpay(ctx, "refunds.fetch", func() (map[string]interface{}, error) {
+		return client.Refund.Fetch(refund.RazorpayRefundID.String, nil, nil)
+	})
+
+	if err != nil {
+		attempts := int(refund.Attempts) + 1
+		metrics.Refunds.WithLabelValues(metrics.RefundRetried).Inc()
+
+		return s.query.MarkRefundAttemptFailed(ctx, sqlc.MarkRefundAttemptFailedParams{
+			ID:            refund.ID,
+			Status:        string(constants.RefundStatusSubmitted),
+			NextAttemptAt: pgtype.Timestamptz{Time: time.Now().Add(utils.ExponentialBackoff(attempts, refundBaseBackoff, refundMaxBackoff)), Valid: true},
+			LastError:     pgtype.Text{String: err.Error(), Valid: true},
+		})
+	}
+
+	return s.applyProviderStatus(ctx, refund, parseRazorpayRefundStatus(body))
+}
+
+// applyProviderStatus moves a refund to the status razorpay reports for it.
+func (s *RefundService) applyProviderStatus(ctx context.Context, refund sqlc.Refund, provider providerRefundStatus) error {
+	razorpay_refund_id := pgtype.Text{String: provider.id, Valid: provider.id != ""}
+
+	switch provider.status {
+	case "processed":
+		err := s.completeRefund(ctx, refund.ID, razorpay_refund_id)
+
+		if err == nil {
+			metrics.Refunds.WithLabelValues(metrics.RefundProcessed).Inc()
+		}
+
+		return err
+
+	case "failed":
+		_, err := s.query.MarkRefundFailed(ctx, sqlc.MarkRefundFailedParams{
+			ID:        refund.ID,
+			LastError: pgtype.Text{String: "Razorpay failed the refund", Valid: true},
+		})
+
+		if err == nil {
+			slog.WarnContext(ctx, "Razorpay failed refund", "refund_id", uuid.UUID(refund.ID.Bytes), "user_id", uuid.UUID(refund.UserID.Bytes), "razorpay_payment_id", refund.RazorpayPaymentID)
+			metrics.Refunds.WithLabelValues(metrics.RefundFailed).Inc()
+		}
+
+		return err
+	}
+
+	// accepted and waiting to be processed; polled less often the longer it takes
+	_, err := s.query.MarkRefundSubmitted(ctx, sqlc.MarkRefundSubmittedParams{
+		ID:               refund.ID,
+		RazorpayRefundID: razorpay_refund_id,
+		NextAttemptAt:    pgtype.Timestamptz{Time: time.Now().Add(utils.ExponentialBackoff(int(refund.Attempts)+1, refundBaseBackoff, refundMaxBackoff)), Valid: true},
+	})
+
+	if err == nil {
+		metrics.Refunds.WithLabelValues(metrics.RefundSubmitted).Inc()
+	}
+
+	return err
+}
+
+// completeRefund marks a refund processed and, in the same transaction, issues the
+// credit note against the subscription's invoice. A subscription refunded on
+// cancellation moves to refunded. Refunds that are already processed are left alone.
+func (s *RefundService) completeRefund(ctx context.Context, refund_id pgtype.UUID, razorpay_refund_id pgtype.Text) error {
+	tx, err := s.pool.Begin(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	qtx := s.query.WithTx(tx)
+	defer tx.Rollback(ctx)
+
+	updated, err := qtx.MarkRefundProcessed(ctx, sqlc.MarkRefundProcessedParams{
+		ID:               refund_id,
+		RazorpayRefundID: razorpay_refund_id,
+	})
+
+	if err != nil || updated == 0 {
+		return err
+	}
+
+	refund, err := qtx.GetRefundByID(ctx, refund_id)
+
+	if err != nil {
+		return err
+	}
+
+	err = creditRefund(ctx, qtx, refund)
+
+	if err != nil {
+		return err
+	}
+
+	if constants.RefundReason(refund.Reason) == constants.RefundReasonCancellation && refund.SubscriptionID.Valid && refund.UserID.Valid {
+		err = transitionSubscription(ctx, qtx, refund.SubscriptionID, refund.UserID, constants.SubscriptionStatusRefunded, "refunded on cancellation")
+
+		if err != nil && !errors.Is(err, ErrInvalidTransition) {
+			return err
+		}
+	}
+
+	return tx.Commit(ctx)
+}
+
+// recordProviderRefund records a refund that was made on razorpay without a local
+// record, along with its credit note.
+func (s *RefundService) recordProviderRefund(ctx context.Context, subscription_id pgtype.UUID, user_id pgtype.UUID, razorpay_payment_id string, razorpay_refund_id string, amount int64, currency constants.Currency) error {
+	tx, err := s.pool.Begin(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	qtx := s.query.WithTx(tx)
+	defer tx.Rollback(ctx)
+
+	refund, err := qtx.CreateNewRefund(ctx, sqlc.CreateNewRefundParams{
+		SubscriptionID:    subscription_id,
+		RazorpayPaymentID: razorpay_payment_id,
+		Amount:            utils.ConvertInt64ToPgtypeNumeric(amount),
+		UserID:            user_id,
+		Currency:          string(currency),
+		Status:            string(constants.RefundStatusProcessed),
+		Reason:            string(constants.RefundReasonReconciliation),
+		RazorpayRefundID:  pgtype.Text{String: razorpay_refund_id, Valid: razorpay_refund_id != ""},
+		ProcessedAt:       pgtype.Timestamptz{Time: time.Now(), Valid: true},
+	})
+
+	if err != nil {
+		return err
+	}
+
+	err = creditRefund(ctx, qtx, refund)
+
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// queueRefund records a refund as pending for the refund worker to submit. It runs in
+// the caller's transaction so the refund is recorded together with the change that
+// caused it. A payment can be refunded in parts, once per subscription and reason, and
+// the amount is capped at what is left of paid_amount. It returns the amount queued.
+func queueRefund(ctx context.Context, qtx *sqlc.Queries, subscription_id pgtype.UUID, user_id pgtype.UUID, razorpay_payment_id string, amount int64, paid_amount int64, currency constants.Currency, reason constants.RefundReason) (int64, error) {
+	// refunds of the same payment queued concurrently would both see the old total
+	err := qtx.LockPaymentRefunds(ctx, razorpay_payment_id)
+
+	if err != nil {
+		return 0, err
+	}
+
+	_, err = qtx.GetRefundForPayment(ctx, sqlc.GetRefundForPaymentParams{
+		RazorpayPaymentID: razorpay_payment_id,
+		SubscriptionID:    subscription_id,
+		Reason:            string(reason),
+	})
+
+	if err == nil {
+		return 0, ErrRefundExists
+	}
+
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return 0, err
+	}
+
+	refunded, err := qtx.GetRefundedAmountByPaymentID(ctx, razorpay_payment_id)
+
+	if err != nil {
+		return 0, err
+	}
+
+	amount = min(amount, paid_amount-refunded)
+
+	if amount <= 0 {
+		return 0, ErrPaymentFullyRefunded
+	}
+
+	_, err = qtx.CreateNewRefund(ctx, sqlc.CreateNewRefundParams{
+		SubscriptionID:    subscription_id,
+		RazorpayPaymentID: razorpay_payment_id,
+		Amount:            utils.ConvertInt64ToPgtypeNumeric(amount),
+		UserID:            user_id,
+		Currency:          string(currency),
+		Status:            string(constants.RefundStatusPending),
+		Reason:            string(reason),
+	})
+
+	if err != nil {
+		return 0, err
+	}
+
+	return amount, nil
+}
+
+// creditRefund issues a credit note for a processed refund when its subscription was
+// invoiced.
+func creditRefund(ctx context.Context, qtx *sqlc.Queries, refund sqlc.Refund) error {
+	if !refund.SubscriptionID.Valid {
+		return nil
+	}
+
+	invoice, err := qtx.GetInvoiceBySubscriptionID(ctx, refund.SubscriptionID)
+
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil
+	}
+
+	if err != nil {
+		return err
+	}
+
+	_, err = issueCreditNote(ctx, qtx, invoice, refund.ID, utils.ConvertPgtypeNumericToInt64(refund.Amount), time.Now())
+
+	return err
+}
+
+// findRazorpayRefund looks for a refund of the payment carrying refund_id in its notes.
+func findRazorpayRefund(ctx context.Context, client *razorpay.Client, razorpay_payment_id string, refund_id string) (providerRefundStatus, bool, error) {
+	body, err := tracing.Razorpay(ctx, "payments.refunds", func() (map[string]interface{}, error) {
+		return client.Payment.FetchMultipleRefund(razorpay_payment_id, map[string]interface{}{"count": razorpayPageSize}, nil)
+	})
+
+	if err != nil {
+		return providerRefundStatus{}, false, err
+	}
+
+	items, _ := body["items"].([]interface{})
+
+	for _, item := range items {
+		entity, ok := item.(map[string]interface{})
+
+		if !ok {
+			continue
+		}
+
+		provider := parseRazorpayRefundStatus(entity)
+
+		if provider.refund_id == refund_id {
+			return provider, true, nil
+		}
+	}
+
+	return providerRefundStatus{}, false, nil
+}
+
+func parseRazorpayRefundStatus(entity map[string]interface{}) providerRefundStatus {
+	provider := providerRefundStatus{
+		id:     razorpayString(entity, "id"),
+		status: razorpayString(entity, "status"),
+	}
+
+	// razorpay sends empty notes as an empty list
+	if notes, ok := entity["notes"].(map[string]interface{}); ok {
+		provider.refund_id = razorpayString(notes, "refund_id")
+	}
+
+	return provider
+}
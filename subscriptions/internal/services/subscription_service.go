@@ -0,0 +1,811 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/parbhat-cpp/fuse/subscriptions/constants"
+	"github.com/parbhat-cpp/fuse/subscriptions/internal/apperrors"
+	"github.com/parbhat-cpp/fuse/subscriptions/internal/config"
+	"github.com/parbhat-cpp/fuse/subscriptions/internal/db/sqlc"
+	"github.com/parbhat-cpp/fuse/subscriptions/internal/types"
+	"github.com/parbhat-cpp/fuse/subscriptions/lib"
+	"github.com/parbhat-cpp/fuse/subscriptions/pkg/utils"
+)
+
+type SubscriptionService struct {
+	query *sqlc.Queries
+	pool  *pgxpool.Pool
+}
+
+var ErrNoActiveSubscription = apperrors.New(apperrors.CodeNotFound, "no active subscription")
+var ErrInvalidPlanChange = apperrors.New(apperrors.CodeInvalidRequest, "invalid plan change")
+var ErrPlanChangeNotFound = apperrors.New(apperrors.CodeNotFound, "no pending plan change")
+var ErrAlreadyCanceled = apperrors.New(apperrors.CodeConflict, "subscription already canceled")
+var ErrTrialUnavailable = apperrors.New(apperrors.CodeConflict, "trial unavailable")
+
+func NewSubscriptionService(query *sqlc.Queries, pool *pgxpool.Pool) *SubscriptionService {
+	return &SubscriptionService{
+		query: query,
+		pool:  pool,
+	}
+}
+
+type CancellationResponse struct {
+	Subscription     sqlc.CancelSubscriptionRow
+	RefundAmount     int64
+	Currency         constants.Currency
+	CanceledRenewals int
+}
+
+type pendingRefund struct {
+	subscription_id     pgtype.UUID
+	razorpay_payment_id string
+	amount              int64
+	paid_amount         int64
+	currency            constants.Currency
+}
+
+/**
+ * Records a downgrade to a cheaper paid plan which takes effect when the current
+ * subscription's ValidUntil is reached. Plans are prepaid, so a downgrade moves the
+ * renewals the user already paid for to the cheaper plan and it is rejected when no
+ * such renewal is queued.
+ * @param ctx: context.Context
+ * @param user_id: uuid.UUID
+ * @param plan_type: string
+ * @return sqlc.SubscriptionPlanChange, error
+ */
+func (s *SubscriptionService) RequestDowngrade(ctx context.Context, user_id uuid.UUID, plan_type string) (sqlc.SubscriptionPlanChange, error) {
+	user_uuid := utils.ConvertGoogleUUIDToPgtypeUUID(user_id)
+
+	target_plan, exists := constants.GetPlans()[plan_type]
+
+	if !exists {
+		return sqlc.SubscriptionPlanChange{}, ErrInvalidPlanChange.Withf("Invalid plan type")
+	}
+
+	if target_plan.Prices[constants.CurrencyINR] <= 0 {
+		return sqlc.SubscriptionPlanChange{}, ErrInvalidPlanChange.Withf("Downgrade target must be a paid plan")
+	}
+
+	active_sub, err := s.query.GetActiveSubscriptionByUserID(ctx, user_uuid)
+
+	if err != nil {
+		return sqlc.SubscriptionPlanChange{}, ErrNoActiveSubscription
+	}
+
+	if constants.SubscriptionStatus(active_sub.Status) == constants.SubscriptionStatusTrialing {
+		return sqlc.SubscriptionPlanChange{}, ErrInvalidPlanChange.Withf("A trial cannot be downgraded")
+	}
+
+	active_plan, err := constants.GetPlanByID(uuid.UUID(active_sub.PlanID.Bytes))
+
+	if err != nil {
+		return sqlc.SubscriptionPlanChange{}, err
+	}
+
+	if target_plan.Prices[constants.CurrencyINR] >= active_plan.Prices[constants.CurrencyINR] {
+		return sqlc.SubscriptionPlanChange{}, ErrInvalidPlanChange.Withf("%s is not a downgrade from %s", target_plan.Name, active_plan.Name)
+	}
+
+	_, err = s.query.GetPendingPlanChangeByUserID(ctx, user_uuid)
+
+	if err == nil {
+		return sqlc.SubscriptionPlanChange{}, ErrInvalidPlanChange.Withf("A plan change is already pending")
+	}
+
+	queued_subs, err := s.query.GetQueuedSubscriptionsByUserID(ctx, sqlc.GetQueuedSubscriptionsByUserIDParams{
+		UserID:    user_uuid,
+		ValidFrom: active_sub.ValidUntil,
+	})
+
+	if err != nil {
+		return sqlc.SubscriptionPlanChange{}, fmt.Errorf("Unable to fetch queued renewals")
+	}
+
+	target_plan_id := utils.ConvertGoogleUUIDToPgtypeUUID(target_plan.ID)
+
+	if !slices.ContainsFunc(queued_subs, func(sub sqlc.GetQueuedSubscriptionsByUserIDRow) bool { return sub.PlanID != target_plan_id }) {
+		return sqlc.SubscriptionPlanChange{}, ErrInvalidPlanChange.Withf("No renewal is queued to downgrade to %s", target_plan.Name)
+	}
+
+	change, err := s.query.CreatePlanChange(ctx, sqlc.CreatePlanChangeParams{
+		UserID:         user_uuid,
+		SubscriptionID: active_sub.ID,
+		FromPlanType:   strings.ToLower(active_plan.Name),
+		ToPlanType:     plan_type,
+		EffectiveAt:    active_sub.ValidUntil,
+	})
+
+	if err != nil {
+		return sqlc.SubscriptionPlanChange{}, fmt.Errorf("Unable to schedule plan change")
+	}
+
+	return change, nil
+}
+
+/**
+ * Cancels the user's pending plan change, if any.
+ * @param ctx: context.Context
+ * @param user_id: uuid.UUID
+ * @return sqlc.SubscriptionPlanChange, error
+ */
+func (s *SubscriptionService) CancelDowngrade(ctx context.Context, user_id uuid.UUID) (sqlc.SubscriptionPlanChange, error) {
+	user_uuid := utils.ConvertGoogleUUIDToPgtypeUUID(user_id)
+
+	change, err := s.query.CancelPendingPlanChange(ctx, user_uuid)
+
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return sqlc.SubscriptionPlanChange{}, ErrPlanChangeNotFound
+		}
+		return sqlc.SubscriptionPlanChange{}, fmt.Errorf("Unable to cancel plan change")
+	}
+
+	return change, nil
+}
+
+/**
+ * Cancels the user's active subscription. Queued renewals are voided and queued for a
+ * full refund, and access is kept until the end of the current period. When refund is
+ * set the current period ends immediately and is refunded according to the configured
+ * policy. Refunds are made by the refund worker.
+ * @param ctx: context.Context
+ * @param user_id: uuid.UUID
+ * @param refund: bool
+ * @return CancellationResponse, error
+ */
+func (s *SubscriptionService) CancelSubscription(ctx context.Context, user_id uuid.UUID, refund bool) (CancellationResponse, error) {
+	cfg := config.LoadEnv()
+	user_uuid := utils.ConvertGoogleUUIDToPgtypeUUID(user_id)
+	now := pgtype.Timestamptz{Time: time.Now(), Valid: true}
+	refunds := []pendingRefund{}
+
+	active_sub, err := s.query.GetActiveSubscriptionByUserID(ctx, user_uuid)
+
+	if err != nil {
+		return CancellationResponse{}, ErrNoActiveSubscription
+	}
+
+	if active_sub.CanceledAt.Valid && !refund {
+		return CancellationResponse{}, ErrAlreadyCanceled
+	}
+
+	tx, err := s.pool.Begin(ctx)
+
+	if err != nil {
+		return CancellationResponse{}, fmt.Errorf("Failed to start a transaction")
+	}
+
+	qtx := s.query.WithTx(tx)
+	defer tx.Rollback(ctx)
+
+	queued_subs, err := qtx.GetQueuedSubscriptionsByUserID(ctx, sqlc.GetQueuedSubscriptionsByUserIDParams{
+		UserID:    user_uuid,
+		ValidFrom: active_sub.ValidUntil,
+	})
+
+	if err != nil {
+		return CancellationResponse{}, fmt.Errorf("Unable to fetch queued renewals")
+	}
+
+	for _, sub := range queued_subs {
+		err = s.voidSubscription(ctx, qtx, sub.ID, user_uuid, now)
+
+		if err != nil {
+			return CancellationResponse{}, fmt.Errorf("Unable to cancel queued renewal")
+		}
+
+		paid_amount := paidAmount(sub.Amount, sub.PlanID, sub.Currency)
+
+		// capped when the renewal was partly refunded by an earlier downgrade
+		refunds = append(refunds, pendingRefund{
+			subscription_id:     sub.ID,
+			razorpay_payment_id: sub.RazorpayPaymentID,
+			amount:              paid_amount,
+			paid_amount:         paid_amount,
+			currency:            constants.Currency(sub.Currency),
+		})
+	}
+
+	_, err = qtx.CancelPendingPlanChange(ctx, user_uuid)
+
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return CancellationResponse{}, fmt.Errorf("Unable to cancel pending plan change")
+	}
+
+	if constants.SubscriptionStatus(active_sub.Status) != constants.SubscriptionStatusCanceled {
+		err = transitionSubscription(ctx, qtx, active_sub.ID, user_uuid, constants.SubscriptionStatusCanceled, "canceled by user")
+
+		if err != nil {
+			return CancellationResponse{}, fmt.Errorf("Unable to cancel subscription")
+		}
+	}
+
+	canceled_sub, err := qtx.CancelSubscription(ctx, active_sub.ID)
+
+	if err != nil {
+		return CancellationResponse{}, fmt.Errorf("Unable to cancel subscription")
+	}
+
+	var refund_amount int64 = 0
+
+	if refund {
+		// trials were never paid for, so there is nothing to refund
+		if active_sub.RazorpayPaymentID != "" {
+			refund_amount = utils.CancellationRefund(
+				paidAmount(active_sub.Amount, active_sub.PlanID, active_sub.Currency),
+				active_sub.ValidFrom.Time,
+				active_sub.ValidUntil.Time,
+				now.Time,
+				cfg.REFUND_FULL_REFUND_DAYS,
+				cfg.REFUND_PRORATE,
+			)
+		}
+
+		// access ends right away when the current period is refunded
+		_, err = qtx.UpdateSubscriptionValidUntil(ctx, sqlc.UpdateSubscriptionValidUntilParams{ID: active_sub.ID, ValidUntil: now})
+
+		if err != nil {
+			return CancellationResponse{}, fmt.Errorf("Unable to end subscription")
+		}
+
+		_, err = qtx.UpdateSubscriptionUsageDuration(ctx, sqlc.UpdateSubscriptionUsageDurationParams{
+			SubscriptionID: active_sub.ID,
+			UserID:         user_uuid,
+			ValidFrom:      active_sub.ValidFrom,
+			ValidUntil:     now,
+		})
+
+		if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+			return CancellationResponse{}, fmt.Errorf("Unable to end subscription usage")
+		}
+
+		canceled_sub.ValidUntil = now
+
+		if refund_amount > 0 {
+			refunds = append(refunds, pendingRefund{
+				subscription_id:     active_sub.ID,
+				razorpay_payment_id: active_sub.RazorpayPaymentID,
+				amount:              refund_amount,
+				paid_amount:         paidAmount(active_sub.Amount, active_sub.PlanID, active_sub.Currency),
+				currency:            constants.Currency(active_sub.Currency),
+			})
+		}
+	}
+
+	refunds, err = queueRefunds(ctx, qtx, user_uuid, refunds, constants.RefundReasonCancellation)
+
+	if err != nil {
+		return CancellationResponse{}, fmt.Errorf("Unable to queue refund")
+	}
+
+	// the refund of the current period may have been capped by earlier refunds
+	if refund_amount > 0 {
+		refund_amount = refunds[len(refunds)-1].amount
+	}
+
+	err = enqueueNotification(ctx, qtx, user_uuid, canceled_sub.ID, lib.SubscriptionCanceledEvent{
+		PlanType:     canceled_sub.PlanType,
+		ValidUntil:   canceled_sub.ValidUntil.Time,
+		RefundAmount: refund_amount,
+		Currency:     active_sub.Currency,
+	})
+
+	if err != nil {
+		return CancellationResponse{}, fmt.Errorf("Unable to queue notification")
+	}
+
+	err = tx.Commit(ctx)
+
+	if err != nil {
+		return CancellationResponse{}, fmt.Errorf("Failed to commit transaction")
+	}
+
+	return CancellationResponse{
+		Subscription:     canceled_sub,
+		RefundAmount:     refund_amount,
+		Currency:         constants.Currency(active_sub.Currency),
+		CanceledRenewals: len(queued_subs),
+	}, nil
+}
+
+/**
+ * Starts a free trial of the configured trial plan. Each user gets one trial, and only
+ * while they have no live subscription.
+ * @param ctx: context.Context
+ * @param user_id: uuid.UUID
+ * @return sqlc.SubscriptionTrial, error
+ */
+func (s *SubscriptionService) StartTrial(ctx context.Context, user_id uuid.UUID) (sqlc.SubscriptionTrial, error) {
+	cfg := config.LoadEnv()
+	user_uuid := utils.ConvertGoogleUUIDToPgtypeUUID(user_id)
+
+	plan, exists := constants.GetPlans()[cfg.TRIAL_PLAN]
+
+	if !exists || plan.Prices[constants.CurrencyINR] <= 0 || cfg.TRIAL_DAYS <= 0 {
+		return sqlc.SubscriptionTrial{}, ErrTrialUnavailable.Withf("Trials are not offered")
+	}
+
+	// matched on the hash, which is kept when the user's data is deleted
+	_, err := s.query.GetTrialByUserIDHash(ctx, trialUserHash(user_id))
+
+	if err == nil {
+		return sqlc.SubscriptionTrial{}, ErrTrialUnavailable.Withf("Trial already used")
+	}
+
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return sqlc.SubscriptionTrial{}, fmt.Errorf("Unable to check trial eligibility")
+	}
+
+	_, err = s.query.GetLatestLiveSubscriptionByUserID(ctx, user_uuid)
+
+	if err == nil {
+		return sqlc.SubscriptionTrial{}, ErrTrialUnavailable.Withf("A subscription is already active")
+	}
+
+	valid_from := pgtype.Timestamptz{Time: time.Now(), Valid: true}
+	valid_until := pgtype.Timestamptz{Time: valid_from.Time.AddDate(0, 0, cfg.TRIAL_DAYS), Valid: true}
+
+	tx, err := s.pool.Begin(ctx)
+
+	if err != nil {
+		return sqlc.SubscriptionTrial{}, fmt.Errorf("Failed to start a transaction")
+	}
+
+	qtx := s.query.WithTx(tx)
+	defer tx.Rollback(ctx)
+
+	sub, err := qtx.CreateSubscription(ctx, sqlc.CreateSubscriptionParams{
+		UserID:          user_uuid,
+		PlanID:          utils.ConvertGoogleUUIDToPgtypeUUID(plan.ID),
+		PlanType:        plan.Name,
+		PurchaseDate:    valid_from,
+		ValidFrom:       valid_from,
+		ValidUntil:      valid_until,
+		Amount:          utils.ConvertInt64ToPgtypeNumeric(0),
+		ProrationCredit: utils.ConvertInt64ToPgtypeNumeric(0),
+		Status:          string(constants.SubscriptionStatusTrialing),
+		Currency:        string(constants.DefaultCurrency),
+	})
+
+	if err == nil {
+		err = recordSubscriptionCreated(ctx, qtx, sub.ID, user_uuid, constants.SubscriptionStatusTrialing, "trial started")
+	}
+
+	if err != nil {
+		return sqlc.SubscriptionTrial{}, fmt.Errorf("Unable to create trial subscription")
+	}
+
+	empty_usage := types.Usage{PublicRoomQuota: 0, RoomSchedulingQuota: 0}
+	empty_usage_json, _ := utils.ConvertMapTypeToBytes(empty_usage)
+
+	_, err = qtx.CreateSubscriptionUsage(ctx, sqlc.CreateSubscriptionUsageParams{
+		UserID:         user_uuid,
+		ValidFrom:      valid_from,
+		ValidUntil:     valid_until,
+		Column4:        string(empty_usage_json),
+		SubscriptionID: sub.ID,
+	})
+
+	if err != nil {
+		return sqlc.SubscriptionTrial{}, fmt.Errorf("Unable to create subscription usage")
+	}
+
+	// user_id_hash is unique, so a concurrent second trial fails here
+	trial, err := qtx.CreateTrial(ctx, sqlc.CreateTrialParams{
+		UserID:         user_uuid,
+		SubscriptionID: sub.ID,
+		PlanType:       cfg.TRIAL_PLAN,
+		StartedAt:      valid_from,
+		EndsAt:         valid_until,
+		UserIDHash:     trialUserHash(user_id),
+	})
+
+	if err != nil {
+		return sqlc.SubscriptionTrial{}, ErrTrialUnavailable.Withf("Trial already used")
+	}
+
+	err = enqueueNotification(ctx, qtx, user_uuid, sub.ID, lib.TrialStartedEvent{
+		PlanType:   plan.Name,
+		ValidFrom:  valid_from.Time,
+		ValidUntil: valid_until.Time,
+		PlanInfo:   plan,
+	})
+
+	if err != nil {
+		return sqlc.SubscriptionTrial{}, fmt.Errorf("Unable to queue notification")
+	}
+
+	err = tx.Commit(ctx)
+
+	if err != nil {
+		return sqlc.SubscriptionTrial{}, fmt.Errorf("Failed to commit transaction")
+	}
+
+	return trial, nil
+}
+
+/**
+ * Reminds users whose trial ends within TRIAL_REMINDER_HOURS and tells users whose
+ * unconverted trial has ended that they are back on the free plan. The trial
+ * subscription itself is expired by SyncSubscriptionStatuses.
+ * @return error
+ */
+func (s *SubscriptionService) ProcessTrials() error {
+	ctx := context.Background()
+	cfg := config.LoadEnv()
+	reminder_cutoff := pgtype.Timestamptz{Time: time.Now().Add(time.Duration(cfg.TRIAL_REMINDER_HOURS) * time.Hour), Valid: true}
+
+	due_reminder, err := s.query.GetTrialsDueForReminder(ctx, reminder_cutoff)
+
+	if err != nil {
+		return err
+	}
+
+	for _, trial := range due_reminder {
+		plan_name := constants.GetPlans()[trial.PlanType].Name
+
+		err = s.notifyTrial(ctx, trial, (*sqlc.Queries).MarkTrialReminderSent, lib.TrialEndingEvent{
+			PlanType: plan_name,
+			EndsAt:   trial.EndsAt.Time,
+		})
+
+		if err != nil {
+			slog.ErrorContext(ctx, "Unable to send trial reminder", "trial_id", uuid.UUID(trial.ID.Bytes), "user_id", uuid.UUID(trial.UserID.Bytes), "error", err)
+		}
+	}
+
+	ended, err := s.query.GetEndedTrials(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	for _, trial := range ended {
+		plan_name := constants.GetPlans()[trial.PlanType].Name
+
+		err = s.notifyTrial(ctx, trial, (*sqlc.Queries).MarkTrialEnded, lib.TrialEndedEvent{
+			PlanType:     plan_name,
+			EndedAt:      trial.EndsAt.Time,
+			FallbackPlan: constants.GetPlans()["free"].Name,
+		})
+
+		if err != nil {
+			slog.ErrorContext(ctx, "Unable to mark trial as ended", "trial_id", uuid.UUID(trial.ID.Bytes), "user_id", uuid.UUID(trial.UserID.Bytes), "error", err)
+		}
+	}
+
+	return nil
+}
+
+/**
+ * Reminds users whose subscription ends within RENEWAL_REMINDER_DAYS and has no renewal
+ * queued after it. Each subscription is reminded once.
+ * @return error
+ */
+func (s *SubscriptionService) SendRenewalReminders() error {
+	ctx := context.Background()
+	cfg := config.LoadEnv()
+
+	if cfg.RENEWAL_REMINDER_DAYS <= 0 {
+		return nil
+	}
+
+	cutoff := pgtype.Timestamptz{Time: time.Now().AddDate(0, 0, cfg.RENEWAL_REMINDER_DAYS), Valid: true}
+
+	due, err := s.query.GetSubscriptionsDueForRenewalReminder(ctx, cutoff)
+
+	if err != nil {
+		return err
+	}
+
+	for _, sub := range due {
+		err = s.notifyOnce(ctx, sub.ID, sub.UserID, sub.ID, (*sqlc.Queries).MarkRenewalReminderSent, lib.RenewalReminderEvent{
+			PlanType:   sub.PlanType,
+			ValidUntil: sub.ValidUntil.Time,
+		})
+
+		if err != nil {
+			slog.ErrorContext(ctx, "Unable to send renewal reminder", "subscription_id", uuid.UUID(sub.ID.Bytes), "user_id", uuid.UUID(sub.UserID.Bytes), "error", err)
+		}
+	}
+
+	return nil
+}
+
+// notifyTrial marks a trial step as done and queues its notification.
+func (s *SubscriptionService) notifyTrial(ctx context.Context, trial sqlc.SubscriptionTrial, mark func(*sqlc.Queries, context.Context, pgtype.UUID) error, event lib.NotificationEvent) error {
+	return s.notifyOnce(ctx, trial.ID, trial.UserID, trial.SubscriptionID, mark, event)
+}
+
+// notifyOnce marks the row with the given id as notified and queues the notification
+// in one transaction, so it is sent exactly once.
+func (s *SubscriptionService) notifyOnce(ctx context.Context, id pgtype.UUID, user_id pgtype.UUID, subscription_id pgtype.UUID, mark func(*sqlc.Queries, context.Context, pgtype.UUID) error, event lib.NotificationEvent) error {
+	tx, err := s.pool.Begin(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback(ctx)
+
+	qtx := s.query.WithTx(tx)
+
+	err = mark(qtx, ctx, id)
+
+	if err != nil {
+		return err
+	}
+
+	err = enqueueNotification(ctx, qtx, user_id, subscription_id, event)
+
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// voidSubscription collapses a queued subscription and its usage to an empty
+// period so it never becomes active.
+func (s *SubscriptionService) voidSubscription(ctx context.Context, qtx *sqlc.Queries, subscription_id pgtype.UUID, user_id pgtype.UUID, at pgtype.Timestamptz) error {
+	err := qtx.UpdateSubscriptionValidity(ctx, sqlc.UpdateSubscriptionValidityParams{
+		ID:         subscription_id,
+		ValidFrom:  at,
+		ValidUntil: at,
+	})
+
+	if err != nil {
+		return err
+	}
+
+	_, err = qtx.UpdateSubscriptionUsageDuration(ctx, sqlc.UpdateSubscriptionUsageDurationParams{
+		SubscriptionID: subscription_id,
+		UserID:         user_id,
+		ValidFrom:      at,
+		ValidUntil:     at,
+	})
+
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return err
+	}
+
+	err = transitionSubscription(ctx, qtx, subscription_id, user_id, constants.SubscriptionStatusCanceled, "renewal canceled by user")
+
+	if err != nil {
+		return err
+	}
+
+	_, err = qtx.CancelSubscription(ctx, subscription_id)
+
+	return err
+}
+
+// transition runs transitionSubscription in its own transaction.
+func (s *SubscriptionService) transition(ctx context.Context, subscription_id pgtype.UUID, user_id pgtype.UUID, to constants.SubscriptionStatus, reason string) error {
+	tx, err := s.pool.Begin(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback(ctx)
+
+	err = transitionSubscription(ctx, s.query.WithTx(tx), subscription_id, user_id, to, reason)
+
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+/**
+ * Moves subscriptions whose period has started to active and those whose period has
+ * ended to expired.
+ * @return error
+ */
+func (s *SubscriptionService) SyncSubscriptionStatuses() error {
+	ctx := context.Background()
+
+	due_activation, err := s.query.GetSubscriptionsDueForActivation(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	for _, sub := range due_activation {
+		err = s.transition(ctx, sub.ID, sub.UserID, constants.SubscriptionStatusActive, "period started")
+
+		if err != nil {
+			slog.ErrorContext(ctx, "Unable to activate subscription", "subscription_id", uuid.UUID(sub.ID.Bytes), "user_id", uuid.UUID(sub.UserID.Bytes), "error", err)
+		}
+	}
+
+	due_expiry, err := s.query.GetSubscriptionsDueForExpiry(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	for _, sub := range due_expiry {
+		err = s.transition(ctx, sub.ID, sub.UserID, constants.SubscriptionStatusExpired, "period ended")
+
+		if err != nil {
+			slog.ErrorContext(ctx, "Unable to expire subscription", "subscription_id", uuid.UUID(sub.ID.Bytes), "user_id", uuid.UUID(sub.UserID.Bytes), "error", err)
+		}
+	}
+
+	return nil
+}
+
+/**
+ * Applies every pending plan change whose effective date has passed.
+ * @return error
+ */
+func (s *SubscriptionService) ApplyDuePlanChanges() error {
+	ctx := context.Background()
+
+	changes, err := s.query.GetDuePlanChanges(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	for _, change := range changes {
+		err = s.applyPlanChange(ctx, change)
+
+		if err != nil {
+			slog.ErrorContext(ctx, "Unable to apply plan change", "change_id", uuid.UUID(change.ID.Bytes), "user_id", uuid.UUID(change.UserID.Bytes), "error", err)
+		}
+	}
+
+	return nil
+}
+
+// Plans are prepaid, so the only thing a plan change can act on is a renewal the
+// user already paid for. Those renewals are moved to the target plan and the price
+// difference is refunded. A change left with no renewal to move is canceled.
+func (s *SubscriptionService) applyPlanChange(ctx context.Context, change sqlc.SubscriptionPlanChange) error {
+	target_plan, exists := constants.GetPlans()[change.ToPlanType]
+
+	if !exists {
+		return fmt.Errorf("Unknown target plan %s", change.ToPlanType)
+	}
+
+	target_plan_id := utils.ConvertGoogleUUIDToPgtypeUUID(target_plan.ID)
+	refunds := []pendingRefund{}
+
+	tx, err := s.pool.Begin(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	qtx := s.query.WithTx(tx)
+	defer tx.Rollback(ctx)
+
+	queued_subs, err := qtx.GetQueuedSubscriptionsByUserID(ctx, sqlc.GetQueuedSubscriptionsByUserIDParams{
+		UserID:    change.UserID,
+		ValidFrom: change.EffectiveAt,
+	})
+
+	if err != nil {
+		return err
+	}
+
+	for _, sub := range queued_subs {
+		if sub.PlanID == target_plan_id {
+			continue
+		}
+
+		target_amount, err := target_plan.Amount(constants.Currency(sub.Currency))
+
+		if err != nil {
+			return err
+		}
+
+		_, err = qtx.UpdateSubscriptionPlan(ctx, sqlc.UpdateSubscriptionPlanParams{
+			ID:       sub.ID,
+			PlanID:   target_plan_id,
+			PlanType: target_plan.Name,
+		})
+
+		if err != nil {
+			return err
+		}
+
+		paid_amount := paidAmount(sub.Amount, sub.PlanID, sub.Currency)
+
+		if paid_amount > target_amount {
+			refunds = append(refunds, pendingRefund{
+				subscription_id:     sub.ID,
+				razorpay_payment_id: sub.RazorpayPaymentID,
+				amount:              paid_amount - target_amount,
+				paid_amount:         paid_amount,
+				currency:            constants.Currency(sub.Currency),
+			})
+		}
+	}
+
+	status := constants.PlanChangeStatusApplied
+
+	if len(queued_subs) == 0 {
+		status = constants.PlanChangeStatusCanceled
+		slog.WarnContext(ctx, "Canceling plan change with no queued renewal", "change_id", uuid.UUID(change.ID.Bytes), "user_id", uuid.UUID(change.UserID.Bytes))
+	}
+
+	err = qtx.UpdatePlanChangeStatus(ctx, sqlc.UpdatePlanChangeStatusParams{
+		ID:     change.ID,
+		Status: string(status),
+	})
+
+	if err != nil {
+		return err
+	}
+
+	_, err = queueRefunds(ctx, qtx, change.UserID, refunds, constants.RefundReasonPlanChange)
+
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// queueRefunds queues refunds in the caller's transaction and returns them with the
+// amounts actually queued. A refund already recorded for the same reason, or of a
+// payment refunded in full, is skipped and left with an amount of 0.
+func queueRefunds(ctx context.Context, qtx *sqlc.Queries, user_id pgtype.UUID, refunds []pendingRefund, reason constants.RefundReason) ([]pendingRefund, error) {
+	for i, r := range refunds {
+		queued, err := queueRefund(ctx, qtx, r.subscription_id, user_id, r.razorpay_payment_id, r.amount, r.paid_amount, r.currency, reason)
+
+		if errors.Is(err, ErrRefundExists) || errors.Is(err, ErrPaymentFullyRefunded) {
+			slog.InfoContext(ctx, "Skipping refund", "user_id", uuid.UUID(user_id.Bytes), "razorpay_payment_id", r.razorpay_payment_id, "error", err)
+		} else if err != nil {
+			return nil, err
+		}
+
+		refunds[i].amount = queued
+	}
+
+	return refunds, nil
+}
+
+// trialUserHash identifies a user's trial without their id, so trial eligibility
+// outlives the deletion of their data.
+func trialUserHash(user_id uuid.UUID) string {
+	sum := sha256.Sum256([]byte(user_id.String()))
+
+	return hex.EncodeToString(sum[:])
+}
+
+// paidAmount returns what was charged for a subscription in the minor unit of its
+// currency. Subscriptions created before amounts were recorded were charged the full
+// plan price in that currency.
+func paidAmount(amount pgtype.Numeric, plan_id pgtype.UUID, currency string) int64 {
+	paid_amount := utils.ConvertPgtypeNumericToInt64(amount)
+
+	if paid_amount == 0 {
+		plan, err := constants.GetPlanByID(uuid.UUID(plan_id.Bytes))
+
+		if err == nil {
+			paid_amount, _ = plan.Amount(constants.Currency(currency))
+		}
+	}
+
+	return paid_amount
+}
@@ -2,11 +2,17 @@ package services
 
 import (
 	"context"
+	"encoding/base64"
 	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/parbhat-cpp/fuse/subscriptions/constants"
+	"github.com/parbhat-cpp/fuse/subscriptions/internal/apperrors"
 	"github.com/parbhat-cpp/fuse/subscriptions/internal/db/sqlc"
 	"github.com/parbhat-cpp/fuse/subscriptions/internal/types"
 	"github.com/parbhat-cpp/fuse/subscriptions/pkg/utils"
@@ -16,16 +22,62 @@ type UsageService struct {
 	query *sqlc.Queries
 }
 
+var ErrInvalidHistoryFilter = apperrors.New(apperrors.CodeInvalidRequest, "invalid history filter")
+
+const defaultHistoryLimit = 20
+const maxHistoryLimit = 100
+
+// HistoryFilter narrows the usage or subscription history of a user. Empty fields are
+// not filtered on. From and To select periods overlapping [From, To).
+type HistoryFilter struct {
+	PlanType string
+	From     *time.Time
+	To       *time.Time
+	Cursor   string
+	Limit    int
+	// v1 lists are not paged unless the caller sends a limit or a cursor
+	Unpaged bool
+}
+
+// Page is a page of a list. NextCursor is empty on the last page.
+type Page[T any] struct {
+	Items      []T
+	NextCursor string
+}
+
+type CurrentUsageResponse struct {
+	sqlc.GetCurrentSubscriptionUsageWithSubscriptionByUserIDRow
+	PendingPlanChange *sqlc.SubscriptionPlanChange
+}
+
 func NewUsageService(query *sqlc.Queries) *UsageService {
 	return &UsageService{
 		query: query,
 	}
 }
 
-func (s *UsageService) GetCurrentUsage(user_id uuid.UUID) (sqlc.GetCurrentSubscriptionUsageWithSubscriptionByUserIDRow, error) {
+func (s *UsageService) GetCurrentUsage(ctx context.Context, user_id uuid.UUID) (CurrentUsageResponse, error) {
 	user_uuid := utils.ConvertGoogleUUIDToPgtypeUUID(user_id)
 
-	usage_row, err := s.query.GetCurrentSubscriptionUsageWithSubscriptionByUserID(context.Background(), user_uuid)
+	usage_row, err := s.getCurrentUsageRow(ctx, user_uuid)
+
+	if err != nil {
+		return CurrentUsageResponse{}, err
+	}
+
+	res := CurrentUsageResponse{GetCurrentSubscriptionUsageWithSubscriptionByUserIDRow: usage_row}
+
+	pending_change, err := s.query.GetPendingPlanChangeByUserID(ctx, user_uuid)
+
+	if err == nil {
+		res.PendingPlanChange = &pending_change
+	}
+
+	return res, nil
+}
+
+func (s *UsageService) getCurrentUsageRow(ctx context.Context, user_uuid pgtype.UUID) (sqlc.GetCurrentSubscriptionUsageWithSubscriptionByUserIDRow, error) {
+	usage_row, err := s.query.GetCurrentSubscriptionUsageWithSubscriptionByUserID(ctx, user_uuid)
 
 	if err != nil {
 		default_usage := types.Usage{
@@ -36,7 +88,7 @@ func (s *UsageService) GetCurrentUsage(user_id uuid.UUID) (sqlc.GetCurrentSubscr
 		if err != nil {
 			return sqlc.GetCurrentSubscriptionUsageWithSubscriptionByUserIDRow{}, err
 		}
-		_, err = s.query.CreateSubscriptionUsage(context.Background(), sqlc.CreateSubscriptionUsageParams{
+		_, err = s.query.CreateSubscriptionUsage(ctx, sqlc.CreateSubscriptionUsageParams{
 			UserID: user_uuid,
 			ValidFrom: pgtype.Timestamptz{
 				Time:  time.Now(),
@@ -49,7 +101,7 @@ func (s *UsageService) GetCurrentUsage(user_id uuid.UUID) (sqlc.GetCurrentSubscr
 			Column4: string(default_usage_json), // Usage column sqlc generated it as Column4
 		})
 
-		usage_row, err := s.query.GetCurrentSubscriptionUsageWithSubscriptionByUserID(context.Background(), user_uuid)
+		usage_row, err := s.query.GetCurrentSubscriptionUsageWithSubscriptionByUserID(ctx, user_uuid)
 
 		if err != nil {
 			return sqlc.GetCurrentSubscriptionUsageWithSubscriptionByUserIDRow{}, err
@@ -60,26 +112,147 @@ func (s *UsageService) GetCurrentUsage(user_id uuid.UUID) (sqlc.GetCurrentSubscr
 	return usage_row, nil
 }
 
-func (s *UsageService) GetPreviousUsage(user_id uuid.UUID) ([]sqlc.GetAllSubscriptionUsageWithSubscriptionRow, error) {
-	user_uuid := utils.ConvertGoogleUUIDToPgtypeUUID(user_id)
+/**
+ * Returns a page of the user's usage periods, newest first.
+ * @param ctx: context.Context
+ * @param user_id: uuid.UUID
+ * @param filter: HistoryFilter
+ * @return Page[sqlc.ListSubscriptionUsageWithSubscriptionRow], error
+ */
+func (s *UsageService) GetPreviousUsage(ctx context.Context, user_id uuid.UUID, filter HistoryFilter) (Page[sqlc.ListSubscriptionUsageWithSubscriptionRow], error) {
+	params, err := historyParams(user_id, filter)
 
-	usage_rows, err := s.query.GetAllSubscriptionUsageWithSubscription(context.Background(), user_uuid)
+	if err != nil {
+		return Page[sqlc.ListSubscriptionUsageWithSubscriptionRow]{}, err
+	}
+
+	usage_rows, err := s.query.ListSubscriptionUsageWithSubscription(ctx, sqlc.ListSubscriptionUsageWithSubscriptionParams(params))
 
 	if err != nil {
-		return nil, err
+		return Page[sqlc.ListSubscriptionUsageWithSubscriptionRow]{}, err
 	}
 
-	return usage_rows, nil
+	return newPage(usage_rows, int(params.RowLimit)-1, func(row sqlc.ListSubscriptionUsageWithSubscriptionRow) (pgtype.Timestamptz, pgtype.UUID) {
+		return row.CreatedAt, row.ID
+	}), nil
 }
 
-func (s *UsageService) GetPreviousSubscription(user_id uuid.UUID) ([]sqlc.GetAllSubscriptionsRow, error) {
-	user_uuid := utils.ConvertGoogleUUIDToPgtypeUUID(user_id)
+/**
+ * Returns a page of the user's subscriptions, newest first.
+ * @param ctx: context.Context
+ * @param user_id: uuid.UUID
+ * @param filter: HistoryFilter
+ * @return Page[sqlc.ListSubscriptionsRow], error
+ */
+func (s *UsageService) GetPreviousSubscription(ctx context.Context, user_id uuid.UUID, filter HistoryFilter) (Page[sqlc.ListSubscriptionsRow], error) {
+	params, err := historyParams(user_id, filter)
+
+	if err != nil {
+		return Page[sqlc.ListSubscriptionsRow]{}, err
+	}
+
+	subscription_rows, err := s.query.ListSubscriptions(ctx, params)
+
+	if err != nil {
+		return Page[sqlc.ListSubscriptionsRow]{}, err
+	}
+
+	return newPage(subscription_rows, int(params.RowLimit)-1, func(row sqlc.ListSubscriptionsRow) (pgtype.Timestamptz, pgtype.UUID) {
+		return row.CreatedAt, row.ID
+	}), nil
+}
+
+// historyParams validates a history filter. One row more than the page size is asked
+// for to tell whether there is a next page.
+func historyParams(user_id uuid.UUID, filter HistoryFilter) (sqlc.ListSubscriptionsParams, error) {
+	plan_type := strings.ToLower(filter.PlanType)
+
+	if plan_type != "" {
+		if _, exists := constants.GetPlans()[plan_type]; !exists {
+			return sqlc.ListSubscriptionsParams{}, ErrInvalidHistoryFilter.Withf("unknown plan type %s", filter.PlanType)
+		}
+	}
+
+	if filter.Limit < 0 || filter.Limit > maxHistoryLimit {
+		return sqlc.ListSubscriptionsParams{}, ErrInvalidHistoryFilter.Withf("limit must be between 1 and %d", maxHistoryLimit)
+	}
+
+	if filter.From != nil && filter.To != nil && !filter.From.Before(*filter.To) {
+		return sqlc.ListSubscriptionsParams{}, ErrInvalidHistoryFilter.Withf("from must be before to")
+	}
+
+	params := sqlc.ListSubscriptionsParams{
+		UserID:   utils.ConvertGoogleUUIDToPgtypeUUID(user_id),
+		PlanType: pgtype.Text{String: plan_type, Valid: plan_type != ""},
+		RowLimit: defaultHistoryLimit + 1,
+	}
+
+	if filter.From != nil {
+		params.PeriodFrom = pgtype.Timestamptz{Time: *filter.From, Valid: true}
+	}
+	if filter.To != nil {
+		params.PeriodTo = pgtype.Timestamptz{Time: *filter.To, Valid: true}
+	}
+	if filter.Limit > 0 {
+		params.RowLimit = int32(filter.Limit) + 1
+	} else if filter.Unpaged && filter.Cursor == "" {
+		params.RowLimit = math.MaxInt32
+	}
+
+	if filter.Cursor != "" {
+		created_at, id, err := decodeCursor(filter.Cursor)
+
+		if err != nil {
+			return sqlc.ListSubscriptionsParams{}, ErrInvalidHistoryFilter.Withf("invalid cursor")
+		}
+
+		params.CursorCreatedAt = pgtype.Timestamptz{Time: created_at, Valid: true}
+		params.CursorID = utils.ConvertGoogleUUIDToPgtypeUUID(id)
+	}
+
+	return params, nil
+}
+
+func newPage[T any](rows []T, limit int, key func(T) (pgtype.Timestamptz, pgtype.UUID)) Page[T] {
+	if len(rows) <= limit {
+		return Page[T]{Items: rows}
+	}
+
+	rows = rows[:limit]
+	created_at, id := key(rows[limit-1])
+
+	return Page[T]{Items: rows, NextCursor: encodeCursor(created_at.Time, uuid.UUID(id.Bytes))}
+}
+
+// cursors are opaque to clients: the creation time and id of the last row of a page
+func encodeCursor(created_at time.Time, id uuid.UUID) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(created_at.UTC().Format(time.RFC3339Nano) + "|" + id.String()))
+}
+
+func decodeCursor(cursor string) (time.Time, uuid.UUID, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+
+	if err != nil {
+		return time.Time{}, uuid.Nil, err
+	}
+
+	created_at_text, id_text, found := strings.Cut(string(raw), "|")
+
+	if !found {
+		return time.Time{}, uuid.Nil, fmt.Errorf("Malformed cursor")
+	}
+
+	created_at, err := time.Parse(time.RFC3339Nano, created_at_text)
+
+	if err != nil {
+		return time.Time{}, uuid.Nil, err
+	}
 
-	subscription_rows, err := s.query.GetAllSubscriptions(context.Background(), user_uuid)
+	id, err := uuid.Parse(id_text)
 
 	if err != nil {
-		return nil, err
+		return time.Time{}, uuid.Nil, err
 	}
 
-	return subscription_rows, nil
+	return created_at, id, nil
 }
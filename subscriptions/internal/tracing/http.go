@@ -0,0 +1,29 @@
+package tracing
+
+import (
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/labstack/echo/otelecho"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// HTTPMiddleware starts a server span for each request, continuing the trace of the
+// caller when it sent a traceparent header. Health checks and metric scrapes are not
+// traced. It must run after the request id middleware, whose id is added to the span.
+func HTTPMiddleware(service_name string) echo.MiddlewareFunc {
+	traced := otelecho.Middleware(service_name, otelecho.WithSkipper(func(c echo.Context) bool {
+		return strings.HasPrefix(c.Path(), "/health") || c.Path() == "/metrics"
+	}))
+
+	return func(nextHandler echo.HandlerFunc) echo.HandlerFunc {
+		return traced(func(c echo.Context) error {
+			trace.SpanFromContext(c.Request().Context()).SetAttributes(
+				attribute.String("http.request_id", c.Response().Header().Get(echo.HeaderXRequestID)),
+			)
+
+			return nextHandler(c)
+		})
+	}
+}
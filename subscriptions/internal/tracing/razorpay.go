@@ -0,0 +1,29 @@
+package tracing
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+/**
+ * Runs a call to the razorpay API in a span. The razorpay client does not take a
+ * context, so its requests cannot be traced on their own.
+ * @param ctx: context.Context
+ * @param operation: string, e.g. orders.create
+ * @param call: func() (map[string]interface{}, error)
+ * @return map[string]interface{}, error
+ */
+func Razorpay(ctx context.Context, operation string, call func() (map[string]interface{}, error)) (map[string]interface{}, error) {
+	_, span := Start(ctx, "razorpay "+operation, trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(
+		attribute.String("peer.service", "razorpay"),
+		attribute.String("razorpay.operation", operation),
+	))
+
+	body, err := call()
+
+	End(span, err)
+
+	return body, err
+}
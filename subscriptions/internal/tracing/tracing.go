@@ -0,0 +1,81 @@
+// Package tracing sets up OpenTelemetry tracing. Spans are exported over OTLP/HTTP to a
+// collector, and W3C trace context is read from incoming requests and sent on outgoing
+// ones so traces continue across fuse services.
+package tracing
+
+import (
+	"context"
+	"strings"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const instrumentationName = "github.com/parbhat-cpp/fuse/subscriptions"
+
+type Options struct {
+	// base url of the collector's OTLP/HTTP receiver, e.g. http://localhost:4318.
+	// Spans are not exported when it is empty.
+	Endpoint    string
+	ServiceName string
+	// share of new traces that are sampled; traces started upstream keep their decision
+	SampleRatio float64
+}
+
+/**
+ * Installs the global tracer provider and W3C trace context propagation. The returned
+ * function flushes buffered spans and must be called before the process exits.
+ * @param ctx: context.Context
+ * @param options: Options
+ * @return func(context.Context) error, error
+ */
+func Init(ctx context.Context, options Options) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	if options.Endpoint == "" {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	exporter, err := otlptracehttp.New(ctx, otlptracehttp.WithEndpointURL(strings.TrimSuffix(options.Endpoint, "/")+"/v1/traces"))
+
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewSchemaless(attribute.String("service.name", options.ServiceName)))
+
+	if err != nil {
+		return nil, err
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(options.SampleRatio))),
+	)
+
+	otel.SetTracerProvider(provider)
+
+	return provider.Shutdown, nil
+}
+
+// Start starts a span from the global tracer provider.
+func Start(ctx context.Context, name string, options ...trace.SpanStartOption) (context.Context, trace.Span) {
+	return otel.Tracer(instrumentationName).Start(ctx, name, options...)
+}
+
+// End records err on span, if any, and ends it.
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+
+	span.End()
+}
@@ -0,0 +1,83 @@
+package lib
+
+import (
+	"time"
+
+	"github.com/parbhat-cpp/fuse/subscriptions/constants"
+)
+
+// NotificationEvent is the data of a notification. Tag names the template the
+// notifications service renders it with.
+type NotificationEvent interface {
+	Tag() string
+	Title() string
+}
+
+type NewSubscriptionEvent struct {
+	PlanType      string         `json:"plan_type"`
+	ValidFrom     time.Time      `json:"valid_from"`
+	ValidUntil    time.Time      `json:"valid_until"`
+	PlanInfo      constants.Plan `json:"plan_info"`
+	Amount        int64          `json:"amount"`
+	Currency      string         `json:"currency"`
+	InvoiceID     string         `json:"invoice_id"`
+	InvoiceNumber string         `json:"invoice_number"`
+}
+
+func (e NewSubscriptionEvent) Tag() string   { return "NEW_SUBSCRIPTION" }
+func (e NewSubscriptionEvent) Title() string { return "Upgraded to " + e.PlanType + " Successfully" }
+
+type SubscriptionCanceledEvent struct {
+	PlanType     string    `json:"plan_type"`
+	ValidUntil   time.Time `json:"valid_until"`
+	RefundAmount int64     `json:"refund_amount"`
+	Currency     string    `json:"currency"`
+}
+
+func (e SubscriptionCanceledEvent) Tag() string   { return "SUBSCRIPTION_CANCELED" }
+func (e SubscriptionCanceledEvent) Title() string { return e.PlanType + " subscription canceled" }
+
+type RenewalReminderEvent struct {
+	PlanType   string    `json:"plan_type"`
+	ValidUntil time.Time `json:"valid_until"`
+}
+
+func (e RenewalReminderEvent) Tag() string   { return "RENEWAL_REMINDER" }
+func (e RenewalReminderEvent) Title() string { return e.PlanType + " subscription ends soon" }
+
+type QuotaWarningEvent struct {
+	PlanType string `json:"plan_type"`
+	Feature  string `json:"feature"`
+	Used     int    `json:"used"`
+	Limit    int    `json:"limit"`
+}
+
+func (e QuotaWarningEvent) Tag() string   { return "QUOTA_WARNING" }
+func (e QuotaWarningEvent) Title() string { return e.Feature + " quota almost used" }
+
+type TrialStartedEvent struct {
+	PlanType   string         `json:"plan_type"`
+	ValidFrom  time.Time      `json:"valid_from"`
+	ValidUntil time.Time      `json:"valid_until"`
+	PlanInfo   constants.Plan `json:"plan_info"`
+}
+
+func (e TrialStartedEvent) Tag() string   { return "TRIAL_STARTED" }
+func (e TrialStartedEvent) Title() string { return e.PlanType + " trial started" }
+
+type TrialEndingEvent struct {
+	PlanType string    `json:"plan_type"`
+	EndsAt   time.Time `json:"ends_at"`
+}
+
+func (e TrialEndingEvent) Tag() string   { return "TRIAL_ENDING" }
+func (e TrialEndingEvent) Title() string { return e.PlanType + " trial ends soon" }
+
+type TrialEndedEvent struct {
+	PlanType     string    `json:"plan_type"`
+	EndedAt      time.Time `json:"ended_at"`
+	FallbackPlan string    `json:"fallback_plan"`
+}
+
+func (e TrialEndedEvent) Tag() string   { return "TRIAL_ENDED" }
+func (e TrialEndedEvent) Title() string { return e.PlanType + " trial ended" }
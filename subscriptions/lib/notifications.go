@@ -2,40 +2,288 @@ package lib
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"net/http"
+	"sync"
+	"time"
 
 	"github.com/parbhat-cpp/fuse/subscriptions/internal/config"
+	"github.com/parbhat-cpp/fuse/subscriptions/pkg/utils"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
 )
 
-func SendNotification(userID string, title string, message string, data map[string]interface{}, channels []string, templateID string) error {
-	httpClient := &http.Client{}
+// requests carry the W3C trace context of the caller so traces continue downstream
+var httpClient = &http.Client{Timeout: 10 * time.Second, Transport: otelhttp.NewTransport(http.DefaultTransport)}
+
+var ErrCircuitOpen = errors.New("notification service circuit is open")
+var ErrNotificationRejected = errors.New("notification rejected")
+
+var defaultChannels = []string{"in-app", "email"}
+
+// Notification is the body the notifications service expects on /notify. Tag names the
+// template the service renders.
+type Notification struct {
+	UserID   string   `json:"userId"`
+	Title    string   `json:"title"`
+	Message  string   `json:"message"`
+	Data     any      `json:"data"`
+	Channels []string `json:"channels"`
+	Tag      string   `json:"tag"`
+}
+
+// NewNotification addresses a typed event to a user on the in-app and email channels.
+func NewNotification(userID string, event NotificationEvent) Notification {
+	return Notification{
+		UserID:   userID,
+		Title:    event.Title(),
+		Data:     event,
+		Channels: defaultChannels,
+		Tag:      event.Tag(),
+	}
+}
+
+// NotificationClient delivers notifications to users.
+type NotificationClient interface {
+	Send(ctx context.Context, notification Notification) error
+}
+
+type NotificationClientOptions struct {
+	Timeout          time.Duration // per request
+	MaxRetries       int
+	BaseBackoff      time.Duration
+	MaxBackoff       time.Duration
+	BreakerThreshold int           // consecutive failed sends that open the circuit
+	BreakerCooldown  time.Duration // how long the circuit stays open
+}
+
+// NewNotificationClient returns a client for the configured notifications service, or
+// one that drops notifications when NOTIFICATION_URL is not set.
+func NewNotificationClient() NotificationClient {
 	cfg := config.LoadEnv()
 
-	body := map[string]interface{}{
-		"user_id":     userID,
-		"title":       title,
-		"message":     message,
-		"data":        data,
-		"channels":    channels,
-		"template_id": templateID,
+	if cfg.NOTIFICATION_URL == "" {
+		return NoopNotificationClient{}
+	}
+
+	return NewHTTPNotificationClient(cfg.NOTIFICATION_URL, NotificationClientOptions{
+		Timeout:          time.Duration(cfg.NOTIFICATION_TIMEOUT_SECONDS) * time.Second,
+		MaxRetries:       cfg.NOTIFICATION_MAX_RETRIES,
+		BaseBackoff:      200 * time.Millisecond,
+		MaxBackoff:       2 * time.Second,
+		BreakerThreshold: cfg.NOTIFICATION_BREAKER_THRESHOLD,
+		BreakerCooldown:  time.Duration(cfg.NOTIFICATION_BREAKER_COOLDOWN_SECONDS) * time.Second,
+	})
+}
+
+// HTTPNotificationClient posts notifications to the notifications service. Failed
+// requests are retried with jittered exponential backoff, and after repeated failed
+// sends the circuit opens and sends fail fast until the cooldown has passed.
+type HTTPNotificationClient struct {
+	url     string
+	client  *http.Client
+	options NotificationClientOptions
+	breaker *CircuitBreaker
+}
+
+func NewHTTPNotificationClient(baseURL string, options NotificationClientOptions) *HTTPNotificationClient {
+	return &HTTPNotificationClient{
+		url:     baseURL + "/notify",
+		client:  &http.Client{Timeout: options.Timeout, Transport: otelhttp.NewTransport(http.DefaultTransport)},
+		options: options,
+		breaker: NewCircuitBreaker(options.BreakerThreshold, options.BreakerCooldown),
+	}
+}
+
+func (c *HTTPNotificationClient) Send(ctx context.Context, notification Notification) error {
+	if !c.breaker.Allow() {
+		return ErrCircuitOpen
+	}
+
+	body, err := json.Marshal(notification)
+
+	if err != nil {
+		return err
+	}
+
+	for attempt := 0; ; attempt++ {
+		err = c.post(ctx, body)
+
+		// a rejected notification will be rejected again, and means the service is up
+		if err == nil || errors.Is(err, ErrNotificationRejected) {
+			c.breaker.Success()
+			return err
+		}
+
+		if attempt >= c.options.MaxRetries {
+			break
+		}
+
+		delay := utils.Jitter(utils.ExponentialBackoff(attempt+1, c.options.BaseBackoff, c.options.MaxBackoff))
+
+		select {
+		case <-ctx.Done():
+			c.breaker.Failure()
+			return ctx.Err()
+		case <-time.After(delay):
+		}
 	}
 
-	request_body, err := json.Marshal(body)
+	c.breaker.Failure()
+
+	return err
+}
+
+// post sends one request. 4xx responses other than 429 are returned as
+// ErrNotificationRejected.
+func (c *HTTPNotificationClient) post(ctx context.Context, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url, bytes.NewReader(body))
 
 	if err != nil {
 		return err
 	}
 
-	_, err = httpClient.Post(
-		cfg.NOTIFICATION_URL+"/notify",
-		"application/json",
-		bytes.NewBuffer(request_body),
-	)
+	req.Header.Set("Content-Type", "application/json")
+
+	res, err := c.client.Do(req)
 
 	if err != nil {
 		return err
 	}
 
+	err = checkResponse(c.url, res)
+
+	if err != nil && res.StatusCode >= 400 && res.StatusCode < 500 && res.StatusCode != http.StatusTooManyRequests {
+		return fmt.Errorf("%w: %v", ErrNotificationRejected, err)
+	}
+
+	return err
+}
+
+// NoopNotificationClient drops every notification. It is used when no notifications
+// service is configured.
+type NoopNotificationClient struct{}
+
+func (NoopNotificationClient) Send(ctx context.Context, notification Notification) error {
+	return nil
+}
+
+// MemoryNotificationClient keeps sent notifications in memory, for tests. Err, when set,
+// is returned by Send instead of recording the notification.
+type MemoryNotificationClient struct {
+	mu            sync.Mutex
+	notifications []Notification
+	Err           error
+}
+
+func (c *MemoryNotificationClient) Send(ctx context.Context, notification Notification) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.Err != nil {
+		return c.Err
+	}
+
+	c.notifications = append(c.notifications, notification)
+
+	return nil
+}
+
+// Notifications returns the notifications sent so far.
+func (c *MemoryNotificationClient) Notifications() []Notification {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return append([]Notification(nil), c.notifications...)
+}
+
+// CircuitBreaker opens after threshold consecutive failures and stays open for
+// cooldown. Once the cooldown has passed calls are let through again, and the next
+// failure opens it straight away.
+type CircuitBreaker struct {
+	mu        sync.Mutex
+	threshold int
+	cooldown  time.Duration
+	failures  int
+	openUntil time.Time
+}
+
+func NewCircuitBreaker(threshold int, cooldown time.Duration) *CircuitBreaker {
+	return &CircuitBreaker{
+		threshold: threshold,
+		cooldown:  cooldown,
+	}
+}
+
+// Allow reports whether a call may go through.
+func (b *CircuitBreaker) Allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return !time.Now().Before(b.openUntil)
+}
+
+func (b *CircuitBreaker) Success() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures = 0
+	b.openUntil = time.Time{}
+}
+
+func (b *CircuitBreaker) Failure() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures++
+
+	if b.threshold > 0 && b.failures >= b.threshold {
+		b.openUntil = time.Now().Add(b.cooldown)
+	}
+}
+
+// PublishEvent posts a domain event to url.
+func PublishEvent(ctx context.Context, url string, topic string, payload []byte) error {
+	body, err := json.Marshal(map[string]interface{}{
+		"topic":   topic,
+		"payload": json.RawMessage(payload),
+	})
+
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	res, err := httpClient.Do(req)
+
+	if err != nil {
+		return err
+	}
+
+	return checkResponse(url, res)
+}
+
+// checkResponse closes the response body and fails on any non 2xx response.
+func checkResponse(url string, res *http.Response) error {
+	defer res.Body.Close()
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		message, _ := io.ReadAll(io.LimitReader(res.Body, 512))
+		return fmt.Errorf("%s responded with %d: %s", url, res.StatusCode, bytes.TrimSpace(message))
+	}
+
+	// drain so the connection can be reused
+	io.Copy(io.Discard, res.Body)
+
 	return nil
 }
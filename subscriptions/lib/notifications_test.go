@@ -0,0 +1,241 @@
+package lib
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type sendTestCase struct {
+	name         string
+	statuses     []int // responses in order; the last one repeats
+	maxRetries   int
+	wantRequests int32
+	wantErr      error // nil for success, errAny for any other error
+}
+
+var errAny = errors.New("any error")
+
+func TestHTTPNotificationClientSend(t *testing.T) {
+	tests := []sendTestCase{
+		{name: "delivered", statuses: []int{http.StatusOK}, maxRetries: 2, wantRequests: 1},
+		{name: "retried after server error", statuses: []int{http.StatusInternalServerError, http.StatusAccepted}, maxRetries: 2, wantRequests: 2},
+		{name: "retried after rate limit", statuses: []int{http.StatusTooManyRequests, http.StatusOK}, maxRetries: 2, wantRequests: 2},
+		{name: "gives up after retries", statuses: []int{http.StatusBadGateway}, maxRetries: 2, wantRequests: 3, wantErr: errAny},
+		{name: "no retries", statuses: []int{http.StatusServiceUnavailable}, maxRetries: 0, wantRequests: 1, wantErr: errAny},
+		{name: "rejected is not retried", statuses: []int{http.StatusBadRequest}, maxRetries: 2, wantRequests: 1, wantErr: ErrNotificationRejected},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var requests atomic.Int32
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				n := int(requests.Add(1))
+
+				if r.Method != http.MethodPost || r.URL.Path != "/notify" {
+					t.Errorf("got %s %s, want POST /notify", r.Method, r.URL.Path)
+				}
+
+				var notification Notification
+
+				if err := json.NewDecoder(r.Body).Decode(&notification); err != nil || notification.Tag != "TRIAL_ENDING" {
+					t.Errorf("got body %+v (error %v), want a TRIAL_ENDING notification", notification, err)
+				}
+
+				w.WriteHeader(tt.statuses[min(n, len(tt.statuses))-1])
+			}))
+			defer server.Close()
+
+			client := NewHTTPNotificationClient(server.URL, NotificationClientOptions{
+				Timeout:     time.Second,
+				MaxRetries:  tt.maxRetries,
+				BaseBackoff: time.Millisecond,
+				MaxBackoff:  5 * time.Millisecond,
+			})
+
+			err := client.Send(context.Background(), NewNotification("user", TrialEndingEvent{PlanType: "Pro"}))
+
+			switch {
+			case tt.wantErr == nil && err != nil:
+				t.Fatalf("Send() error = %v, want nil", err)
+			case tt.wantErr == errAny && (err == nil || errors.Is(err, ErrNotificationRejected)):
+				t.Fatalf("Send() error = %v, want a delivery error", err)
+			case tt.wantErr != nil && tt.wantErr != errAny && !errors.Is(err, tt.wantErr):
+				t.Fatalf("Send() error = %v, want %v", err, tt.wantErr)
+			}
+
+			if got := requests.Load(); got != tt.wantRequests {
+				t.Fatalf("got %d requests, want %d", got, tt.wantRequests)
+			}
+		})
+	}
+}
+
+func TestHTTPNotificationClientStopsOnContextDone(t *testing.T) {
+	var requests atomic.Int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	client := NewHTTPNotificationClient(server.URL, NotificationClientOptions{
+		Timeout:     time.Second,
+		MaxRetries:  5,
+		BaseBackoff: time.Hour,
+		MaxBackoff:  time.Hour,
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	err := client.Send(ctx, NewNotification("user", TrialEndingEvent{PlanType: "Pro"}))
+
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Send() error = %v, want %v", err, context.DeadlineExceeded)
+	}
+
+	if got := requests.Load(); got != 1 {
+		t.Fatalf("got %d requests, want 1", got)
+	}
+}
+
+func TestHTTPNotificationClientOpensCircuit(t *testing.T) {
+	var requests atomic.Int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	client := NewHTTPNotificationClient(server.URL, NotificationClientOptions{
+		Timeout:          time.Second,
+		BreakerThreshold: 2,
+		BreakerCooldown:  time.Hour,
+	})
+
+	notification := NewNotification("user", TrialEndingEvent{PlanType: "Pro"})
+
+	for i := 0; i < 2; i++ {
+		if err := client.Send(context.Background(), notification); err == nil || errors.Is(err, ErrCircuitOpen) {
+			t.Fatalf("send %d: error = %v, want a delivery error", i+1, err)
+		}
+	}
+
+	if err := client.Send(context.Background(), notification); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("Send() error = %v, want %v", err, ErrCircuitOpen)
+	}
+
+	if got := requests.Load(); got != 2 {
+		t.Fatalf("got %d requests, want 2 as the open circuit fails fast", got)
+	}
+}
+
+func TestCircuitBreaker(t *testing.T) {
+	const cooldown = 20 * time.Millisecond
+
+	tests := []struct {
+		name      string
+		threshold int
+		calls     func(b *CircuitBreaker)
+		wantAllow bool
+	}{
+		{
+			name:      "closed below threshold",
+			threshold: 3,
+			calls: func(b *CircuitBreaker) {
+				b.Failure()
+				b.Failure()
+			},
+			wantAllow: true,
+		},
+		{
+			name:      "opens at threshold",
+			threshold: 3,
+			calls: func(b *CircuitBreaker) {
+				b.Failure()
+				b.Failure()
+				b.Failure()
+			},
+			wantAllow: false,
+		},
+		{
+			name:      "success resets the failure count",
+			threshold: 2,
+			calls: func(b *CircuitBreaker) {
+				b.Failure()
+				b.Success()
+				b.Failure()
+			},
+			wantAllow: true,
+		},
+		{
+			name:      "never opens without a threshold",
+			threshold: 0,
+			calls: func(b *CircuitBreaker) {
+				for i := 0; i < 10; i++ {
+					b.Failure()
+				}
+			},
+			wantAllow: true,
+		},
+		{
+			name:      "allows calls after the cooldown",
+			threshold: 1,
+			calls: func(b *CircuitBreaker) {
+				b.Failure()
+				time.Sleep(2 * cooldown)
+			},
+			wantAllow: true,
+		},
+		{
+			name:      "reopens on the first failure after the cooldown",
+			threshold: 2,
+			calls: func(b *CircuitBreaker) {
+				b.Failure()
+				b.Failure()
+				time.Sleep(2 * cooldown)
+				b.Failure()
+			},
+			wantAllow: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			breaker := NewCircuitBreaker(tt.threshold, cooldown)
+			tt.calls(breaker)
+
+			if got := breaker.Allow(); got != tt.wantAllow {
+				t.Fatalf("Allow() = %t, want %t", got, tt.wantAllow)
+			}
+		})
+	}
+}
+
+func TestMemoryNotificationClient(t *testing.T) {
+	client := &MemoryNotificationClient{}
+	notification := NewNotification("user", TrialEndingEvent{PlanType: "Pro"})
+
+	if err := client.Send(context.Background(), notification); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+
+	client.Err = errors.New("unavailable")
+
+	if err := client.Send(context.Background(), notification); !errors.Is(err, client.Err) {
+		t.Fatalf("Send() error = %v, want %v", err, client.Err)
+	}
+
+	if got := client.Notifications(); len(got) != 1 || got[0].Tag != "TRIAL_ENDING" {
+		t.Fatalf("Notifications() = %+v, want the one TRIAL_ENDING notification sent", got)
+	}
+}
@@ -0,0 +1,5 @@
+ALTER TABLE IF EXISTS subscriptions DROP COLUMN upgraded_from;
+ALTER TABLE IF EXISTS subscriptions DROP COLUMN proration_credit;
+ALTER TABLE IF EXISTS subscriptions DROP COLUMN amount;
+
+DROP TABLE IF EXISTS payment_orders;
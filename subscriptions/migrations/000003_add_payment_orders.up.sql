@@ -0,0 +1,22 @@
+CREATE TABLE payment_orders (
+  id uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+  user_id uuid NOT NULL references profiles(id),
+  plan_id uuid NOT NULL,
+  plan_type text NOT NULL,
+  razorpay_order_id text NOT NULL UNIQUE,
+  amount numeric NOT NULL,
+  proration_credit numeric NOT NULL DEFAULT 0,
+  upgrade_from_subscription_id uuid references subscriptions(id),
+  status text NOT NULL DEFAULT 'created',
+  created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+ALTER TABLE IF EXISTS subscriptions
+ADD COLUMN amount numeric NOT NULL DEFAULT 0;
+
+ALTER TABLE IF EXISTS subscriptions
+ADD COLUMN proration_credit numeric NOT NULL DEFAULT 0;
+
+ALTER TABLE IF EXISTS subscriptions
+ADD COLUMN upgraded_from uuid references subscriptions(id);
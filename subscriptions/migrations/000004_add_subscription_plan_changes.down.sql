@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS subscription_plan_changes_pending_user_idx;
+DROP TABLE IF EXISTS subscription_plan_changes;
@@ -0,0 +1,14 @@
+CREATE TABLE subscription_plan_changes (
+  id uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+  user_id uuid NOT NULL references profiles(id),
+  subscription_id uuid NOT NULL references subscriptions(id),
+  from_plan_type text NOT NULL,
+  to_plan_type text NOT NULL,
+  effective_at TIMESTAMP WITH TIME ZONE NOT NULL,
+  status text NOT NULL DEFAULT 'pending',
+  created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX subscription_plan_changes_pending_user_idx
+ON subscription_plan_changes (user_id) WHERE status = 'pending';
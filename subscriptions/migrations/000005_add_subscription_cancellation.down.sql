@@ -0,0 +1 @@
+ALTER TABLE IF EXISTS subscriptions DROP COLUMN canceled_at;
@@ -0,0 +1,2 @@
+ALTER TABLE IF EXISTS subscriptions
+ADD COLUMN canceled_at TIMESTAMP WITH TIME ZONE;
@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS subscription_events;
+
+DROP INDEX IF EXISTS subscriptions_user_id_status_idx;
+ALTER TABLE IF EXISTS subscriptions DROP CONSTRAINT IF EXISTS subscriptions_status_check;
+ALTER TABLE IF EXISTS subscriptions DROP COLUMN status;
@@ -0,0 +1,27 @@
+ALTER TABLE IF EXISTS subscriptions
+ADD COLUMN status text NOT NULL DEFAULT 'pending';
+
+UPDATE subscriptions SET status = CASE
+  WHEN valid_until <= NOW() THEN 'expired'
+  WHEN valid_from > NOW() THEN 'pending'
+  WHEN canceled_at IS NOT NULL THEN 'canceled'
+  ELSE 'active'
+END;
+
+ALTER TABLE IF EXISTS subscriptions
+ADD CONSTRAINT subscriptions_status_check
+CHECK (status IN ('pending', 'trialing', 'active', 'past_due', 'canceled', 'expired', 'refunded'));
+
+CREATE INDEX subscriptions_user_id_status_idx ON subscriptions (user_id, status);
+
+CREATE TABLE subscription_events (
+  id uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+  subscription_id uuid NOT NULL references subscriptions(id),
+  user_id uuid references profiles(id),
+  from_status text,
+  to_status text NOT NULL,
+  reason text NOT NULL DEFAULT '',
+  created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX subscription_events_subscription_id_idx ON subscription_events (subscription_id);
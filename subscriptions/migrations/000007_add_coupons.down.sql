@@ -0,0 +1,5 @@
+ALTER TABLE IF EXISTS payment_orders DROP COLUMN discount_amount;
+ALTER TABLE IF EXISTS payment_orders DROP COLUMN coupon_id;
+
+DROP TABLE IF EXISTS coupon_redemptions;
+DROP TABLE IF EXISTS coupons;
@@ -0,0 +1,32 @@
+CREATE TABLE coupons (
+  id uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+  code text NOT NULL UNIQUE,
+  discount_type text NOT NULL CHECK (discount_type IN ('percentage', 'fixed')),
+  discount_value numeric NOT NULL,
+  valid_from TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  expires_at TIMESTAMP WITH TIME ZONE,
+  max_redemptions integer,
+  per_user_limit integer NOT NULL DEFAULT 1,
+  plan_types text[] NOT NULL DEFAULT '{}',
+  is_active boolean NOT NULL DEFAULT true,
+  created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE coupon_redemptions (
+  id uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+  coupon_id uuid NOT NULL references coupons(id),
+  user_id uuid references profiles(id),
+  subscription_id uuid NOT NULL references subscriptions(id),
+  payment_order_id uuid references payment_orders(id) ON DELETE SET NULL,
+  discount_amount numeric NOT NULL,
+  created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX coupon_redemptions_coupon_id_user_id_idx ON coupon_redemptions (coupon_id, user_id);
+
+ALTER TABLE IF EXISTS payment_orders
+ADD COLUMN coupon_id uuid references coupons(id);
+
+ALTER TABLE IF EXISTS payment_orders
+ADD COLUMN discount_amount numeric NOT NULL DEFAULT 0;
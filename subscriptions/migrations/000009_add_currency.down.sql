@@ -0,0 +1,4 @@
+ALTER TABLE coupons DROP COLUMN IF EXISTS currency;
+ALTER TABLE refunds DROP COLUMN IF EXISTS currency;
+ALTER TABLE subscriptions DROP COLUMN IF EXISTS currency;
+ALTER TABLE payment_orders DROP COLUMN IF EXISTS currency;
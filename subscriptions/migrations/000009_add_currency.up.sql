@@ -0,0 +1,6 @@
+ALTER TABLE payment_orders ADD COLUMN currency text NOT NULL DEFAULT 'INR';
+ALTER TABLE subscriptions ADD COLUMN currency text NOT NULL DEFAULT 'INR';
+ALTER TABLE refunds ADD COLUMN currency text NOT NULL DEFAULT 'INR';
+
+-- fixed discounts are an amount in this currency
+ALTER TABLE coupons ADD COLUMN currency text NOT NULL DEFAULT 'INR';
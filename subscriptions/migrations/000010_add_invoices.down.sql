@@ -0,0 +1,6 @@
+DROP TABLE IF EXISTS invoices;
+DROP TABLE IF EXISTS invoice_sequences;
+
+ALTER TABLE payment_orders DROP COLUMN IF EXISTS billing_state_code;
+ALTER TABLE payment_orders DROP COLUMN IF EXISTS gstin;
+ALTER TABLE payment_orders DROP COLUMN IF EXISTS billing_name;
@@ -0,0 +1,34 @@
+ALTER TABLE payment_orders ADD COLUMN billing_name text;
+ALTER TABLE payment_orders ADD COLUMN gstin text;
+ALTER TABLE payment_orders ADD COLUMN billing_state_code text;
+
+-- one counter per financial year keeps invoice numbers consecutive
+CREATE TABLE invoice_sequences (
+  financial_year text PRIMARY KEY,
+  last_number bigint NOT NULL
+);
+
+CREATE TABLE invoices (
+  id uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+  invoice_number text NOT NULL UNIQUE,
+  user_id uuid references profiles(id),
+  subscription_id uuid NOT NULL references subscriptions(id),
+  payment_order_id uuid references payment_orders(id) ON DELETE SET NULL,
+  plan_type text NOT NULL,
+  currency text NOT NULL,
+  billing_name text,
+  gstin text,
+  place_of_supply text,
+  seller_gstin text NOT NULL,
+  seller_state_code text NOT NULL,
+  tax_rate numeric NOT NULL,
+  taxable_amount numeric NOT NULL,
+  cgst_amount numeric NOT NULL DEFAULT 0,
+  sgst_amount numeric NOT NULL DEFAULT 0,
+  igst_amount numeric NOT NULL DEFAULT 0,
+  total_amount numeric NOT NULL,
+  issued_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX invoices_user_id_issued_at_idx ON invoices (user_id, issued_at DESC);
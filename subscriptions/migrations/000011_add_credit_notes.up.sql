@@ -0,0 +1,24 @@
+CREATE TABLE credit_note_sequences (
+  financial_year text PRIMARY KEY,
+  last_number bigint NOT NULL
+);
+
+CREATE TABLE credit_notes (
+  id uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+  credit_note_number text NOT NULL UNIQUE,
+  invoice_id uuid NOT NULL references invoices(id),
+  refund_id uuid NOT NULL references refunds(id),
+  user_id uuid references profiles(id),
+  currency text NOT NULL,
+  tax_rate numeric NOT NULL,
+  taxable_amount numeric NOT NULL,
+  cgst_amount numeric NOT NULL DEFAULT 0,
+  sgst_amount numeric NOT NULL DEFAULT 0,
+  igst_amount numeric NOT NULL DEFAULT 0,
+  total_amount numeric NOT NULL,
+  issued_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX credit_notes_user_id_issued_at_idx ON credit_notes (user_id, issued_at DESC);
+CREATE INDEX credit_notes_invoice_id_idx ON credit_notes (invoice_id);
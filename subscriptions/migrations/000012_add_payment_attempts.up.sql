@@ -0,0 +1,20 @@
+-- user_id has no foreign key: attempts are recorded even when the payload names an unknown user
+CREATE TABLE payment_attempts (
+  id uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+  user_id uuid,
+  plan_type text NOT NULL,
+  order_id text NOT NULL,
+  razorpay_order_id text NOT NULL,
+  razorpay_payment_id text NOT NULL,
+  outcome text NOT NULL CHECK (outcome IN ('succeeded', 'failed')),
+  reason_code text NOT NULL,
+  error_message text,
+  refunded boolean NOT NULL DEFAULT false,
+  client_ip text,
+  created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX payment_attempts_created_at_idx ON payment_attempts (created_at DESC);
+CREATE INDEX payment_attempts_user_id_idx ON payment_attempts (user_id, created_at DESC);
+CREATE INDEX payment_attempts_razorpay_order_id_idx ON payment_attempts (razorpay_order_id);
+CREATE INDEX payment_attempts_razorpay_payment_id_idx ON payment_attempts (razorpay_payment_id);
@@ -0,0 +1,19 @@
+-- outbox_events are written in the same transaction as the change they describe and
+-- delivered afterwards by the outbox dispatcher
+CREATE TABLE outbox_events (
+  id uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+  topic text NOT NULL,
+  user_id uuid,
+  aggregate_id uuid,
+  payload jsonb NOT NULL,
+  status text NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'delivered', 'dead')),
+  attempts integer NOT NULL DEFAULT 0,
+  next_attempt_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  last_error text,
+  delivered_at TIMESTAMP WITH TIME ZONE,
+  created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX outbox_events_pending_idx ON outbox_events (next_attempt_at) WHERE status = 'pending';
+CREATE INDEX outbox_events_dead_idx ON outbox_events (created_at DESC) WHERE status = 'dead';
+CREATE INDEX outbox_events_user_id_idx ON outbox_events (user_id);
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS refunds_created_at_idx;
+DROP INDEX IF EXISTS refunds_razorpay_payment_id_idx;
+DROP INDEX IF EXISTS subscriptions_created_at_idx;
+DROP INDEX IF EXISTS subscriptions_razorpay_payment_id_idx;
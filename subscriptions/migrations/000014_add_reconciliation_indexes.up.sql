@@ -0,0 +1,5 @@
+-- reconciliation looks rows up by razorpay payment id and scans them by creation date
+CREATE INDEX IF NOT EXISTS subscriptions_razorpay_payment_id_idx ON subscriptions (razorpay_payment_id);
+CREATE INDEX IF NOT EXISTS subscriptions_created_at_idx ON subscriptions (created_at);
+CREATE INDEX IF NOT EXISTS refunds_razorpay_payment_id_idx ON refunds (razorpay_payment_id);
+CREATE INDEX IF NOT EXISTS refunds_created_at_idx ON refunds (created_at);
@@ -0,0 +1,12 @@
+DROP INDEX IF EXISTS refunds_status_idx;
+DROP INDEX IF EXISTS refunds_due_idx;
+DROP INDEX IF EXISTS refunds_razorpay_refund_id_idx;
+
+ALTER TABLE IF EXISTS refunds
+DROP COLUMN IF EXISTS processed_at,
+DROP COLUMN IF EXISTS last_error,
+DROP COLUMN IF EXISTS next_attempt_at,
+DROP COLUMN IF EXISTS attempts,
+DROP COLUMN IF EXISTS razorpay_refund_id,
+DROP COLUMN IF EXISTS reason,
+DROP COLUMN IF EXISTS status;
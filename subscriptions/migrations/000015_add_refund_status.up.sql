@@ -0,0 +1,18 @@
+-- refunds are recorded as pending before razorpay is called and moved along by the
+-- refund worker and razorpay webhooks. Existing rows were only written once razorpay
+-- accepted the refund.
+ALTER TABLE IF EXISTS refunds
+ADD COLUMN status text NOT NULL DEFAULT 'processed' CHECK (status IN ('pending', 'submitted', 'processed', 'failed')),
+ADD COLUMN reason text NOT NULL DEFAULT '',
+ADD COLUMN razorpay_refund_id text,
+ADD COLUMN attempts integer NOT NULL DEFAULT 0,
+ADD COLUMN next_attempt_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+ADD COLUMN last_error text,
+ADD COLUMN processed_at TIMESTAMP WITH TIME ZONE;
+
+ALTER TABLE IF EXISTS refunds
+ALTER COLUMN status SET DEFAULT 'pending';
+
+CREATE UNIQUE INDEX refunds_razorpay_refund_id_idx ON refunds (razorpay_refund_id) WHERE razorpay_refund_id IS NOT NULL;
+CREATE INDEX refunds_due_idx ON refunds (next_attempt_at) WHERE status IN ('pending', 'submitted');
+CREATE INDEX refunds_status_idx ON refunds (status, updated_at DESC);
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS subscriptions_user_id_created_at_idx;
+DROP INDEX IF EXISTS subscription_usage_user_id_created_at_idx;
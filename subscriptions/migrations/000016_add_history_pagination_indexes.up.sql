@@ -0,0 +1,3 @@
+-- usage and subscription history is listed per user, newest first, a page at a time
+CREATE INDEX IF NOT EXISTS subscription_usage_user_id_created_at_idx ON subscription_usage (user_id, created_at DESC, id DESC);
+CREATE INDEX IF NOT EXISTS subscriptions_user_id_created_at_idx ON subscriptions (user_id, created_at DESC, id DESC);
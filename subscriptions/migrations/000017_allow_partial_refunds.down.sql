@@ -0,0 +1 @@
+DROP INDEX IF EXISTS refunds_payment_subscription_reason_idx;
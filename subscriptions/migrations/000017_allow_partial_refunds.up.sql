@@ -0,0 +1,4 @@
+-- a payment can be refunded in parts, e.g. a downgrade followed by a cancellation, so
+-- refunds are unique per payment, subscription and reason instead of per payment
+CREATE UNIQUE INDEX refunds_payment_subscription_reason_idx
+ON refunds (razorpay_payment_id, COALESCE(subscription_id, '00000000-0000-0000-0000-000000000000'::uuid), reason);
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS refunds_payment_subscription_reason_idx;
+CREATE UNIQUE INDEX refunds_payment_subscription_reason_idx
+ON refunds (razorpay_payment_id, COALESCE(subscription_id, '00000000-0000-0000-0000-000000000000'::uuid), reason);
@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS refunds_payment_subscription_reason_idx;
+-- refunds made on razorpay are reconciled one row each, so a payment can have several
+-- under the reconciliation reason; those are kept unique by razorpay_refund_id
+CREATE UNIQUE INDEX refunds_payment_subscription_reason_idx
+ON refunds (razorpay_payment_id, COALESCE(subscription_id, '00000000-0000-0000-0000-000000000000'::uuid), reason)
+WHERE reason <> 'reconciliation';
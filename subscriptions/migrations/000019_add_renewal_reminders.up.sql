@@ -0,0 +1,2 @@
+-- set once the reminder ahead of valid_until is queued, so it is sent once per subscription
+ALTER TABLE subscriptions ADD COLUMN renewal_reminder_sent_at TIMESTAMP WITH TIME ZONE;
@@ -0,0 +1,6 @@
+DELETE FROM subscription_trials WHERE user_id IS NULL OR subscription_id IS NULL;
+ALTER TABLE subscription_trials ALTER COLUMN subscription_id SET NOT NULL;
+ALTER TABLE subscription_trials ALTER COLUMN user_id SET NOT NULL;
+
+ALTER TABLE subscription_trials DROP CONSTRAINT IF EXISTS subscription_trials_user_id_hash_key;
+ALTER TABLE subscription_trials DROP COLUMN IF EXISTS user_id_hash;
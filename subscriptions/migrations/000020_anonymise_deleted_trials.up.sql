@@ -0,0 +1,9 @@
+-- deleting a user's data keeps their trial with only a hash of the user id, so the
+-- same user cannot start a second trial
+ALTER TABLE subscription_trials ADD COLUMN user_id_hash text;
+UPDATE subscription_trials SET user_id_hash = encode(sha256(convert_to(user_id::text, 'UTF8')), 'hex');
+ALTER TABLE subscription_trials ALTER COLUMN user_id_hash SET NOT NULL;
+ALTER TABLE subscription_trials ADD CONSTRAINT subscription_trials_user_id_hash_key UNIQUE (user_id_hash);
+
+ALTER TABLE subscription_trials ALTER COLUMN user_id DROP NOT NULL;
+ALTER TABLE subscription_trials ALTER COLUMN subscription_id DROP NOT NULL;
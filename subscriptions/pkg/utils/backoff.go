@@ -0,0 +1,33 @@
+package utils
+
+import (
+	"math/rand/v2"
+	"time"
+)
+
+// ExponentialBackoff returns base doubled for every attempt after the first, capped at max.
+func ExponentialBackoff(attempt int, base time.Duration, max time.Duration) time.Duration {
+	delay := base
+
+	for i := 1; i < attempt; i++ {
+		delay = delay * 2
+
+		if delay >= max {
+			return max
+		}
+	}
+
+	return delay
+}
+
+// Jitter returns a random duration between half of delay and delay, so clients that
+// failed together do not retry together.
+func Jitter(delay time.Duration) time.Duration {
+	if delay <= 1 {
+		return delay
+	}
+
+	half := delay / 2
+
+	return half + time.Duration(rand.Int64N(int64(delay-half)))
+}
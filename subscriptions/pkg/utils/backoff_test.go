@@ -0,0 +1,40 @@
+package utils
+
+import (
+	"testing"
+	"time"
+)
+
+func TestExponentialBackoff(t *testing.T) {
+	tests := []struct {
+		attempt int
+		want    time.Duration
+	}{
+		{attempt: 0, want: time.Second},
+		{attempt: 1, want: time.Second},
+		{attempt: 2, want: 2 * time.Second},
+		{attempt: 4, want: 8 * time.Second},
+		{attempt: 5, want: 10 * time.Second},
+		{attempt: 50, want: 10 * time.Second},
+	}
+
+	for _, tt := range tests {
+		if got := ExponentialBackoff(tt.attempt, time.Second, 10*time.Second); got != tt.want {
+			t.Errorf("ExponentialBackoff(%d) = %v, want %v", tt.attempt, got, tt.want)
+		}
+	}
+}
+
+func TestJitter(t *testing.T) {
+	tests := []time.Duration{0, 1, 2, time.Millisecond, time.Minute}
+
+	for _, delay := range tests {
+		for i := 0; i < 100; i++ {
+			got := Jitter(delay)
+
+			if got < delay/2 || got > delay {
+				t.Fatalf("Jitter(%v) = %v, want between %v and %v", delay, got, delay/2, delay)
+			}
+		}
+	}
+}
@@ -2,6 +2,8 @@ package utils
 
 import (
 	"encoding/json"
+	"math/big"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5/pgtype"
@@ -16,6 +18,39 @@ func ConvertGoogleUUIDToPgtypeUUID(u uuid.UUID) pgtype.UUID {
 	}
 }
 
+// ConvertPgtypeUUIDToGoogleUUID returns nil for a NULL uuid.
+func ConvertPgtypeUUIDToGoogleUUID(u pgtype.UUID) *uuid.UUID {
+	if !u.Valid {
+		return nil
+	}
+	value := uuid.UUID(u.Bytes)
+	return &value
+}
+
+// ConvertPgtypeTimestamptzToTime returns nil for a NULL or infinite timestamp.
+func ConvertPgtypeTimestamptzToTime(t pgtype.Timestamptz) *time.Time {
+	if !t.Valid || t.InfinityModifier != pgtype.Finite {
+		return nil
+	}
+	value := t.Time
+	return &value
+}
+
+func ConvertInt64ToPgtypeNumeric(n int64) pgtype.Numeric {
+	return pgtype.Numeric{
+		Int:   big.NewInt(n),
+		Valid: true,
+	}
+}
+
+func ConvertPgtypeNumericToInt64(n pgtype.Numeric) int64 {
+	value, err := n.Int64Value()
+	if err != nil || !value.Valid {
+		return 0
+	}
+	return value.Int64
+}
+
 func ConvertBytesToMap(byteData []byte) (dataMap, error) {
 	var result dataMap
 	err := json.Unmarshal(byteData, &result)
@@ -6,6 +6,7 @@ import (
 	"crypto/subtle"
 	"encoding/hex"
 	"errors"
+	"time"
 )
 
 func PaymentVerify(sign string, order_id string, payment_id string, secret string) error {
@@ -26,3 +27,50 @@ func PaymentVerify(sign string, order_id string, payment_id string, secret strin
 		return nil
 	}
 }
+
+// ProratedCredit returns the unused part of amount for a period running from
+// valid_from to valid_until, as seen at now. The result is in the same unit
+// as amount (paise) and is rounded down.
+func ProratedCredit(amount int64, valid_from time.Time, valid_until time.Time, now time.Time) int64 {
+	total := valid_until.Sub(valid_from)
+	remaining := valid_until.Sub(now)
+
+	if total <= 0 || remaining <= 0 {
+		return 0
+	}
+	if remaining > total {
+		return amount
+	}
+	return int64(float64(amount) * float64(remaining) / float64(total))
+}
+
+// CancellationRefund applies the refund policy to a cancelled period: the full
+// amount is returned within full_refund_days of valid_from, after that the
+// unused part is returned when prorate is set and nothing otherwise.
+func CancellationRefund(amount int64, valid_from time.Time, valid_until time.Time, now time.Time, full_refund_days int, prorate bool) int64 {
+	if now.Before(valid_from.AddDate(0, 0, full_refund_days)) {
+		return amount
+	}
+	if prorate {
+		return ProratedCredit(amount, valid_from, valid_until, now)
+	}
+	return 0
+}
+
+// WebhookVerify checks the X-Razorpay-Signature of a webhook, an HMAC SHA256 of the
+// raw request body keyed with the webhook secret.
+func WebhookVerify(body []byte, signature string, secret string) error {
+	if secret == "" {
+		return errors.New("Webhook secret is not configured")
+	}
+
+	h := hmac.New(sha256.New, []byte(secret))
+	h.Write(body)
+
+	sha := hex.EncodeToString(h.Sum(nil))
+	if subtle.ConstantTimeCompare([]byte(sha), []byte(signature)) != 1 {
+		return errors.New("Invalid webhook signature")
+	}
+
+	return nil
+}
@@ -0,0 +1,68 @@
+package utils
+
+import (
+	"testing"
+	"time"
+)
+
+func TestProratedCredit(t *testing.T) {
+	valid_from := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	valid_until := valid_from.AddDate(0, 0, 30)
+
+	tests := []struct {
+		name        string
+		amount      int64
+		valid_from  time.Time
+		valid_until time.Time
+		now         time.Time
+		want        int64
+	}{
+		{name: "unused period", amount: 29900, valid_from: valid_from, valid_until: valid_until, now: valid_from, want: 29900},
+		{name: "before the period starts", amount: 29900, valid_from: valid_from, valid_until: valid_until, now: valid_from.AddDate(0, 0, -5), want: 29900},
+		{name: "half used", amount: 30000, valid_from: valid_from, valid_until: valid_until, now: valid_from.AddDate(0, 0, 15), want: 15000},
+		{name: "rounded down", amount: 29900, valid_from: valid_from, valid_until: valid_until, now: valid_from.AddDate(0, 0, 10), want: 19933},
+		{name: "period over", amount: 29900, valid_from: valid_from, valid_until: valid_until, now: valid_until, want: 0},
+		{name: "after the period", amount: 29900, valid_from: valid_from, valid_until: valid_until, now: valid_until.AddDate(0, 0, 1), want: 0},
+		{name: "empty period", amount: 29900, valid_from: valid_from, valid_until: valid_from, now: valid_from, want: 0},
+		{name: "nothing paid", amount: 0, valid_from: valid_from, valid_until: valid_until, now: valid_from, want: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ProratedCredit(tt.amount, tt.valid_from, tt.valid_until, tt.now); got != tt.want {
+				t.Fatalf("ProratedCredit() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCancellationRefund(t *testing.T) {
+	valid_from := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	valid_until := valid_from.AddDate(0, 0, 30)
+
+	tests := []struct {
+		name             string
+		now              time.Time
+		full_refund_days int
+		prorate          bool
+		want             int64
+	}{
+		{name: "within the full refund window", now: valid_from.AddDate(0, 0, 2), full_refund_days: 7, want: 30000},
+		{name: "last moment of the window", now: valid_from.AddDate(0, 0, 7).Add(-time.Second), full_refund_days: 7, want: 30000},
+		{name: "window over, prorated", now: valid_from.AddDate(0, 0, 7), full_refund_days: 7, prorate: true, want: 23000},
+		{name: "window over, not prorated", now: valid_from.AddDate(0, 0, 7), full_refund_days: 7, want: 0},
+		{name: "no window, prorated", now: valid_from.AddDate(0, 0, 15), prorate: true, want: 15000},
+		{name: "no window, not prorated", now: valid_from, want: 0},
+		{name: "prorated after the period", now: valid_until, full_refund_days: 7, prorate: true, want: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := CancellationRefund(30000, valid_from, valid_until, tt.now, tt.full_refund_days, tt.prorate)
+
+			if got != tt.want {
+				t.Fatalf("CancellationRefund() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,16 @@
+package utils
+
+import "strings"
+
+// QueryName returns the name sqlc puts in the first line of each query, e.g.
+// GetRefundByID for "-- name: GetRefundByID :one". Other statements, like the ones
+// that begin and commit transactions, are named "other".
+func QueryName(sql string) string {
+	fields := strings.Fields(sql)
+
+	if len(fields) >= 3 && fields[0] == "--" && fields[1] == "name:" {
+		return fields[2]
+	}
+
+	return "other"
+}
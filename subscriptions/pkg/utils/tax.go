@@ -0,0 +1,60 @@
+package utils
+
+import (
+	"regexp"
+	"strings"
+)
+
+var gstinPattern = regexp.MustCompile(`^[0-9]{2}[A-Z]{5}[0-9]{4}[A-Z][1-9A-Z]Z[0-9A-Z]$`)
+
+type GSTBreakup struct {
+	TaxableAmount int64
+	CGST          int64
+	SGST          int64
+	IGST          int64
+}
+
+// IsValidGSTIN reports whether gstin has the shape of a GST identification number.
+func IsValidGSTIN(gstin string) bool {
+	return gstinPattern.MatchString(strings.ToUpper(gstin))
+}
+
+// SplitInclusiveGST splits a tax inclusive amount into its taxable value and GST.
+// Intra-state supplies are taxed as CGST + SGST in equal halves, inter-state supplies
+// as IGST. Amounts are in paise and rate is a percentage.
+func SplitInclusiveGST(amount int64, rate int64, intra_state bool) GSTBreakup {
+	if rate <= 0 {
+		return GSTBreakup{TaxableAmount: amount}
+	}
+
+	taxable := (amount*100 + (100+rate)/2) / (100 + rate)
+	tax := amount - taxable
+
+	if intra_state {
+		cgst := tax / 2
+		return GSTBreakup{TaxableAmount: taxable, CGST: cgst, SGST: tax - cgst}
+	}
+
+	return GSTBreakup{TaxableAmount: taxable, IGST: tax}
+}
+
+// ReverseGST splits a partial refund of an invoice into the taxable value and GST it
+// reverses, in the same proportion and under the same heads as the invoice.
+func ReverseGST(invoice GSTBreakup, invoice_total int64, amount int64) GSTBreakup {
+	if invoice_total <= 0 {
+		return GSTBreakup{TaxableAmount: amount}
+	}
+
+	taxable := (invoice.TaxableAmount*amount + invoice_total/2) / invoice_total
+	tax := amount - taxable
+
+	switch {
+	case invoice.IGST > 0:
+		return GSTBreakup{TaxableAmount: taxable, IGST: tax}
+	case invoice.CGST > 0 || invoice.SGST > 0:
+		cgst := tax / 2
+		return GSTBreakup{TaxableAmount: taxable, CGST: cgst, SGST: tax - cgst}
+	}
+
+	return GSTBreakup{TaxableAmount: amount}
+}
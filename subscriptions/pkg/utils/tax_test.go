@@ -0,0 +1,117 @@
+package utils
+
+import "testing"
+
+func TestSplitInclusiveGST(t *testing.T) {
+	tests := []struct {
+		name        string
+		amount      int64
+		rate        int64
+		intra_state bool
+		want        GSTBreakup
+	}{
+		{name: "even split", amount: 11800, rate: 18, intra_state: true, want: GSTBreakup{TaxableAmount: 10000, CGST: 900, SGST: 900}},
+		{name: "odd paise go to SGST", amount: 29900, rate: 18, intra_state: true, want: GSTBreakup{TaxableAmount: 25339, CGST: 2280, SGST: 2281}},
+		{name: "taxable value rounded up", amount: 100, rate: 18, intra_state: true, want: GSTBreakup{TaxableAmount: 85, CGST: 7, SGST: 8}},
+		{name: "inter-state", amount: 29900, rate: 18, want: GSTBreakup{TaxableAmount: 25339, IGST: 4561}},
+		{name: "too small to tax", amount: 1, rate: 18, intra_state: true, want: GSTBreakup{TaxableAmount: 1}},
+		{name: "no GST", amount: 29900, rate: 0, intra_state: true, want: GSTBreakup{TaxableAmount: 29900}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := SplitInclusiveGST(tt.amount, tt.rate, tt.intra_state)
+
+			if got != tt.want {
+				t.Fatalf("SplitInclusiveGST() = %+v, want %+v", got, tt.want)
+			}
+
+			if sum := got.TaxableAmount + got.CGST + got.SGST + got.IGST; sum != tt.amount {
+				t.Fatalf("SplitInclusiveGST() adds up to %d, want %d", sum, tt.amount)
+			}
+		})
+	}
+}
+
+func TestReverseGST(t *testing.T) {
+	intra_state := SplitInclusiveGST(29900, 18, true)
+	inter_state := SplitInclusiveGST(29900, 18, false)
+
+	tests := []struct {
+		name    string
+		invoice GSTBreakup
+		total   int64
+		amount  int64
+		want    GSTBreakup
+	}{
+		{name: "full refund", invoice: intra_state, total: 29900, amount: 29900, want: intra_state},
+		{name: "partial refund, odd paise go to SGST", invoice: intra_state, total: 29900, amount: 10000, want: GSTBreakup{TaxableAmount: 8475, CGST: 762, SGST: 763}},
+		{name: "partial refund, inter-state", invoice: inter_state, total: 29900, amount: 10000, want: GSTBreakup{TaxableAmount: 8475, IGST: 1525}},
+		{name: "untaxed invoice", invoice: GSTBreakup{TaxableAmount: 29900}, total: 29900, amount: 10000, want: GSTBreakup{TaxableAmount: 10000}},
+		{name: "empty invoice", invoice: GSTBreakup{}, total: 0, amount: 10000, want: GSTBreakup{TaxableAmount: 10000}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ReverseGST(tt.invoice, tt.total, tt.amount); got != tt.want {
+				t.Fatalf("ReverseGST() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReverseGSTPartialRefundsAddUpToInvoice(t *testing.T) {
+	tests := []struct {
+		name        string
+		total       int64
+		intra_state bool
+		refunds     []int64
+	}{
+		{name: "two refunds", total: 29900, intra_state: true, refunds: []int64{10000, 19900}},
+		{name: "three refunds", total: 29900, intra_state: true, refunds: []int64{9967, 9967, 9966}},
+		{name: "many small refunds", total: 100, intra_state: true, refunds: []int64{33, 33, 34}},
+		{name: "inter-state", total: 59900, refunds: []int64{12345, 23456, 24099}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			invoice := SplitInclusiveGST(tt.total, 18, tt.intra_state)
+
+			var reversed GSTBreakup
+			for _, amount := range tt.refunds {
+				credit_note := ReverseGST(invoice, tt.total, amount)
+
+				if sum := credit_note.TaxableAmount + credit_note.CGST + credit_note.SGST + credit_note.IGST; sum != amount {
+					t.Fatalf("ReverseGST(%d) adds up to %d", amount, sum)
+				}
+
+				reversed.TaxableAmount += credit_note.TaxableAmount
+				reversed.CGST += credit_note.CGST
+				reversed.SGST += credit_note.SGST
+				reversed.IGST += credit_note.IGST
+			}
+
+			// each credit note rounds on its own, so the total can be off by a paisa per note
+			drift := int64(len(tt.refunds))
+			invoice_tax := invoice.CGST + invoice.SGST + invoice.IGST
+			reversed_tax := reversed.CGST + reversed.SGST + reversed.IGST
+
+			if diff := reversed_tax - invoice_tax; diff > drift || diff < -drift {
+				t.Fatalf("credit notes reverse %d of tax, invoice charged %d", reversed_tax, invoice_tax)
+			}
+
+			for _, head := range []struct {
+				name               string
+				reversed, invoiced int64
+			}{
+				{"CGST", reversed.CGST, invoice.CGST},
+				{"SGST", reversed.SGST, invoice.SGST},
+				{"IGST", reversed.IGST, invoice.IGST},
+			} {
+				if diff := head.reversed - head.invoiced; diff > drift || diff < -drift {
+					t.Fatalf("credit notes reverse %d of %s, invoice charged %d", head.reversed, head.name, head.invoiced)
+				}
+			}
+		})
+	}
+}